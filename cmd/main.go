@@ -1,31 +1,140 @@
 package main
 
 import (
+	"context"
 	"github.com/joho/godotenv"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"usdt/config"
+	"usdt/internal/cli"
 	"usdt/internal/db"
+	"usdt/internal/gateway"
 	migrate "usdt/internal/infrastructure/db"
 	"usdt/internal/infrastructure/logger"
+	"usdt/internal/infrastructure/tlsconfig"
+	"usdt/internal/modules/interceptor"
+	"usdt/internal/modules/service"
+	"usdt/internal/modules/storage"
 	"usdt/run"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		err := cli.Export(ctx, os.Args[2:], os.Stdout)
+		stop()
+		if err != nil {
+			log.Fatalf("export: %v", err)
+		}
+		return
+	}
+
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Error loading .env file")
 	}
 	conf := config.NewConfig()
+	if len(os.Args) > 1 && (os.Args[1] == "import" || os.Args[1] == "keys") && conf.StorageBackend == config.StorageMemory {
+		// База в памяти исчезает вместе с процессом команды, и сервис её не увидит.
+		log.Fatalf("%s: %s=%s is not supported", os.Args[1], config.StorageBackend, config.StorageMemory)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(conf, os.Args[2:]); err != nil {
+			log.Fatalf("import: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		if err := runKeys(conf, os.Args[2:]); err != nil {
+			log.Fatalf("keys: %v", err)
+		}
+		return
+	}
 	logger, file := logger.NewLogger(conf)
 	defer file.Close()
 	defer logger.Sync()
 
-	grpcServer := grpc.NewServer()
+	switch conf.StorageBackend {
+	case config.StorageDB, config.StorageMemory:
+	default:
+		log.Fatalf("unknown storage backend: %s", conf.StorageBackend)
+	}
+	// База открывается до миграций: база SQLite в памяти (STORAGE_BACKEND=memory)
+	// существует, пока открыто хотя бы одно соединение с ней.
+	adapter, err := db.NewDB(conf)
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
 	err = migrate.RunMigrations(conf, logger)
 	if err != nil {
 		logger.Error(err.Error())
 	}
-	adapter, err := db.NewDB(conf)
+
+	var opts []grpc.ServerOption
+	if conf.TLS.Enabled() {
+		tlsConf, err := tlsconfig.Server(conf.TLS)
+		if err != nil {
+			log.Fatalf("failed to configure TLS: %v", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+	if conf.Auth.Enabled {
+		auth := interceptor.NewAuth(service.NewAPIKeyService(storage.NewAPIKeyStorage(adapter), conf.Auth.JWTSecret), logger)
+		opts = append(opts, grpc.ChainUnaryInterceptor(auth.Unary()), grpc.ChainStreamInterceptor(auth.Stream()))
+	}
+	// Ограничение ставится после аутентификации, чтобы считать запросы по ключу, а не по адресу.
+	var quota interceptor.QuotaConsumer
+	if conf.RateLimit.DailyQuota > 0 {
+		quota = service.NewQuotaService(storage.NewQuotaStorage(adapter), conf.RateLimit.DailyQuota)
+	}
+	if conf.GatewaySecret == "" {
+		// Шлюз работает в этом же процессе, поэтому секрет достаточно знать только ему.
+		if conf.GatewaySecret, err = gateway.NewSecret(); err != nil {
+			log.Fatalf("failed to configure gateway: %v", err)
+		}
+	}
+	limiter := interceptor.NewRateLimit(conf.RateLimit, quota, conf.GatewaySecret, logger)
+	opts = append(opts, grpc.ChainUnaryInterceptor(limiter.Unary()), grpc.ChainStreamInterceptor(limiter.Stream()))
+	grpcServer := grpc.NewServer(opts...)
+	if conf.Reflection {
+		reflection.Register(grpcServer)
+	}
 	run.Run(adapter, logger, conf, grpcServer)
 }
+
+// runImport загружает историю курсов из файла прямо в базу, минуя gRPC сервер.
+func runImport(conf config.Config, args []string) error {
+	if err := migrate.RunMigrations(conf, zap.NewNop()); err != nil {
+		return err
+	}
+	adapter, err := db.NewDB(conf)
+	if err != nil {
+		return err
+	}
+	defer adapter.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return cli.Import(ctx, args, os.Stdout, storage.NewUsdtStorage(adapter), storage.NewQuarantineStorage(adapter), conf)
+}
+
+// runKeys управляет API ключами прямо в базе, минуя gRPC сервер.
+func runKeys(conf config.Config, args []string) error {
+	if err := migrate.RunMigrations(conf, zap.NewNop()); err != nil {
+		return err
+	}
+	adapter, err := db.NewDB(conf)
+	if err != nil {
+		return err
+	}
+	defer adapter.Close()
+
+	keys := service.NewAPIKeyService(storage.NewAPIKeyStorage(adapter), conf.Auth.JWTSecret)
+	return cli.Keys(context.Background(), args, os.Stdout, keys)
+}
@@ -2,28 +2,392 @@ package config
 
 import (
 	"flag"
+	"math"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	AppName = "APP_NAME"
-	LogLvl  = "LOG_LEVEL"
-	Port    = "PORT"
+	AppName        = "APP_NAME"
+	LogLvl         = "LOG_LEVEL"
+	Port           = "PORT"
+	MigrationsPath = "MIGRATIONS_PATH"
+	QuoteTTL       = "QUOTE_TTL"
+	QuoteMarkup    = "QUOTE_MARKUP_PERCENT"
+	PollInterval   = "POLL_INTERVAL"
+	PollCurrencies = "POLL_CURRENCIES"
+	AlertAttempts  = "ALERT_MAX_ATTEMPTS"
+	AlertBackoff   = "ALERT_RETRY_BACKOFF"
+	WebhookTimeout = "WEBHOOK_TIMEOUT"
+	WebhookPrivate = "ALERT_WEBHOOK_ALLOW_PRIVATE"
+	MaxJumpPercent = "VALIDATION_MAX_JUMP_PERCENT"
+	JumpWindow     = "VALIDATION_JUMP_WINDOW"
+	MaxSnapshotAge = "VALIDATION_MAX_AGE"
+	ArbInterval    = "ARBITRAGE_INTERVAL"
+	ArbFees        = "ARBITRAGE_FEES"
+	ArbVenues      = "ARBITRAGE_VENUES"
+	MetricsPort    = "METRICS_PORT"
+	GatewayPort    = "GATEWAY_PORT"
+	GatewaySecret  = "GATEWAY_SECRET"
+	Reflection     = "GRPC_REFLECTION"
+	RetInterval    = "RETENTION_INTERVAL"
+	RetRaw         = "RETENTION_RAW"
+	RetMinute      = "RETENTION_MINUTE"
+	RetHour        = "RETENTION_HOUR"
+	RetBatchSize   = "RETENTION_BATCH_SIZE"
+	PartInterval   = "PARTITION_INTERVAL"
+	PartAhead      = "PARTITION_MONTHS_AHEAD"
+	PartRetention  = "PARTITION_RETENTION"
+	BatchSize      = "WRITE_BATCH_SIZE"
+	BatchInterval  = "WRITE_FLUSH_INTERVAL"
+	BatchMax       = "WRITE_MAX_BUFFERED"
+	StorageBackend = "STORAGE_BACKEND"
+	DbDriver       = "DB_DRIVER"
+	DbPath         = "DB_PATH"
+	OutboxEnabled  = "OUTBOX_ENABLED"
+	OutboxSink     = "OUTBOX_SINK"
+	OutboxURL      = "OUTBOX_WEBHOOK_URL"
+	OutboxSecret   = "OUTBOX_WEBHOOK_SECRET"
+	OutboxFile     = "OUTBOX_FILE_PATH"
+	OutboxInterval = "OUTBOX_INTERVAL"
+	OutboxBatch    = "OUTBOX_BATCH_SIZE"
+	OutboxBackoff  = "OUTBOX_RETRY_BACKOFF"
+	OutboxKeep     = "OUTBOX_RETENTION"
+	ExportPageSize = "EXPORT_PAGE_SIZE"
+	ImportBatch    = "IMPORT_BATCH_SIZE"
+	TLSCert        = "TLS_CERT_FILE"
+	TLSKey         = "TLS_KEY_FILE"
+	TLSMinVersion  = "TLS_MIN_VERSION"
+	TLSClientCA    = "TLS_CLIENT_CA_FILE"
+	TLSGatewayCert = "TLS_GATEWAY_CERT_FILE"
+	TLSGatewayKey  = "TLS_GATEWAY_KEY_FILE"
+	AuthEnabled    = "AUTH_ENABLED"
+	AuthJWTSecret  = "AUTH_JWT_SECRET"
+	LimitRPS       = "RATE_LIMIT_RPS"
+	LimitBurst     = "RATE_LIMIT_BURST"
+	LimitMethods   = "RATE_LIMIT_METHODS"
+	LimitQuota     = "RATE_LIMIT_DAILY_QUOTA"
+	LimitFailOpen  = "RATE_LIMIT_QUOTA_FAIL_OPEN"
+	ExLimits       = "EXCHANGE_RATE_LIMITS"
+	ExAttempts     = "EXCHANGE_MAX_ATTEMPTS"
+	ExBackoff      = "EXCHANGE_RETRY_BACKOFF"
+	ExMaxBackoff   = "EXCHANGE_MAX_BACKOFF"
+	ExMode         = "EXCHANGE_MODE"
+	ExStreamURL    = "EXCHANGE_STREAM_URL"
+	ExStreamRead   = "EXCHANGE_STREAM_READ_TIMEOUT"
+	ExStreamMin    = "EXCHANGE_STREAM_MIN_INTERVAL"
+	ExRecordFile   = "EXCHANGE_RECORD_FILE"
+	ExReplayFile   = "EXCHANGE_REPLAY_FILE"
+	ExReplayFrom   = "EXCHANGE_REPLAY_FROM"
+	ExReplaySpeed  = "EXCHANGE_REPLAY_SPEED"
+	SimSeed        = "SIM_SEED"
+	SimPrices      = "SIM_PRICES"
+	SimVolatility  = "SIM_VOLATILITY"
+	SimSpread      = "SIM_SPREAD_PERCENT"
+	SimDepth       = "SIM_DEPTH"
+	SimVolume      = "SIM_LEVEL_VOLUME"
+	SimLatency     = "SIM_LATENCY"
+	SimErrorRate   = "SIM_ERROR_RATE"
+	SimCrossedRate = "SIM_CROSSED_RATE"
+	HTTPProxy      = "EXCHANGE_PROXY_URL"
+	HTTPCAFile     = "EXCHANGE_CA_FILE"
+	HTTPDial       = "EXCHANGE_DIAL_TIMEOUT"
+	HTTPHandshake  = "EXCHANGE_TLS_TIMEOUT"
+	HTTPResponse   = "EXCHANGE_RESPONSE_TIMEOUT"
+	HTTPRequest    = "EXCHANGE_REQUEST_TIMEOUT"
+	HTTPIdle       = "EXCHANGE_IDLE_TIMEOUT"
+	HTTPMaxIdle    = "EXCHANGE_MAX_IDLE_CONNS"
+	HTTPMaxPerHost = "EXCHANGE_MAX_CONNS_PER_HOST"
 )
 
+// Драйверы базы данных.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Приёмники событий outbox.
+const (
+	SinkWebhook = "webhook"
+	SinkFile    = "file"
+)
+
+// Режимы получения стаканов биржи.
+const (
+	ExchangePoll   = "poll"
+	ExchangeStream = "stream"
+	ExchangeReplay = "replay"
+	ExchangeSim    = "simulate"
+)
+
+// Бэкенды хранения курсов.
+const (
+	StorageDB     = "db"
+	StorageMemory = "memory"
+)
+
+// MemoryDBPath - база SQLite в памяти процесса, в которой при StorageMemory
+// хранятся котировки, оповещения, ключи и остальные данные кроме курсов.
+// Общий кэш нужен, чтобы миграции и сервис работали с одной базой.
+const MemoryDBPath = "usdt?mode=memory&cache=shared"
+
 type Config struct {
-	AppName string
-	LogLvl  string
-	Port    string
-	Db      DB
+	AppName        string
+	LogLvl         string
+	Port           string
+	MetricsPort    string
+	GatewayPort    string
+	MigrationsPath string
+	// GatewaySecret - секрет, которым REST шлюз подтверждает переданный им
+	// адрес клиента; пустой генерируется при запуске.
+	GatewaySecret string
+	// Reflection включает сервис gRPC reflection для grpcurl и Postman.
+	Reflection bool
+	// StorageBackend - где хранятся курсы: StorageDB или StorageMemory. При
+	// StorageMemory остальные данные хранятся в базе SQLite MemoryDBPath.
+	StorageBackend string
+	Db             DB
+	Quote          Quote
+	Poll           Poll
+	Alert          Alert
+	Validation     Validation
+	Arbitrage      Arbitrage
+	Retention      Retention
+	Partition      Partition
+	Batch          Batch
+	Outbox         Outbox
+	Export         Export
+	Import         Import
+	TLS            TLS
+	Auth           Auth
+	RateLimit      RateLimit
+	Exchange       Exchange
+	HTTPClient     HTTPClient
+	Simulation     Simulation
 }
 
+// DB - подключение к базе. Для DriverSQLite используется только Path -
+// путь к файлу базы.
 type DB struct {
+	Driver   string
 	User     string
 	Password string
 	Host     string
 	Port     string
 	Database string
+	Path     string
+}
+
+// Quote - параметры фиксированных котировок.
+type Quote struct {
+	TTL           time.Duration
+	MarkupPercent float64
+}
+
+// Poll - параметры периодического опроса биржи.
+type Poll struct {
+	Interval   time.Duration
+	Currencies []string
+}
+
+// Alert - параметры доставки вебхуков по правилам оповещения.
+type Alert struct {
+	MaxAttempts    int
+	RetryBackoff   time.Duration
+	WebhookTimeout time.Duration
+	// AllowPrivate разрешает вебхуки на loopback и адреса внутренней сети.
+	AllowPrivate bool
+}
+
+// Validation - пороги проверки снимков биржи перед сохранением.
+// JumpWindow ограничивает, насколько старый сохранённый курс используется
+// для проверки скачка цены.
+type Validation struct {
+	MaxJumpPercent float64
+	JumpWindow     time.Duration
+	MaxAge         time.Duration
+}
+
+// Arbitrage - параметры поиска арбитража между биржами.
+// Venues - биржи, между которыми ищется арбитраж: garantex, garantex-replay,
+// simulator и simulator:<seed> - ещё одна синтетическая биржа со своим seed.
+// Пустой список отключает поиск. Fees - комиссия тейкера в процентах по
+// имени биржи.
+type Arbitrage struct {
+	Interval time.Duration
+	Venues   []string
+	Fees     map[string]float64
+}
+
+// Retention - политика хранения курсов: сырые снимки хранятся Raw, затем
+// переносятся в минутные и часовые агрегаты, которые хранятся Minute и Hour.
+// Нулевой срок хранения агрегатов означает "хранить всегда", а нулевые
+// Interval или Raw отключают очистку: выгрузка и импорт работают только с
+// сырыми снимками.
+type Retention struct {
+	Interval  time.Duration
+	Raw       time.Duration
+	Minute    time.Duration
+	Hour      time.Duration
+	BatchSize int
+}
+
+// Partition - обслуживание месячных секций currency_rates: секции создаются
+// на MonthsAhead месяцев вперёд, а секции, целиком старше Retention,
+// отсоединяются и удаляются. Нулевой Retention отключает удаление.
+type Partition struct {
+	Interval    time.Duration
+	MonthsAhead int
+	Retention   time.Duration
+}
+
+// Batch - пакетная запись курсов опроса: буфер сбрасывается при Size строках
+// или раз в Interval. Size не больше 1 отключает буферизацию.
+type Batch struct {
+	Size        int
+	Interval    time.Duration
+	MaxBuffered int
+}
+
+// Outbox - события об изменении курсов. При Enabled события пишутся в
+// таблицу outbox в одной транзакции с курсом, а релей каждые Interval
+// публикует до BatchSize событий в приёмник Sink. Неудачные публикации
+// повторяются с экспоненциальной задержкой от RetryBackoff; опубликованные
+// события удаляются через Retention.
+type Outbox struct {
+	Enabled       bool
+	Sink          string
+	WebhookURL    string
+	WebhookSecret string
+	FilePath      string
+	Interval      time.Duration
+	BatchSize     int
+	RetryBackoff  time.Duration
+	Retention     time.Duration
+}
+
+// Export - выгрузка истории курсов: строки читаются из базы страницами по
+// PageSize.
+type Export struct {
+	PageSize int
+}
+
+// Import - загрузка истории курсов из файла: строки проверяются и пишутся
+// пакетами по BatchSize.
+type Import struct {
+	BatchSize int
+}
+
+// TLS - шифрование gRPC сервера. Без CertFile сервер слушает без TLS.
+// С ClientCAFile сервер требует клиентский сертификат, подписанный одним из
+// CA этого файла; REST шлюз тогда подключается с GatewayCertFile и
+// GatewayKeyFile. Сертификаты и CA перечитываются при изменении файлов.
+type TLS struct {
+	CertFile        string
+	KeyFile         string
+	MinVersion      string
+	ClientCAFile    string
+	GatewayCertFile string
+	GatewayKeyFile  string
+}
+
+// Enabled сообщает, слушает ли gRPC сервер по TLS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Auth - аутентификация клиентов gRPC API. При Enabled методы, кроме
+// HealthCheck, требуют API ключ или JWT, подписанный JWTSecret; без
+// JWTSecret принимаются только API ключи.
+type Auth struct {
+	Enabled   bool
+	JWTSecret string
+}
+
+// Limit - ограничение частоты запросов: RPS запросов в секунду в среднем и
+// до Burst подряд. Нулевой RPS снимает ограничение.
+type Limit struct {
+	RPS   float64
+	Burst int
+}
+
+// RateLimit - ограничения запросов одного клиента (API ключа, сертификата
+// или адреса). Default действует на все методы вместе, Methods - на
+// отдельные методы по короткому имени вместо Default. DailyQuota - запросов
+// клиента за сутки UTC, 0 - без квоты. QuotaFailOpen пропускает запросы,
+// когда квоту не удалось проверить; иначе они отклоняются.
+type RateLimit struct {
+	Default       Limit
+	Methods       map[string]Limit
+	DailyQuota    int64
+	QuotaFailOpen bool
+}
+
+// Exchange - запросы к биржам. Limits - лимиты исходящих запросов по имени
+// биржи, общие для всех обращений к ней. Сетевые ошибки и ответы 429 и 5xx
+// повторяются до MaxAttempts попыток с экспоненциальной задержкой от
+// RetryBackoff до MaxBackoff, но не дольше дедлайна вызывающего.
+// В режиме ExchangeStream стаканы ведутся по подписке WebSocket на StreamURL
+// (обязателен: протокол потока не сверен с биржей);
+// соединение без сообщений дольше StreamReadTimeout переподключается с той
+// же задержкой, что и повторы; курс пары по изменениям стакана сохраняется
+// не чаще раза в StreamMinInterval. RecordFile дописывает HTTP обмены с биржей в
+// файл; в режиме ExchangeReplay биржа не вызывается, а стаканы берутся из
+// ReplayFile начиная с ReplayFrom (нулевое - с первой записи) со скоростью
+// ReplaySpeed.
+type Exchange struct {
+	Mode              string
+	Limits            map[string]Limit
+	MaxAttempts       int
+	RetryBackoff      time.Duration
+	MaxBackoff        time.Duration
+	StreamURL         string
+	StreamReadTimeout time.Duration
+	StreamMinInterval time.Duration
+	RecordFile        string
+	ReplayFile        string
+	ReplayFrom        time.Time
+	ReplaySpeed       float64
+}
+
+// HTTPClient - общий HTTP клиент запросов к биржам. ProxyURL - прокси
+// http, https или socks5; без него действуют HTTPS_PROXY и NO_PROXY.
+// CAFile добавляет CA к системным. DialTimeout, TLSHandshakeTimeout и
+// ResponseHeaderTimeout ограничивают фазы запроса, RequestTimeout - одну
+// попытку целиком. Пул держит до MaxIdleConns простаивающих соединений,
+// к одной бирже - до MaxConnsPerHost; простаивающие дольше IdleConnTimeout
+// закрываются.
+type HTTPClient struct {
+	ProxyURL              string
+	CAFile                string
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	RequestTimeout        time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConns          int
+	MaxConnsPerHost       int
+}
+
+// Simulation - синтетическая биржа режима ExchangeSim. Цена пары начинается с
+// Prices и на каждый запрос меняется логнормальным шагом с Volatility
+// (стандартное отклонение логарифма цены за шаг); стакан из Depth уровней на
+// сторону строится со спредом SpreadPercent и объёмами вокруг LevelVolume.
+// Одинаковый Seed даёт одинаковые стаканы. Сбои: задержка ответа до Latency,
+// ошибка с вероятностью ErrorRate, перевёрнутый стакан с вероятностью
+// CrossedRate.
+type Simulation struct {
+	Seed          int64
+	Prices        map[string]float64
+	Volatility    float64
+	SpreadPercent float64
+	Depth         int
+	LevelVolume   float64
+	Latency       time.Duration
+	ErrorRate     float64
+	CrossedRate   float64
 }
 
 var (
@@ -43,16 +407,153 @@ func NewConfig() Config {
 	flag.StringVar(&dbDatabase, "db-database", "", "Название базы данных")
 	flag.Parse()
 
+	storageBackend := getEnvOrDefault(StorageBackend, StorageDB)
+	driver := getEnvOrDefault(DbDriver, DriverPostgres)
+	dbPath := getEnvOrDefault(DbPath, "usdt.db")
+	if storageBackend == StorageMemory {
+		driver, dbPath = DriverSQLite, MemoryDBPath
+	}
+	migrationsPath := "file:///app/migrations"
+	if driver == DriverSQLite {
+		migrationsPath = "file:///app/migrations_sqlite"
+	}
+
 	return Config{
-		AppName: getEnvOrDefault(AppName, "usdt-rate-service"),
-		LogLvl:  getEnvOrDefault(LogLvl, "info"),
-		Port:    getEnvOrDefault(Port, "50051"),
+		AppName:        getEnvOrDefault(AppName, "usdt-rate-service"),
+		LogLvl:         getEnvOrDefault(LogLvl, "info"),
+		Port:           getEnvOrDefault(Port, "50051"),
+		MetricsPort:    getEnvOrDefault(MetricsPort, "9090"),
+		GatewayPort:    getEnvOrDefault(GatewayPort, "8080"),
+		GatewaySecret:  getEnvOrDefault(GatewaySecret, ""),
+		Reflection:     getEnvBoolOrDefault(Reflection, false),
+		MigrationsPath: getEnvOrDefault(MigrationsPath, migrationsPath),
+		StorageBackend: storageBackend,
 		Db: DB{
+			Driver:   driver,
 			User:     getEnvOrDefault("DB_USER", dbUser),
 			Password: getEnvOrDefault("DB_PASSWORD", dbPassword),
 			Host:     getEnvOrDefault("DB_HOST", dbHost),
 			Port:     getEnvOrDefault("DB_PORT", dbPort),
 			Database: getEnvOrDefault("DB_DATABASE", dbDatabase),
+			Path:     dbPath,
+		},
+		Quote: Quote{
+			TTL:           getEnvDurationOrDefault(QuoteTTL, 30*time.Second),
+			MarkupPercent: getEnvFloatOrDefault(QuoteMarkup, 0.5),
+		},
+		Poll: Poll{
+			Interval:   getEnvDurationOrDefault(PollInterval, 10*time.Second),
+			Currencies: getEnvListOrDefault(PollCurrencies, []string{"RUB", "USD", "EUR", "KGS"}),
+		},
+		Alert: Alert{
+			MaxAttempts:    getEnvIntOrDefault(AlertAttempts, 5),
+			RetryBackoff:   getEnvDurationOrDefault(AlertBackoff, time.Second),
+			WebhookTimeout: getEnvDurationOrDefault(WebhookTimeout, 5*time.Second),
+			AllowPrivate:   getEnvBoolOrDefault(WebhookPrivate, false),
+		},
+		Validation: Validation{
+			MaxJumpPercent: getEnvFloatOrDefault(MaxJumpPercent, 20),
+			JumpWindow:     getEnvDurationOrDefault(JumpWindow, time.Hour),
+			MaxAge:         getEnvDurationOrDefault(MaxSnapshotAge, 5*time.Minute),
+		},
+		Arbitrage: Arbitrage{
+			Interval: getEnvDurationOrDefault(ArbInterval, 10*time.Second),
+			Venues:   getEnvListOrDefault(ArbVenues, nil),
+			Fees:     getEnvFloatMapOrDefault(ArbFees, map[string]float64{"garantex": 0.15}),
+		},
+		Retention: Retention{
+			Interval:  getEnvDurationOrDefault(RetInterval, 0),
+			Raw:       getEnvDurationOrDefault(RetRaw, 0),
+			Minute:    getEnvDurationOrDefault(RetMinute, 30*24*time.Hour),
+			Hour:      getEnvDurationOrDefault(RetHour, 0),
+			BatchSize: getEnvIntOrDefault(RetBatchSize, 5000),
+		},
+		Partition: Partition{
+			Interval:    getEnvDurationOrDefault(PartInterval, 24*time.Hour),
+			MonthsAhead: getEnvIntOrDefault(PartAhead, 3),
+			Retention:   getEnvDurationOrDefault(PartRetention, 0),
+		},
+		Batch: Batch{
+			Size:        getEnvIntOrDefault(BatchSize, 1),
+			Interval:    getEnvDurationOrDefault(BatchInterval, time.Second),
+			MaxBuffered: getEnvIntOrDefault(BatchMax, 10000),
+		},
+		Outbox: Outbox{
+			Enabled:       getEnvBoolOrDefault(OutboxEnabled, false),
+			Sink:          getEnvOrDefault(OutboxSink, SinkWebhook),
+			WebhookURL:    getEnvOrDefault(OutboxURL, ""),
+			WebhookSecret: getEnvOrDefault(OutboxSecret, ""),
+			FilePath:      getEnvOrDefault(OutboxFile, "outbox.jsonl"),
+			Interval:      getEnvDurationOrDefault(OutboxInterval, time.Second),
+			BatchSize:     getEnvIntOrDefault(OutboxBatch, 100),
+			RetryBackoff:  getEnvDurationOrDefault(OutboxBackoff, time.Second),
+			Retention:     getEnvDurationOrDefault(OutboxKeep, 24*time.Hour),
+		},
+		Export: Export{
+			PageSize: getEnvIntOrDefault(ExportPageSize, 5000),
+		},
+		Import: Import{
+			BatchSize: getEnvIntOrDefault(ImportBatch, 1000),
+		},
+		TLS: TLS{
+			CertFile:        getEnvOrDefault(TLSCert, ""),
+			KeyFile:         getEnvOrDefault(TLSKey, ""),
+			MinVersion:      getEnvOrDefault(TLSMinVersion, "1.2"),
+			ClientCAFile:    getEnvOrDefault(TLSClientCA, ""),
+			GatewayCertFile: getEnvOrDefault(TLSGatewayCert, ""),
+			GatewayKeyFile:  getEnvOrDefault(TLSGatewayKey, ""),
+		},
+		Auth: Auth{
+			Enabled:   getEnvBoolOrDefault(AuthEnabled, false),
+			JWTSecret: getEnvOrDefault(AuthJWTSecret, ""),
+		},
+		RateLimit: RateLimit{
+			Default: Limit{
+				RPS:   getEnvFloatOrDefault(LimitRPS, 20),
+				Burst: getEnvIntOrDefault(LimitBurst, 40),
+			},
+			Methods: getEnvLimitMapOrDefault(LimitMethods, map[string]Limit{
+				"GetRates":    {RPS: 5, Burst: 10},
+				"ExportRates": {RPS: 0.2, Burst: 1},
+			}),
+			DailyQuota:    int64(getEnvIntOrDefault(LimitQuota, 0)),
+			QuotaFailOpen: getEnvBoolOrDefault(LimitFailOpen, true),
+		},
+		Exchange: Exchange{
+			Mode:              getEnvOrDefault(ExMode, ExchangePoll),
+			Limits:            getEnvLimitMapOrDefault(ExLimits, map[string]Limit{"garantex": {RPS: 5, Burst: 10}}),
+			MaxAttempts:       getEnvIntOrDefault(ExAttempts, 3),
+			RetryBackoff:      getEnvDurationOrDefault(ExBackoff, 200*time.Millisecond),
+			MaxBackoff:        getEnvDurationOrDefault(ExMaxBackoff, 5*time.Second),
+			StreamURL:         getEnvOrDefault(ExStreamURL, ""),
+			StreamReadTimeout: getEnvDurationOrDefault(ExStreamRead, 30*time.Second),
+			StreamMinInterval: getEnvDurationOrDefault(ExStreamMin, time.Second),
+			RecordFile:        getEnvOrDefault(ExRecordFile, ""),
+			ReplayFile:        getEnvOrDefault(ExReplayFile, ""),
+			ReplayFrom:        getEnvTimeOrDefault(ExReplayFrom, time.Time{}),
+			ReplaySpeed:       getEnvFloatOrDefault(ExReplaySpeed, 1),
+		},
+		Simulation: Simulation{
+			Seed:          int64(getEnvIntOrDefault(SimSeed, 1)),
+			Prices:        getEnvFloatMapOrDefault(SimPrices, map[string]float64{"RUB": 95, "USD": 1, "EUR": 0.92, "KGS": 86}),
+			Volatility:    getEnvFloatOrDefault(SimVolatility, 0.001),
+			SpreadPercent: getEnvFloatOrDefault(SimSpread, 0.2),
+			Depth:         getEnvIntOrDefault(SimDepth, 10),
+			LevelVolume:   getEnvFloatOrDefault(SimVolume, 1000),
+			Latency:       getEnvDurationOrDefault(SimLatency, 0),
+			ErrorRate:     getEnvFloatOrDefault(SimErrorRate, 0),
+			CrossedRate:   getEnvFloatOrDefault(SimCrossedRate, 0),
+		},
+		HTTPClient: HTTPClient{
+			ProxyURL:              getEnvOrDefault(HTTPProxy, ""),
+			CAFile:                getEnvOrDefault(HTTPCAFile, ""),
+			DialTimeout:           getEnvDurationOrDefault(HTTPDial, 5*time.Second),
+			TLSHandshakeTimeout:   getEnvDurationOrDefault(HTTPHandshake, 5*time.Second),
+			ResponseHeaderTimeout: getEnvDurationOrDefault(HTTPResponse, 5*time.Second),
+			RequestTimeout:        getEnvDurationOrDefault(HTTPRequest, 10*time.Second),
+			IdleConnTimeout:       getEnvDurationOrDefault(HTTPIdle, 90*time.Second),
+			MaxIdleConns:          getEnvIntOrDefault(HTTPMaxIdle, 100),
+			MaxConnsPerHost:       getEnvIntOrDefault(HTTPMaxPerHost, 10),
 		},
 	}
 }
@@ -64,3 +565,105 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvTimeOrDefault разбирает время в формате RFC 3339.
+func getEnvTimeOrDefault(key string, defaultValue time.Time) time.Time {
+	value, err := time.Parse(time.RFC3339, os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getEnvFloatMapOrDefault разбирает значение вида "name=1.5,other=0.2".
+func getEnvFloatMapOrDefault(key string, defaultValue map[string]float64) map[string]float64 {
+	values := make(map[string]float64)
+	for _, item := range getEnvListOrDefault(key, nil) {
+		name, raw, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getEnvLimitMapOrDefault разбирает значение вида "GetRates=5:10,ExportRates=0.2";
+// без ":burst" запас равен округлённому вверх RPS, но не меньше 1.
+func getEnvLimitMapOrDefault(key string, defaultValue map[string]Limit) map[string]Limit {
+	values := make(map[string]Limit)
+	for _, item := range getEnvListOrDefault(key, nil) {
+		name, raw, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		rawRPS, rawBurst, hasBurst := strings.Cut(raw, ":")
+		rps, err := strconv.ParseFloat(strings.TrimSpace(rawRPS), 64)
+		if err != nil {
+			continue
+		}
+		burst := max(1, int(math.Ceil(rps)))
+		if hasBurst {
+			if burst, err = strconv.Atoi(strings.TrimSpace(rawBurst)); err != nil {
+				continue
+			}
+		}
+		values[strings.TrimSpace(name)] = Limit{RPS: rps, Burst: burst}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
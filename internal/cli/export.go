@@ -0,0 +1,109 @@
+// Package cli содержит подкоманды исполняемого файла сервиса.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"usdt/internal/infrastructure/ratefile"
+	"usdt/internal/infrastructure/tlsconfig"
+	"usdt/internal/modules/interceptor"
+	"usdt/internal/proto/usdt_proto"
+)
+
+// Export выполняет подкоманду export: выгружает курсы через RPC ExportRates
+// в файл или в stdout. Файл пишется во временный "<out>.part" и
+// переименовывается только после успешной выгрузки.
+func Export(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:50051", "Адрес gRPC сервера")
+	currencies := flags.String("currencies", "", "Валюты через запятую, например RUB,USD")
+	from := flags.String("from", "", "Начало периода в RFC3339")
+	to := flags.String("to", "", "Конец периода в RFC3339, по умолчанию - сейчас")
+	format := flags.String("format", ratefile.CSV, "Формат: csv, jsonl или parquet")
+	out := flags.String("out", "-", "Файл выгрузки, - для stdout")
+	apiKey := flags.String("api-key", "", "API ключ при включённой аутентификации")
+	useTLS := flags.Bool("tls", false, "Подключаться по TLS")
+	caFile := flags.String("ca", "", "CA для проверки сервера, по умолчанию - системные")
+	certFile := flags.String("cert", "", "Клиентский сертификат для взаимного TLS")
+	keyFile := flags.String("key", "", "Ключ клиентского сертификата")
+	serverName := flags.String("server-name", "", "Имя сервера в сертификате, если отличается от адреса")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	req := &usdt_proto.ExportRatesRequest{From: *from, To: *to, Format: *format}
+	for _, currency := range strings.Split(*currencies, ",") {
+		if currency = strings.TrimSpace(currency); currency != "" {
+			req.TargetCurrencies = append(req.TargetCurrencies, currency)
+		}
+	}
+	if len(req.TargetCurrencies) == 0 || *from == "" {
+		return errors.New("нужно указать -currencies и -from")
+	}
+
+	creds := insecure.NewCredentials()
+	if *useTLS {
+		tlsConf, err := tlsconfig.Client(*caFile, *certFile, *keyFile, *serverName)
+		if err != nil {
+			return fmt.Errorf("не удалось настроить TLS: %w", err)
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("не удалось подключиться к %s: %w", *addr, err)
+	}
+	defer conn.Close()
+	if *apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, interceptor.APIKeyHeader, *apiKey)
+	}
+	stream, err := usdt_proto.NewExportServiceClient(conn).ExportRates(ctx, req)
+	if err != nil {
+		return fmt.Errorf("не удалось начать выгрузку: %w", err)
+	}
+
+	if *out == "-" {
+		return receiveExport(stream, stdout)
+	}
+	part := *out + ".part"
+	file, err := os.Create(part)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл выгрузки: %w", err)
+	}
+	err = receiveExport(stream, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(part)
+		return err
+	}
+	if err := os.Rename(part, *out); err != nil {
+		return fmt.Errorf("не удалось сохранить файл выгрузки: %w", err)
+	}
+	return nil
+}
+
+func receiveExport(stream grpc.ServerStreamingClient[usdt_proto.ExportRatesChunk], w io.Writer) error {
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("выгрузка прервана: %w", err)
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return fmt.Errorf("не удалось записать выгрузку: %w", err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"usdt/internal/db"
+	"usdt/internal/models"
+	"usdt/internal/modules/controller"
+	"usdt/internal/modules/service"
+	"usdt/internal/modules/storage"
+	"usdt/internal/proto/usdt_proto"
+)
+
+// startExportServer поднимает ExportService поверх хранилища в памяти и
+// возвращает его адрес.
+func startExportServer(t *testing.T, rates []models.CurrencyRate) string {
+	t.Helper()
+	adapter := db.NewMemoryAdapter()
+	require.NoError(t, adapter.CreateCurrencyRates(context.Background(), rates))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	exportService := service.NewExportService(storage.NewUsdtStorage(adapter), 2)
+	usdt_proto.RegisterExportServiceServer(server, controller.NewExportController(exportService, zap.NewNop()))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestExport(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var rates []models.CurrencyRate
+	for i := 0; i < 5; i++ {
+		rates = append(rates, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	rates = append(rates, models.CurrencyRate{Pair: "USDT/USD", AskPrice: 1.01, BidPrice: 1, Timestamp: start})
+	addr := startExportServer(t, rates)
+	ctx := context.Background()
+
+	t.Run("File", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "rates.jsonl")
+		err := Export(ctx, []string{"-addr", addr, "-currencies", "RUB,USD", "-format", "jsonl",
+			"-from", start.Format(time.RFC3339), "-to", start.Add(3 * time.Minute).Format(time.RFC3339), "-out", out}, nil)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		assert.Len(t, lines, 5)
+		assert.Contains(t, lines[4], `"pair":"USDT/USD"`)
+		_, err = os.Stat(out + ".part")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Stdout", func(t *testing.T) {
+		var stdout strings.Builder
+		err := Export(ctx, []string{"-addr", addr, "-currencies", "RUB", "-from", start.Format(time.RFC3339)}, &stdout)
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(stdout.String()), "\n"), 6)
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "rates.xlsx")
+		err := Export(ctx, []string{"-addr", addr, "-currencies", "RUB", "-from", start.Format(time.RFC3339),
+			"-format", "xlsx", "-out", out}, nil)
+		assert.Error(t, err)
+		entries, _ := os.ReadDir(filepath.Dir(out))
+		assert.Empty(t, entries)
+	})
+
+	t.Run("TLSConfigError", func(t *testing.T) {
+		err := Export(ctx, []string{"-addr", addr, "-currencies", "RUB", "-from", start.Format(time.RFC3339),
+			"-tls", "-ca", filepath.Join(t.TempDir(), "missing.pem")}, nil)
+		assert.ErrorContains(t, err, "TLS")
+	})
+
+	t.Run("MissingFlags", func(t *testing.T) {
+		assert.Error(t, Export(ctx, []string{"-addr", addr, "-currencies", "RUB"}, nil))
+	})
+}
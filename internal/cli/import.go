@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"usdt/config"
+	"usdt/internal/infrastructure/ratefile"
+	"usdt/internal/models"
+	"usdt/internal/modules/service"
+)
+
+// Import выполняет подкоманду import: загружает историю курсов из CSV или
+// JSON Lines в хранилище storage, а отклонённые строки откладывает в карантин
+// quarantine. Ход импорта после каждого пакета пишется в файл -progress, и
+// повторный запуск продолжает с места остановки. После успешного импорта
+// файл хода удаляется.
+func Import(ctx context.Context, args []string, stdout io.Writer, storage service.RateImportServicer, quarantine service.QuarantineServicer, conf config.Config) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	path := flags.String("file", "", "Файл с курсами")
+	format := flags.String("format", "", "Формат: csv или jsonl, по умолчанию - по расширению файла")
+	progressPath := flags.String("progress", "", "Файл хода импорта, по умолчанию <file>.progress")
+	batch := flags.Int("batch", conf.Import.BatchSize, "Строк в пакете записи")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("нужно указать -file")
+	}
+	if *format == "" {
+		detected, err := ratefile.FormatFromPath(*path)
+		if err != nil {
+			return err
+		}
+		*format = detected
+	}
+	if *progressPath == "" {
+		*progressPath = *path + ".progress"
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл импорта: %w", err)
+	}
+	defer file.Close()
+	reader, err := ratefile.NewReader(*format, file)
+	if err != nil {
+		return err
+	}
+
+	progress := &progressFile{path: *progressPath, out: stdout}
+	importer := service.NewRateImporter(storage, quarantine, conf.Validation, *batch)
+	report, err := importer.Import(ctx, reader, progress)
+	if err != nil {
+		return fmt.Errorf("импорт остановлен после %d строк, повторный запуск продолжит с этого места: %w", report.Processed, err)
+	}
+	if err := os.Remove(*progressPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("не удалось удалить файл хода импорта: %w", err)
+	}
+	fmt.Fprintf(stdout, "импорт завершён: %s\n", formatProgress(report))
+	return nil
+}
+
+// progressFile хранит ход импорта в JSON-файле и печатает его после каждого
+// пакета. Файл заменяется атомарно, чтобы прерванная запись не испортила ход.
+type progressFile struct {
+	path string
+	out  io.Writer
+}
+
+func (p *progressFile) Load() (models.ImportProgress, error) {
+	var progress models.ImportProgress
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return progress, fmt.Errorf("не удалось прочитать ход импорта: %w", err)
+	}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return progress, fmt.Errorf("не удалось разобрать ход импорта %s: %w", p.path, err)
+	}
+	if progress.Processed > 0 {
+		fmt.Fprintf(p.out, "продолжение импорта: %s\n", formatProgress(progress))
+	}
+	return progress, nil
+}
+
+func (p *progressFile) Save(progress models.ImportProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить ход импорта: %w", err)
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("не удалось сохранить ход импорта: %w", err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("не удалось сохранить ход импорта: %w", err)
+	}
+	fmt.Fprintln(p.out, formatProgress(progress))
+	return nil
+}
+
+func formatProgress(progress models.ImportProgress) string {
+	var rejected int64
+	reasons := make([]string, 0, len(progress.Rejected))
+	for reason, n := range progress.Rejected {
+		rejected += n
+		reasons = append(reasons, fmt.Sprintf("%s=%d", reason, n))
+	}
+	sort.Strings(reasons)
+	line := fmt.Sprintf("обработано %d, импортировано %d, дублей %d, отклонено %d",
+		progress.Processed, progress.Imported, progress.Duplicates, rejected)
+	if len(reasons) > 0 {
+		line += " (" + strings.Join(reasons, ", ") + ")"
+	}
+	return line
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/config"
+	"usdt/internal/db"
+	"usdt/internal/models"
+	"usdt/internal/modules/storage"
+)
+
+// quarantineList запоминает отложенные в карантин курсы.
+type quarantineList struct {
+	rates []models.QuarantinedRate
+}
+
+func (q *quarantineList) Create(ctx context.Context, rate models.QuarantinedRate) error {
+	q.rates = append(q.rates, rate)
+	return nil
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rates.csv")
+	data := "pair,ask_price,bid_price,timestamp\n" +
+		"USDT/RUB,91,90,2024-05-01T12:00:00Z\n" +
+		"USDT/RUB,91,92,2024-05-01T12:01:00Z\n" +
+		"USDT/RUB,91.1,90.1,2024-05-01T12:02:00Z\n" +
+		"USDT/USD,1.01,0.99,2024-05-01T12:00:00Z\n" +
+		"USDT/RUB,bad,90,2024-05-01T12:03:00Z\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	adapter := db.NewMemoryAdapter()
+	rates := storage.NewUsdtStorage(adapter)
+	quarantine := &quarantineList{}
+	conf := config.Config{Import: config.Import{BatchSize: 2}}
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	var out bytes.Buffer
+	require.NoError(t, Import(ctx, []string{"-file", path}, &out, rates, quarantine, conf))
+	assert.Contains(t, out.String(), "импорт завершён: обработано 5, импортировано 3, дублей 0, отклонено 2 (crossed_book=1, invalid_record=1)")
+	_, err := os.Stat(path + ".progress")
+	assert.True(t, os.IsNotExist(err), "файл хода должен удаляться после импорта")
+
+	stored, err := rates.GetHistory(ctx, "USDT/RUB", from, to)
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+	require.Len(t, quarantine.rates, 1)
+	assert.Equal(t, models.RejectCrossedBook, quarantine.rates[0].Reason)
+
+	// Прерванный импорт продолжается с сохранённой строки.
+	progress := filepath.Join(dir, "resume.progress")
+	require.NoError(t, os.WriteFile(progress, []byte(`{"processed":3,"imported":2,"rejected":{"crossed_book":1}}`), 0o644))
+	out.Reset()
+	require.NoError(t, Import(ctx, []string{"-file", path, "-progress", progress}, &out, rates, quarantine, conf))
+	assert.Contains(t, out.String(), "продолжение импорта: обработано 3")
+	assert.Contains(t, out.String(), "импорт завершён: обработано 5, импортировано 2, дублей 1, отклонено 2")
+
+	assert.Error(t, Import(ctx, []string{"-file", filepath.Join(dir, "rates.xlsx")}, &out, rates, quarantine, conf))
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"usdt/internal/models"
+)
+
+// KeyManager выпускает, перечисляет и отзывает API ключи.
+type KeyManager interface {
+	Issue(ctx context.Context, key models.APIKey, ttl time.Duration) (models.APIKey, string, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
+
+// Keys выполняет подкоманду keys: create, list или revoke. Ключи
+// управляются прямо в базе, поэтому так выпускается и первый ключ с правом
+// admin, которым затем пользуются RPC KeyService.
+func Keys(ctx context.Context, args []string, stdout io.Writer, keys KeyManager) error {
+	if len(args) == 0 {
+		return errors.New("нужна команда: create, list или revoke")
+	}
+	switch args[0] {
+	case "create":
+		return createKey(ctx, args[1:], stdout, keys)
+	case "list":
+		return listKeys(ctx, stdout, keys)
+	case "revoke":
+		return revokeKey(ctx, args[1:], stdout, keys)
+	default:
+		return fmt.Errorf("неизвестная команда %q: нужна create, list или revoke", args[0])
+	}
+}
+
+func createKey(ctx context.Context, args []string, stdout io.Writer, keys KeyManager) error {
+	flags := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	name := flags.String("name", "", "Имя клиента")
+	scopes := flags.String("scopes", models.ScopeRatesRead, "Права через запятую: "+strings.Join(models.Scopes, ", "))
+	currencies := flags.String("currencies", "", "Доступные валюты через запятую, пусто - все")
+	ttl := flags.Duration("ttl", 0, "Срок действия, 0 - бессрочно")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	key := models.APIKey{Name: *name, Scopes: splitList(*scopes)}
+	for _, currency := range splitList(*currencies) {
+		key.Pairs = append(key.Pairs, models.CurrencyPair(currency))
+	}
+	created, secret, err := keys.Issue(ctx, key, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "ключ %d (%s): %s\n", created.ID, created.Name, secret)
+	fmt.Fprintln(stdout, "сохраните ключ: он больше не будет показан")
+	return nil
+}
+
+func listKeys(ctx context.Context, stdout io.Writer, keys KeyManager) error {
+	list, err := keys.List(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tИМЯ\tПРЕФИКС\tПРАВА\tПАРЫ\tДЕЙСТВУЕТ ДО\tСОСТОЯНИЕ")
+	for _, key := range list {
+		pairs, expires, state := "все", "бессрочно", "активен"
+		if len(key.Pairs) > 0 {
+			pairs = strings.Join(key.Pairs, ",")
+		}
+		if key.ExpiresAt != nil {
+			expires = key.ExpiresAt.Format(time.RFC3339)
+		}
+		switch {
+		case key.RevokedAt != nil:
+			state = "отозван " + key.RevokedAt.Format(time.RFC3339)
+		case !key.Active(now):
+			state = "просрочен"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(key.Scopes, ","), pairs, expires, state)
+	}
+	return w.Flush()
+}
+
+func revokeKey(ctx context.Context, args []string, stdout io.Writer, keys KeyManager) error {
+	flags := flag.NewFlagSet("keys revoke", flag.ContinueOnError)
+	id := flags.Int64("id", 0, "ID ключа")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return errors.New("нужно указать -id")
+	}
+	if err := keys.Revoke(ctx, *id); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "ключ %d отозван\n", *id)
+	return nil
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/db"
+	migrate "usdt/internal/infrastructure/db"
+	"usdt/internal/models"
+	"usdt/internal/modules/service"
+	"usdt/internal/modules/storage"
+)
+
+func newKeyService(t *testing.T) *service.APIKeyService {
+	t.Helper()
+	conf := config.Config{
+		MigrationsPath: "file://../infrastructure/db/migrations_sqlite",
+		Db:             config.DB{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "usdt.db")},
+	}
+	require.NoError(t, migrate.RunMigrations(conf, zap.NewNop()))
+	adapter, err := db.NewDB(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() { adapter.Close() })
+	return service.NewAPIKeyService(storage.NewAPIKeyStorage(adapter), "")
+}
+
+func TestKeys(t *testing.T) {
+	keys := newKeyService(t)
+	ctx := context.Background()
+	var out bytes.Buffer
+
+	require.NoError(t, Keys(ctx, []string{"create", "-name", "partner", "-scopes", "rates:read, quotes", "-currencies", "rub,USD", "-ttl", "720h"}, &out, keys))
+	secret := regexp.MustCompile(`usdt_\S+`).FindString(out.String())
+	require.NotEmpty(t, secret, out.String())
+	assert.Contains(t, out.String(), "ключ 1 (partner)")
+
+	principal, err := keys.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.ScopeRatesRead, models.ScopeQuotes}, principal.Scopes)
+	assert.Equal(t, []string{"USDT/RUB", "USDT/USD"}, principal.Pairs)
+
+	out.Reset()
+	require.NoError(t, Keys(ctx, []string{"list"}, &out, keys))
+	assert.Contains(t, out.String(), secret[:11])
+	assert.Contains(t, out.String(), "rates:read,quotes")
+	assert.Contains(t, out.String(), "активен")
+	assert.NotContains(t, out.String(), secret)
+
+	out.Reset()
+	require.NoError(t, Keys(ctx, []string{"revoke", "-id", "1"}, &out, keys))
+	_, err = keys.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, models.ErrUnauthenticated)
+	out.Reset()
+	require.NoError(t, Keys(ctx, []string{"list"}, &out, keys))
+	assert.Contains(t, out.String(), "отозван")
+
+	assert.ErrorIs(t, Keys(ctx, []string{"revoke", "-id", "42"}, &out, keys), models.ErrAPIKeyNotFound)
+	assert.ErrorIs(t, Keys(ctx, []string{"create", "-name", "x", "-scopes", "root"}, &out, keys), models.ErrInvalidAPIKey)
+	assert.Error(t, Keys(ctx, []string{"rotate"}, &out, keys))
+	assert.Error(t, Keys(ctx, nil, &out, keys))
+}
@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	"log"
 	"os"
@@ -15,11 +17,15 @@ import (
 
 type DbAdapter struct {
 	db *gorm.DB
+	// outbox включает запись событий об изменении курсов в таблицу outbox.
+	outbox bool
 }
 
 func NewDB(cfg config.Config) (*DbAdapter, error) {
-	connString := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
-		cfg.Db.User, cfg.Db.Password, cfg.Db.Host, cfg.Db.Port, cfg.Db.Database)
+	dialector, err := openDialector(cfg.Db)
+	if err != nil {
+		return nil, err
+	}
 
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
@@ -30,7 +36,7 @@ func NewDB(cfg config.Config) (*DbAdapter, error) {
 		},
 	)
 
-	db, err := gorm.Open(postgres.Open(connString), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: newLogger,
 	})
 	if err != nil {
@@ -41,7 +47,21 @@ func NewDB(cfg config.Config) (*DbAdapter, error) {
 		return nil, fmt.Errorf("Ошибка проверки соединения с базой данных: %w", err)
 	}
 
-	return &DbAdapter{db: db}, nil
+	return &DbAdapter{db: db, outbox: cfg.Outbox.Enabled}, nil
+}
+
+// openDialector выбирает драйвер GORM по cfg.Driver; пустой драйвер - Postgres.
+func openDialector(cfg config.DB) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "", config.DriverPostgres:
+		connString := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
+			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+		return postgres.Open(connString), nil
+	case config.DriverSQLite:
+		return openSQLite(cfg.Path)
+	default:
+		return nil, fmt.Errorf("Неизвестный драйвер базы данных: %s", cfg.Driver)
+	}
 }
 
 func (adapter *DbAdapter) Close() error {
@@ -52,9 +72,120 @@ func (adapter *DbAdapter) Close() error {
 	return sqlDB.Close()
 }
 
-func (adapter *DbAdapter) CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) error {
-	result := adapter.db.Create(&rate)
-	return result.Error
+// rateConflict - уникальный ключ курса: пара, время биржи и поставщик.
+var rateConflict = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "pair"}, {Name: "timestamp"}, {Name: "source"}},
+	DoNothing: true,
+}
+
+// CreateCurrencyRate сохраняет курс, если снимка с теми же парой, временем
+// биржи и поставщиком ещё нет, и возвращает сохранённую запись - новую или
+// уже существующую - и признак того, что строка была добавлена.
+func (adapter *DbAdapter) CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
+	var created bool
+	err := adapter.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(rateConflict).Create(&rate)
+		if result.Error != nil {
+			return result.Error
+		}
+		if created = result.RowsAffected > 0; !created {
+			var stored models.CurrencyRate
+			err := tx.Where("pair = ? AND source = ? AND timestamp = ?", rate.Pair, rate.Source, rate.Timestamp).
+				First(&stored).Error
+			rate = stored
+			return err
+		}
+		return adapter.writeRateEvents(tx, models.EventRateCreated, rate)
+	})
+	if err != nil {
+		return models.CurrencyRate{}, false, err
+	}
+	return rate, created, nil
+}
+
+// insertBatchSize ограничивает число строк в одном INSERT, чтобы не выйти за
+// лимит параметров запроса Postgres.
+const insertBatchSize = 1000
+
+// CreateCurrencyRates сохраняет курсы многострочными INSERT в одной транзакции.
+// Уже сохранённые снимки и повторы внутри пакета пропускаются.
+func (adapter *DbAdapter) CreateCurrencyRates(ctx context.Context, rates []models.CurrencyRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	err := adapter.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fresh, err := newRates(tx, rates)
+		if err != nil || len(fresh) == 0 {
+			return err
+		}
+		result := tx.Clauses(rateConflict).CreateInBatches(fresh, insertBatchSize)
+		if result.Error != nil {
+			return result.Error
+		}
+		// Пропущенную при вставке строку GORM не заметит и припишет её ID
+		// следующему курсу, поэтому пакет, который параллельно записал кто-то
+		// ещё, откатывается целиком.
+		if result.RowsAffected != int64(len(fresh)) {
+			return errors.New("часть курсов пакета записана параллельно")
+		}
+		return adapter.writeRateEvents(tx, models.EventRateCreated, fresh...)
+	})
+	if err != nil {
+		return fmt.Errorf("Ошибка пакетной записи курсов: %w", err)
+	}
+	return nil
+}
+
+// rateKey - уникальный ключ курса.
+type rateKey struct {
+	pair   string
+	source string
+	ts     int64
+}
+
+func keyOf(rate models.CurrencyRate) rateKey {
+	return rateKey{pair: rate.Pair, source: rate.Source, ts: rate.Timestamp.UnixMicro()}
+}
+
+// newRates возвращает курсы пакета, которых ещё нет в базе, без повторов.
+func newRates(tx *gorm.DB, rates []models.CurrencyRate) ([]models.CurrencyRate, error) {
+	pairs := make(map[string]struct{})
+	from, to := rates[0].Timestamp, rates[0].Timestamp
+	for _, rate := range rates {
+		pairs[rate.Pair] = struct{}{}
+		if rate.Timestamp.Before(from) {
+			from = rate.Timestamp
+		}
+		if rate.Timestamp.After(to) {
+			to = rate.Timestamp
+		}
+	}
+	names := make([]string, 0, len(pairs))
+	for pair := range pairs {
+		names = append(names, pair)
+	}
+
+	var stored []models.CurrencyRate
+	err := tx.Select("pair", "source", "timestamp").
+		Where("pair IN ? AND timestamp >= ? AND timestamp <= ?", names, from, to).
+		Find(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[rateKey]struct{}, len(stored)+len(rates))
+	for _, rate := range stored {
+		seen[keyOf(rate)] = struct{}{}
+	}
+	fresh := make([]models.CurrencyRate, 0, len(rates))
+	for _, rate := range rates {
+		key := keyOf(rate)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		fresh = append(fresh, rate)
+	}
+	return fresh, nil
 }
 
 func (adapter *DbAdapter) GetCurrencyRate(ctx context.Context, id int64) (*models.CurrencyRate, error) {
@@ -89,12 +220,136 @@ func (adapter *DbAdapter) GetAllCurrencyRates(ctx context.Context) ([]models.Cur
 	return rates, nil
 }
 
-func (adapter *DbAdapter) UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) error {
-	result := adapter.db.Save(&rate)
-	return result.Error
+// UpdateCurrencyRate меняет цены и время биржи курса, если его версия совпадает
+// с rate.Version, и возвращает запись с увеличенной версией.
+func (adapter *DbAdapter) UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error) {
+	var updated models.CurrencyRate
+	err := adapter.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.CurrencyRate{}).
+			Where("id = ? AND version = ?", rate.ID, rate.Version).
+			Updates(map[string]interface{}{
+				"ask_price": rate.AskPrice,
+				"bid_price": rate.BidPrice,
+				"timestamp": rate.Timestamp,
+				"version":   gorm.Expr("version + 1"),
+			})
+		if isDuplicateKey(tx, result.Error) {
+			return fmt.Errorf("Ошибка обновления курса: %w", models.ErrRateDuplicate)
+		}
+		if result.Error != nil {
+			return fmt.Errorf("Ошибка обновления курса: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return versionMismatch(tx, rate.ID)
+		}
+		if err := tx.Where("id = ?", rate.ID).First(&updated).Error; err != nil {
+			return fmt.Errorf("Ошибка получения обновлённого курса: %w", err)
+		}
+		return adapter.writeRateEvents(tx, models.EventRateUpdated, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteCurrencyRate удаляет курс, если его версия совпадает с version.
+func (adapter *DbAdapter) DeleteCurrencyRate(ctx context.Context, id, version int64) error {
+	return adapter.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var deleted []models.CurrencyRate
+		result := tx.Clauses(clause.Returning{}).Where("id = ? AND version = ?", id, version).Delete(&deleted)
+		if result.Error != nil {
+			return fmt.Errorf("Ошибка удаления курса: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return versionMismatch(tx, id)
+		}
+		return adapter.writeRateEvents(tx, models.EventRateDeleted, deleted...)
+	})
+}
+
+// isDuplicateKey сообщает, что err - нарушение уникального ключа. Ошибки
+// драйвера переводятся в gorm.ErrDuplicatedKey только здесь, чтобы не
+// менять остальные ошибки адаптера.
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if err == nil {
+		return false
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// versionMismatch объясняет, почему условное изменение не затронуло строк:
+// курса с таким ID нет или его версия уже другая.
+func versionMismatch(tx *gorm.DB, id int64) error {
+	var count int64
+	if err := tx.Model(&models.CurrencyRate{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("Ошибка проверки версии курса: %w", err)
+	}
+	if count == 0 {
+		return models.ErrRateNotFound
+	}
+	return models.ErrRateVersionConflict
 }
 
-func (adapter *DbAdapter) DeleteCurrencyRate(ctx context.Context, id int64) error {
-	result := adapter.db.Delete(&models.CurrencyRate{}, id)
-	return result.Error
+func (adapter *DbAdapter) GetCurrencyRateHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	var rates []models.CurrencyRate
+	result := historyQuery(adapter.db.WithContext(ctx), pair, from, to).Find(&rates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения истории курсов: %w", result.Error)
+	}
+	return rates, nil
+}
+
+// GetCurrencyRatePage возвращает не больше limit курсов пары за [from, to],
+// следующих за курсором after, в порядке времени биржи и ID. Страницы
+// читаются по ключу, а не через OFFSET, поэтому выгрузка длинной истории
+// не замедляется к концу.
+func (adapter *DbAdapter) GetCurrencyRatePage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error) {
+	var rates []models.CurrencyRate
+	query := historyQuery(adapter.db.WithContext(ctx), pair, from, to)
+	if !after.IsZero() {
+		query = query.Where("(timestamp > ? OR (timestamp = ? AND id > ?))", after.Timestamp, after.Timestamp, after.ID)
+	}
+	result := query.Order("id").Limit(limit).Find(&rates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения страницы курсов: %w", result.Error)
+	}
+	return rates, nil
+}
+
+// historyQuery ограничивает выборку диапазоном timestamp, чтобы Postgres
+// читал только секции currency_rates, пересекающиеся с [from, to].
+func historyQuery(tx *gorm.DB, pair string, from, to time.Time) *gorm.DB {
+	return tx.Where("pair = ? AND timestamp >= ? AND timestamp <= ?", pair, from, to).Order("timestamp")
+}
+
+// GetCurrencyRateByKey возвращает сохранённый снимок с теми же парой, временем
+// биржи и поставщиком, что и rate, или nil, если его нет.
+func (adapter *DbAdapter) GetCurrencyRateByKey(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error) {
+	var stored models.CurrencyRate
+	result := adapter.db.WithContext(ctx).
+		Where("pair = ? AND source = ? AND timestamp = ?", rate.Pair, rate.Source, rate.Timestamp).
+		First(&stored)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Ошибка получения курса по ключу снимка: %w", result.Error)
+	}
+	return &stored, nil
+}
+
+func (adapter *DbAdapter) GetLatestCurrencyRate(ctx context.Context, pair string) (*models.CurrencyRate, error) {
+	var rate models.CurrencyRate
+	result := adapter.db.WithContext(ctx).Where("pair = ?", pair).Order("timestamp DESC").First(&rate)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Ошибка получения последнего курса по Pair: %w", result.Error)
+	}
+	return &rate, nil
 }
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"usdt/config"
+	migrate "usdt/internal/infrastructure/db"
+	"usdt/internal/models"
+)
+
+// newTestAdapter подключается к тестовой базе из переменных TEST_DB_*.
+// Без TEST_DB_HOST интеграционные тесты пропускаются.
+func newTestAdapter(t *testing.T) *DbAdapter {
+	t.Helper()
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST не задан, интеграционные тесты пропущены")
+	}
+	conf := config.Config{
+		MigrationsPath: "file://../infrastructure/db/migrations",
+		Db: config.DB{
+			User:     os.Getenv("TEST_DB_USER"),
+			Password: os.Getenv("TEST_DB_PASSWORD"),
+			Host:     host,
+			Port:     os.Getenv("TEST_DB_PORT"),
+			Database: os.Getenv("TEST_DB_DATABASE"),
+		},
+	}
+	if err := migrate.RunMigrations(conf, zap.NewNop()); err != nil {
+		t.Fatalf("не удалось выполнить миграции: %v", err)
+	}
+	adapter, err := NewDB(conf)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к базе: %v", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+	return adapter
+}
+
+func TestDbAdapter_CreateCurrencyRates(t *testing.T) {
+	adapter := newTestAdapter(t)
+	ctx := context.Background()
+	pair := "USDT/TST"
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, adapter.db.Exec("DELETE FROM currency_rates WHERE pair = ? AND timestamp >= ? AND timestamp < ?",
+		pair, start, start.Add(time.Hour)).Error)
+
+	rates := make([]models.CurrencyRate, insertBatchSize+5)
+	for i := range rates {
+		rates[i] = models.CurrencyRate{Pair: pair, AskPrice: 101, BidPrice: 100, Timestamp: start.Add(time.Duration(i) * time.Second)}
+	}
+	require.NoError(t, adapter.CreateCurrencyRates(ctx, rates))
+	require.NoError(t, adapter.CreateCurrencyRates(ctx, nil))
+
+	stored, err := adapter.GetCurrencyRateHistory(ctx, pair, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, stored, len(rates))
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"usdt/internal/models"
+)
+
+func (adapter *DbAdapter) CreateAlertRule(ctx context.Context, rule *models.AlertRule) error {
+	result := adapter.db.WithContext(ctx).Create(rule)
+	return result.Error
+}
+
+func (adapter *DbAdapter) GetAlertRule(ctx context.Context, id int64) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	result := adapter.db.WithContext(ctx).Where("id = ?", id).First(&rule)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Ошибка получения правила оповещения по ID: %w", result.Error)
+	}
+	return &rule, nil
+}
+
+// ListAlertRules возвращает правила по паре; пустая пара - все правила.
+func (adapter *DbAdapter) ListAlertRules(ctx context.Context, pair string) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	query := adapter.db.WithContext(ctx).Order("id")
+	if pair != "" {
+		query = query.Where("pair = ?", pair)
+	}
+	result := query.Find(&rules)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения правил оповещения: %w", result.Error)
+	}
+	return rules, nil
+}
+
+func (adapter *DbAdapter) UpdateAlertRule(ctx context.Context, rule models.AlertRule) error {
+	result := adapter.db.WithContext(ctx).Model(&models.AlertRule{}).
+		Where("id = ?", rule.ID).
+		Select("pair", "condition", "threshold", "window_seconds", "webhook_url", "secret", "enabled").
+		Updates(&rule)
+	if result.Error != nil {
+		return fmt.Errorf("Ошибка обновления правила оповещения: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+func (adapter *DbAdapter) DeleteAlertRule(ctx context.Context, id int64) error {
+	result := adapter.db.WithContext(ctx).Delete(&models.AlertRule{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("Ошибка удаления правила оповещения: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+func (adapter *DbAdapter) CreateAlertDelivery(ctx context.Context, delivery models.AlertDelivery) error {
+	result := adapter.db.WithContext(ctx).Create(&delivery)
+	return result.Error
+}
+
+func (adapter *DbAdapter) ListAlertDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error) {
+	var deliveries []models.AlertDelivery
+	result := adapter.db.WithContext(ctx).
+		Where("rule_id = ?", ruleID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения журнала доставки: %w", result.Error)
+	}
+	return deliveries, nil
+}
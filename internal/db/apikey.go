@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"usdt/internal/models"
+)
+
+func (adapter *DbAdapter) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	result := adapter.db.WithContext(ctx).Create(key)
+	if result.Error != nil {
+		return fmt.Errorf("Ошибка создания API ключа: %w", result.Error)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash возвращает ключ по хешу, в том числе отозванный; nil, если
+// ключа нет.
+func (adapter *DbAdapter) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	result := adapter.db.WithContext(ctx).Where("hash = ?", hash).First(&key)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Ошибка получения API ключа: %w", result.Error)
+	}
+	return &key, nil
+}
+
+func (adapter *DbAdapter) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	result := adapter.db.WithContext(ctx).Order("id").Find(&keys)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения API ключей: %w", result.Error)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ; повторный отзыв не меняет время первого.
+func (adapter *DbAdapter) RevokeAPIKey(ctx context.Context, id int64, at time.Time) error {
+	result := adapter.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", at))
+	if result.Error != nil {
+		return fmt.Errorf("Ошибка отзыва API ключа: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/models"
+)
+
+func testAPIKeys(t *testing.T, adapter *DbAdapter) {
+	ctx := context.Background()
+	hash := time.Now().Format("20060102150405.000000000") + "-hash"
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := models.APIKey{Name: "partner", Prefix: "usdt_abcdef", Hash: hash,
+		Scopes: []string{models.ScopeRatesRead}, Pairs: []string{"USDT/RUB"}, ExpiresAt: &expires, CreatedAt: time.Now().UTC()}
+	require.NoError(t, adapter.CreateAPIKey(ctx, &key))
+	require.NotZero(t, key.ID)
+
+	stored, err := adapter.GetAPIKeyByHash(ctx, hash)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, key.Scopes, stored.Scopes)
+	assert.Equal(t, key.Pairs, stored.Pairs)
+	assert.True(t, expires.Equal(*stored.ExpiresAt))
+	assert.Nil(t, stored.RevokedAt)
+
+	missing, err := adapter.GetAPIKeyByHash(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	revoked := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, adapter.RevokeAPIKey(ctx, key.ID, revoked))
+	require.NoError(t, adapter.RevokeAPIKey(ctx, key.ID, revoked.Add(time.Hour)))
+	stored, err = adapter.GetAPIKeyByHash(ctx, hash)
+	require.NoError(t, err)
+	require.NotNil(t, stored.RevokedAt)
+	assert.True(t, revoked.Equal(*stored.RevokedAt), "повторный отзыв не меняет время первого")
+	assert.ErrorIs(t, adapter.RevokeAPIKey(ctx, -1, revoked), models.ErrAPIKeyNotFound)
+
+	keys, err := adapter.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, keys)
+
+	duplicate := models.APIKey{Name: "copy", Prefix: "usdt_abcdef", Hash: hash, CreatedAt: time.Now()}
+	assert.Error(t, adapter.CreateAPIKey(ctx, &duplicate), "хеш ключа уникален")
+}
+
+func TestDbAdapter_APIKeys(t *testing.T) {
+	testAPIKeys(t, newTestAdapter(t))
+}
+
+func TestSQLiteAdapter_APIKeys(t *testing.T) {
+	testAPIKeys(t, newSQLiteTestAdapter(t))
+}
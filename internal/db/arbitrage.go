@@ -0,0 +1,11 @@
+package db
+
+import (
+	"context"
+	"usdt/internal/models"
+)
+
+func (adapter *DbAdapter) CreateArbitrageOpportunity(ctx context.Context, opportunity models.ArbitrageOpportunity) error {
+	result := adapter.db.WithContext(ctx).Create(&opportunity)
+	return result.Error
+}
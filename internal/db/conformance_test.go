@@ -0,0 +1,417 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/models"
+	"usdt/internal/modules/storage"
+)
+
+// conformancePair - префикс пар, которые создаёт набор; строки с ним
+// удаляются перед каждым подтестом на общих базах.
+const conformancePair = "USDT/CF"
+
+// createRate сохраняет новый курс и проваливает тест, если он не записался.
+func createRate(t *testing.T, adapter storage.UsdtStorager, rate models.CurrencyRate) models.CurrencyRate {
+	t.Helper()
+	stored, created, err := adapter.CreateCurrencyRate(context.Background(), rate)
+	require.NoError(t, err)
+	require.True(t, created, "курс %s от %s уже был сохранён", rate.Pair, rate.Timestamp)
+	return stored
+}
+
+// runUsdtStoragerConformance проверяет поведение storage.UsdtStorager, которое
+// должно совпадать у всех бэкендов. newAdapter возвращает хранилище без курсов
+// с префиксом conformancePair.
+func runUsdtStoragerConformance(t *testing.T, newAdapter func(t *testing.T) storage.UsdtStorager) {
+	ctx := context.Background()
+	base := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	rate := func(pair string, bid float64, offset time.Duration) models.CurrencyRate {
+		return models.CurrencyRate{Pair: pair, AskPrice: bid + 0.5, BidPrice: bid, Timestamp: base.Add(offset)}
+	}
+
+	t.Run("CreateAndGetByID", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "A"
+		createRate(t, adapter, rate(pair, 90.25, 0))
+		createRate(t, adapter, rate(pair, 91.5, time.Minute))
+
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 2)
+		assert.NotZero(t, stored[0].ID)
+		assert.Less(t, stored[0].ID, stored[1].ID)
+		assertSameRate(t, rate(pair, 90.25, 0), stored[0])
+
+		got, err := adapter.GetCurrencyRate(ctx, stored[1].ID)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, stored[1].ID, got.ID)
+		assertSameRate(t, rate(pair, 91.5, time.Minute), *got)
+
+		missing, err := adapter.GetCurrencyRate(ctx, -1)
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("CreateBatch", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "B"
+		batch := []models.CurrencyRate{rate(pair, 1, 0), rate(pair, 2, time.Second), rate(pair, 3, 2*time.Second)}
+		require.NoError(t, adapter.CreateCurrencyRates(ctx, batch))
+		require.NoError(t, adapter.CreateCurrencyRates(ctx, nil))
+
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 3)
+		for i := range batch {
+			assertSameRate(t, batch[i], stored[i])
+		}
+	})
+
+	t.Run("DuplicateSnapshot", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "D"
+		snapshot := rate(pair, 70, 0)
+		snapshot.Source = "garantex"
+		first := createRate(t, adapter, snapshot)
+		assert.NotZero(t, first.ID)
+		assert.Equal(t, int64(1), first.Version)
+		assert.False(t, first.CreatedAt.IsZero())
+
+		repeat := snapshot
+		repeat.AskPrice = 99
+		existing, created, err := adapter.CreateCurrencyRate(ctx, repeat)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, first.ID, existing.ID, "возвращается уже сохранённая запись")
+		assert.Equal(t, snapshot.AskPrice, existing.AskPrice)
+
+		other := snapshot
+		other.Source = "other"
+		createRate(t, adapter, other)
+
+		// Пакет пропускает сохранённые снимки и повторы внутри себя.
+		next := rate(pair, 71, time.Second)
+		next.Source = "garantex"
+		require.NoError(t, adapter.CreateCurrencyRates(ctx, []models.CurrencyRate{repeat, next, next}))
+
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 3)
+		assert.Equal(t, 70.5, stored[0].AskPrice)
+		var sources []string
+		for _, rate := range stored {
+			sources = append(sources, rate.Source)
+		}
+		assert.Equal(t, []string{"garantex", "other", "garantex"}, sources)
+	})
+
+	t.Run("ConcurrentDuplicateSnapshot", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "E"
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		created := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, ok, err := adapter.CreateCurrencyRate(ctx, rate(pair, 80, 0))
+				assert.NoError(t, err)
+				if ok {
+					mu.Lock()
+					created++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, created)
+		assert.Len(t, ratesOf(t, adapter, pair), 1)
+	})
+
+	t.Run("GetByPair", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "C"
+		createRate(t, adapter, rate(pair, 10, time.Hour))
+		createRate(t, adapter, rate(pair, 11, 0))
+
+		got, err := adapter.GetCurrencyRateByPair(ctx, pair)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assertSameRate(t, rate(pair, 10, time.Hour), *got)
+
+		missing, err := adapter.GetCurrencyRateByPair(ctx, conformancePair+"NONE")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "D"
+		createRate(t, adapter, rate(pair, 20, 0))
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 1)
+		assert.Equal(t, int64(1), stored[0].Version)
+		assert.False(t, stored[0].CreatedAt.IsZero())
+
+		updated := rate(pair, 25.75, time.Minute)
+		updated.ID = stored[0].ID
+		updated.Version = stored[0].Version
+		result, err := adapter.UpdateCurrencyRate(ctx, updated)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, int64(2), result.Version)
+		assertSameRate(t, updated, *result)
+		assert.True(t, stored[0].CreatedAt.Equal(result.CreatedAt))
+
+		got, err := adapter.GetCurrencyRate(ctx, updated.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assertSameRate(t, updated, *got)
+		assert.Equal(t, int64(2), got.Version)
+		assert.Len(t, ratesOf(t, adapter, pair), 1)
+
+		// Повтор со старой версией не должен перезаписать чужое изменение.
+		_, err = adapter.UpdateCurrencyRate(ctx, updated)
+		assert.ErrorIs(t, err, models.ErrRateVersionConflict)
+		got, err = adapter.GetCurrencyRate(ctx, updated.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), got.Version)
+
+		missing := rate(pair, 30, 0)
+		missing.ID, missing.Version = -1, 1
+		_, err = adapter.UpdateCurrencyRate(ctx, missing)
+		assert.ErrorIs(t, err, models.ErrRateNotFound)
+
+		// Перенос на время уже сохранённого снимка нарушил бы уникальный ключ.
+		other := createRate(t, adapter, rate(pair, 21, 2*time.Minute))
+		other.Timestamp = updated.Timestamp
+		_, err = adapter.UpdateCurrencyRate(ctx, other)
+		assert.ErrorIs(t, err, models.ErrRateDuplicate)
+	})
+
+	t.Run("ConcurrentUpdate", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "J"
+		createRate(t, adapter, rate(pair, 50, 0))
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 1)
+
+		// Из обновлений одной версии проходит ровно одно.
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded, conflicts := 0, 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				update := rate(pair, 60+float64(i), 0)
+				update.ID, update.Version = stored[0].ID, stored[0].Version
+				_, err := adapter.UpdateCurrencyRate(ctx, update)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					succeeded++
+				case errors.Is(err, models.ErrRateVersionConflict):
+					conflicts++
+				default:
+					t.Errorf("неожиданная ошибка: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, 9, conflicts)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "E"
+		createRate(t, adapter, rate(pair, 40, 0))
+		createRate(t, adapter, rate(pair, 41, time.Minute))
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 2)
+
+		err := adapter.DeleteCurrencyRate(ctx, stored[0].ID, stored[0].Version+1)
+		assert.ErrorIs(t, err, models.ErrRateVersionConflict)
+		assert.Len(t, ratesOf(t, adapter, pair), 2)
+
+		require.NoError(t, adapter.DeleteCurrencyRate(ctx, stored[0].ID, stored[0].Version))
+		got, err := adapter.GetCurrencyRate(ctx, stored[0].ID)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+		remaining := ratesOf(t, adapter, pair)
+		require.Len(t, remaining, 1)
+		assert.Equal(t, stored[1].ID, remaining[0].ID)
+
+		err = adapter.DeleteCurrencyRate(ctx, stored[0].ID, stored[0].Version)
+		assert.ErrorIs(t, err, models.ErrRateNotFound)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "F"
+		createRate(t, adapter, rate(pair, 3, 3*time.Minute))
+		createRate(t, adapter, rate(pair, 1, time.Minute))
+		createRate(t, adapter, rate(pair, 2, 2*time.Minute))
+		createRate(t, adapter, rate(pair, 4, 4*time.Minute))
+		createRate(t, adapter, rate(conformancePair+"G", 9, 2*time.Minute))
+
+		// Границы включаются, результат упорядочен по времени биржи.
+		history, err := adapter.GetCurrencyRateHistory(ctx, pair, base.Add(time.Minute), base.Add(3*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		for i, bid := range []float64{1, 2, 3} {
+			assert.Equal(t, bid, history[i].BidPrice)
+		}
+
+		empty, err := adapter.GetCurrencyRateHistory(ctx, pair, base.Add(time.Hour), base.Add(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "K"
+		// Два курса разных поставщиков с одним временем биржи: порядок
+		// внутри него задаёт ID.
+		for i, offset := range []time.Duration{2 * time.Minute, time.Minute, time.Minute, 3 * time.Minute, time.Hour} {
+			r := rate(pair, float64(i+1), offset)
+			r.Source = fmt.Sprintf("source%d", i)
+			createRate(t, adapter, r)
+		}
+		createRate(t, adapter, rate(conformancePair+"L", 9, time.Minute))
+
+		var bids []float64
+		var after models.RateCursor
+		pages := 0
+		for {
+			page, err := adapter.GetCurrencyRatePage(ctx, pair, base, base.Add(3*time.Minute), after, 2)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			pages++
+			for _, rate := range page {
+				bids = append(bids, rate.BidPrice)
+			}
+			after = models.CursorAfter(page[len(page)-1])
+		}
+		assert.Equal(t, []float64{2, 3, 1, 4}, bids)
+		assert.Equal(t, 2, pages)
+	})
+
+	t.Run("Latest", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "H"
+		createRate(t, adapter, rate(pair, 2, 2*time.Minute))
+		createRate(t, adapter, rate(pair, 1, time.Minute))
+
+		latest, err := adapter.GetLatestCurrencyRate(ctx, pair)
+		require.NoError(t, err)
+		require.NotNil(t, latest)
+		assertSameRate(t, rate(pair, 2, 2*time.Minute), *latest)
+
+		missing, err := adapter.GetLatestCurrencyRate(ctx, conformancePair+"NONE")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("GetByKey", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "K"
+		snapshot := rate(pair, 5, time.Minute)
+		snapshot.Source = "garantex"
+		createRate(t, adapter, snapshot)
+
+		// Ключ - пара, поставщик и момент времени в любой зоне; цены не важны.
+		key := snapshot
+		key.AskPrice = 0
+		key.Timestamp = key.Timestamp.In(time.FixedZone("MSK", 3*3600))
+		stored, err := adapter.GetCurrencyRateByKey(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.NotZero(t, stored.ID)
+		assertSameRate(t, snapshot, *stored)
+
+		for _, other := range []models.CurrencyRate{
+			{Pair: pair, Source: "other", Timestamp: snapshot.Timestamp},
+			{Pair: pair, Source: "garantex", Timestamp: snapshot.Timestamp.Add(time.Second)},
+		} {
+			missing, err := adapter.GetCurrencyRateByKey(ctx, other)
+			assert.NoError(t, err)
+			assert.Nil(t, missing)
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		adapter := newAdapter(t)
+		pair := conformancePair + "I"
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, created, err := adapter.CreateCurrencyRate(ctx, rate(pair, float64(i), time.Duration(i)*time.Second))
+				assert.NoError(t, err)
+				assert.True(t, created)
+			}()
+		}
+		wg.Wait()
+
+		stored := ratesOf(t, adapter, pair)
+		require.Len(t, stored, 20)
+		ids := make(map[int64]bool)
+		for _, rate := range stored {
+			ids[rate.ID] = true
+		}
+		assert.Len(t, ids, 20)
+	})
+}
+
+// ratesOf возвращает курсы пары из GetAllCurrencyRates по возрастанию ID.
+func ratesOf(t *testing.T, adapter storage.UsdtStorager, pair string) []models.CurrencyRate {
+	t.Helper()
+	all, err := adapter.GetAllCurrencyRates(context.Background())
+	require.NoError(t, err)
+	var rates []models.CurrencyRate
+	for _, rate := range all {
+		if rate.Pair == pair {
+			rates = append(rates, rate)
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].ID < rates[j].ID })
+	return rates
+}
+
+// assertSameRate сравнивает курсы без учёта ID и часового пояса времени.
+func assertSameRate(t *testing.T, want, got models.CurrencyRate) {
+	t.Helper()
+	assert.Equal(t, want.Pair, got.Pair)
+	assert.Equal(t, want.AskPrice, got.AskPrice)
+	assert.Equal(t, want.BidPrice, got.BidPrice)
+	assert.Equal(t, want.Source, got.Source)
+	assert.True(t, want.Timestamp.Equal(got.Timestamp), "timestamp: want %s, got %s", want.Timestamp, got.Timestamp)
+}
+
+func TestMemoryAdapter_Conformance(t *testing.T) {
+	runUsdtStoragerConformance(t, func(t *testing.T) storage.UsdtStorager {
+		return NewMemoryAdapter()
+	})
+}
+
+func TestDbAdapter_Conformance(t *testing.T) {
+	runUsdtStoragerConformance(t, func(t *testing.T) storage.UsdtStorager {
+		adapter := newTestAdapter(t)
+		require.NoError(t, adapter.db.Exec("DELETE FROM currency_rates WHERE pair LIKE ?", conformancePair+"%").Error)
+		return adapter
+	})
+}
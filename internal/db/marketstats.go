@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"usdt/internal/models"
+)
+
+func (adapter *DbAdapter) CreateMarketStats(ctx context.Context, stats models.MarketStats) error {
+	result := adapter.db.WithContext(ctx).Create(&stats)
+	return result.Error
+}
+
+func (adapter *DbAdapter) GetLatestMarketStats(ctx context.Context, pair string) (*models.MarketStats, error) {
+	var stats models.MarketStats
+	result := adapter.db.WithContext(ctx).Where("pair = ?", pair).Order("timestamp DESC").First(&stats)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Ошибка получения метрик рынка: %w", result.Error)
+	}
+	return &stats, nil
+}
+
+func (adapter *DbAdapter) GetMarketStatsHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error) {
+	var stats []models.MarketStats
+	result := adapter.db.WithContext(ctx).
+		Where("pair = ? AND timestamp >= ? AND timestamp <= ?", pair, from, to).
+		Order("timestamp").
+		Find(&stats)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения истории метрик рынка: %w", result.Error)
+	}
+	return stats, nil
+}
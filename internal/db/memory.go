@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"usdt/internal/models"
+)
+
+// MemoryAdapter хранит курсы в памяти процесса и повторяет поведение
+// DbAdapter для storage.UsdtStorager: идентификаторы выдаются по возрастанию,
+// повторные снимки не сохраняются, а Update и Delete проверяют версию записи. Данные
+// теряются при перезапуске, поэтому адаптер подходит для тестов и локального
+// запуска (STORAGE_BACKEND=memory).
+type MemoryAdapter struct {
+	mu    sync.RWMutex
+	rates map[int64]models.CurrencyRate
+	// keys - ID курса по уникальному ключу снимка.
+	keys   map[rateKey]int64
+	nextID int64
+}
+
+func NewMemoryAdapter() *MemoryAdapter {
+	return &MemoryAdapter{
+		rates:  make(map[int64]models.CurrencyRate),
+		keys:   make(map[rateKey]int64),
+		nextID: 1,
+	}
+}
+
+func (adapter *MemoryAdapter) CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	if id, exists := adapter.keys[keyOf(rate)]; exists {
+		return adapter.rates[id], false, nil
+	}
+	stored, err := adapter.insert(rate)
+	if err != nil {
+		return models.CurrencyRate{}, false, err
+	}
+	return stored, true, nil
+}
+
+func (adapter *MemoryAdapter) CreateCurrencyRates(ctx context.Context, rates []models.CurrencyRate) error {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	// Как и INSERT в транзакции, пакет записывается целиком или не записывается.
+	for _, rate := range rates {
+		if _, exists := adapter.rates[rate.ID]; rate.ID != 0 && exists {
+			return fmt.Errorf("Ошибка пакетной записи курсов: запись с ID %d уже существует", rate.ID)
+		}
+	}
+	for _, rate := range rates {
+		if _, exists := adapter.keys[keyOf(rate)]; !exists {
+			adapter.insert(rate)
+		}
+	}
+	return nil
+}
+
+// insert сохраняет курс под его ID или под следующим свободным и возвращает
+// сохранённую запись; вызывается под mu.
+func (adapter *MemoryAdapter) insert(rate models.CurrencyRate) (models.CurrencyRate, error) {
+	if rate.ID == 0 {
+		for {
+			rate.ID = adapter.nextID
+			adapter.nextID++
+			if _, exists := adapter.rates[rate.ID]; !exists {
+				break
+			}
+		}
+	} else if _, exists := adapter.rates[rate.ID]; exists {
+		return models.CurrencyRate{}, fmt.Errorf("Ошибка записи курса: запись с ID %d уже существует", rate.ID)
+	}
+	if rate.CreatedAt.IsZero() {
+		rate.CreatedAt = time.Now()
+	}
+	if rate.Version == 0 {
+		rate.Version = 1
+	}
+	adapter.rates[rate.ID] = rate
+	adapter.keys[keyOf(rate)] = rate.ID
+	return rate, nil
+}
+
+func (adapter *MemoryAdapter) GetCurrencyRate(ctx context.Context, id int64) (*models.CurrencyRate, error) {
+	adapter.mu.RLock()
+	defer adapter.mu.RUnlock()
+	rate, ok := adapter.rates[id]
+	if !ok {
+		return nil, nil
+	}
+	return &rate, nil
+}
+
+// GetCurrencyRateByPair возвращает курс пары с наименьшим ID, как First в GORM.
+func (adapter *MemoryAdapter) GetCurrencyRateByPair(ctx context.Context, pair string) (*models.CurrencyRate, error) {
+	adapter.mu.RLock()
+	defer adapter.mu.RUnlock()
+	for _, rate := range adapter.sorted() {
+		if rate.Pair == pair {
+			return &rate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (adapter *MemoryAdapter) GetAllCurrencyRates(ctx context.Context) ([]models.CurrencyRate, error) {
+	adapter.mu.RLock()
+	defer adapter.mu.RUnlock()
+	return adapter.sorted(), nil
+}
+
+func (adapter *MemoryAdapter) UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	current, err := adapter.checkVersion(rate.ID, rate.Version)
+	if err != nil {
+		return nil, err
+	}
+	oldKey := keyOf(current)
+	current.AskPrice = rate.AskPrice
+	current.BidPrice = rate.BidPrice
+	current.Timestamp = rate.Timestamp
+	if id, exists := adapter.keys[keyOf(current)]; exists && id != current.ID {
+		return nil, fmt.Errorf("Ошибка обновления курса: %w: %s от %s", models.ErrRateDuplicate, current.Pair, current.Timestamp.Format(time.RFC3339))
+	}
+	current.Version++
+	delete(adapter.keys, oldKey)
+	adapter.keys[keyOf(current)] = current.ID
+	adapter.rates[current.ID] = current
+	return &current, nil
+}
+
+func (adapter *MemoryAdapter) DeleteCurrencyRate(ctx context.Context, id, version int64) error {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	current, err := adapter.checkVersion(id, version)
+	if err != nil {
+		return err
+	}
+	delete(adapter.keys, keyOf(current))
+	delete(adapter.rates, id)
+	return nil
+}
+
+// checkVersion возвращает курс, если его версия равна version; вызывается под mu.
+func (adapter *MemoryAdapter) checkVersion(id, version int64) (models.CurrencyRate, error) {
+	current, ok := adapter.rates[id]
+	if !ok {
+		return models.CurrencyRate{}, models.ErrRateNotFound
+	}
+	if current.Version != version {
+		return models.CurrencyRate{}, models.ErrRateVersionConflict
+	}
+	return current, nil
+}
+
+func (adapter *MemoryAdapter) GetCurrencyRateHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	adapter.mu.RLock()
+	defer adapter.mu.RUnlock()
+	rates := []models.CurrencyRate{}
+	for _, rate := range adapter.sorted() {
+		if rate.Pair == pair && !rate.Timestamp.Before(from) && !rate.Timestamp.After(to) {
+			rates = append(rates, rate)
+		}
+	}
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Timestamp.Before(rates[j].Timestamp) })
+	return rates, nil
+}
+
+func (adapter *MemoryAdapter) GetCurrencyRatePage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error) {
+	history, err := adapter.GetCurrencyRateHistory(ctx, pair, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rates := []models.CurrencyRate{}
+	for _, rate := range history {
+		if len(rates) == limit {
+			break
+		}
+		if after.IsZero() || rate.Timestamp.After(after.Timestamp) || (rate.Timestamp.Equal(after.Timestamp) && rate.ID > after.ID) {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func (adapter *MemoryAdapter) GetCurrencyRateByKey(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error) {
+	adapter.mu.RLock()
+	defer adapter.mu.RUnlock()
+	id, ok := adapter.keys[keyOf(rate)]
+	if !ok {
+		return nil, nil
+	}
+	stored := adapter.rates[id]
+	return &stored, nil
+}
+
+func (adapter *MemoryAdapter) GetLatestCurrencyRate(ctx context.Context, pair string) (*models.CurrencyRate, error) {
+	adapter.mu.RLock()
+	defer adapter.mu.RUnlock()
+	var latest *models.CurrencyRate
+	for _, rate := range adapter.sorted() {
+		if rate.Pair == pair && (latest == nil || rate.Timestamp.After(latest.Timestamp)) {
+			latest = &rate
+		}
+	}
+	return latest, nil
+}
+
+// sorted возвращает копию всех курсов по возрастанию ID; вызывается под mu.
+func (adapter *MemoryAdapter) sorted() []models.CurrencyRate {
+	rates := make([]models.CurrencyRate, 0, len(adapter.rates))
+	for _, rate := range adapter.rates {
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].ID < rates[j].ID })
+	return rates
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"usdt/internal/models"
+)
+
+// writeRateEvents добавляет в outbox по событию на курс внутри транзакции
+// tx, в которой курсы были изменены.
+func (adapter *DbAdapter) writeRateEvents(tx *gorm.DB, eventType string, rates ...models.CurrencyRate) error {
+	if !adapter.outbox || len(rates) == 0 {
+		return nil
+	}
+	now := time.Now()
+	events := make([]models.OutboxEvent, 0, len(rates))
+	for _, rate := range rates {
+		payload, err := json.Marshal(rate)
+		if err != nil {
+			return fmt.Errorf("Ошибка сериализации события курса: %w", err)
+		}
+		events = append(events, models.OutboxEvent{
+			EventType:      eventType,
+			IdempotencyKey: RateEventKey(eventType, rate),
+			Payload:        string(payload),
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	if err := tx.CreateInBatches(events, insertBatchSize).Error; err != nil {
+		return fmt.Errorf("Ошибка записи события в outbox: %w", err)
+	}
+	return nil
+}
+
+// RateEventKey - ключ идемпотентности события: версия курса различает
+// последовательные изменения одной записи.
+func RateEventKey(eventType string, rate models.CurrencyRate) string {
+	return fmt.Sprintf("%s:%d:%d", eventType, rate.ID, rate.Version)
+}
+
+// ListPendingOutboxEvents возвращает не больше limit неопубликованных событий,
+// время повторной попытки которых наступило к now, в порядке записи.
+func (adapter *DbAdapter) ListPendingOutboxEvents(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	result := adapter.db.WithContext(ctx).
+		Where("published_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&events)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения событий outbox: %w", result.Error)
+	}
+	return events, nil
+}
+
+func (adapter *DbAdapter) MarkOutboxEventPublished(ctx context.Context, id int64, at time.Time) error {
+	result := adapter.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"published_at": at,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("Ошибка отметки публикации события outbox: %w", result.Error)
+	}
+	return nil
+}
+
+// MarkOutboxEventFailed записывает ошибку публикации и откладывает следующую
+// попытку до nextAttemptAt.
+func (adapter *DbAdapter) MarkOutboxEventFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	result := adapter.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      reason,
+			"next_attempt_at": nextAttemptAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("Ошибка записи неудачной публикации события outbox: %w", result.Error)
+	}
+	return nil
+}
+
+// DeletePublishedOutboxEvents удаляет события, опубликованные раньше before.
+func (adapter *DbAdapter) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	result := adapter.db.WithContext(ctx).
+		Where("published_at IS NOT NULL AND published_at < ?", before).
+		Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("Ошибка удаления опубликованных событий outbox: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/models"
+)
+
+func newOutboxTestAdapter(t *testing.T) *DbAdapter {
+	t.Helper()
+	adapter := newSQLiteTestAdapter(t)
+	adapter.outbox = true
+	return adapter
+}
+
+// outboxEvents возвращает все события outbox в порядке записи.
+func outboxEvents(t *testing.T, adapter *DbAdapter) []models.OutboxEvent {
+	t.Helper()
+	var events []models.OutboxEvent
+	require.NoError(t, adapter.db.Order("id").Find(&events).Error)
+	return events
+}
+
+func TestOutbox_EventsFollowRateChanges(t *testing.T) {
+	adapter := newOutboxTestAdapter(t)
+	ctx := context.Background()
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	createRate(t, adapter, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: ts})
+	require.NoError(t, adapter.CreateCurrencyRates(ctx, []models.CurrencyRate{
+		{Pair: "USDT/RUB", AskPrice: 92, BidPrice: 91, Timestamp: ts.Add(time.Second)},
+		{Pair: "USDT/USD", AskPrice: 1.01, BidPrice: 1, Timestamp: ts.Add(time.Second)},
+	}))
+	updated, err := adapter.UpdateCurrencyRate(ctx, models.CurrencyRate{ID: 1, Version: 1, AskPrice: 95, BidPrice: 94, Timestamp: ts})
+	require.NoError(t, err)
+	require.NoError(t, adapter.DeleteCurrencyRate(ctx, 2, 1))
+
+	events := outboxEvents(t, adapter)
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, event.IdempotencyKey)
+		assert.Nil(t, event.PublishedAt)
+	}
+	assert.Equal(t, []string{
+		"rate.created:1:1",
+		"rate.created:2:1",
+		"rate.created:3:1",
+		"rate.updated:1:2",
+		"rate.deleted:2:1",
+	}, keys)
+
+	var payload models.CurrencyRate
+	require.NoError(t, json.Unmarshal([]byte(events[3].Payload), &payload))
+	assert.Equal(t, models.EventRateUpdated, events[3].EventType)
+	assert.Equal(t, updated.ID, payload.ID)
+	assert.Equal(t, 94.0, payload.BidPrice)
+	assert.Equal(t, int64(2), payload.Version)
+}
+
+func TestOutbox_RolledBackWithRate(t *testing.T) {
+	adapter := newOutboxTestAdapter(t)
+	ctx := context.Background()
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	createRate(t, adapter, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: ts})
+
+	// Занятый ключ следующего события ломает запись в outbox, и курс не
+	// должен сохраниться без своего события.
+	require.NoError(t, adapter.db.Create(&models.OutboxEvent{EventType: models.EventRateCreated,
+		IdempotencyKey: "rate.created:2:1", Payload: "{}", NextAttemptAt: ts}).Error)
+	next := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 92, BidPrice: 91, Timestamp: ts.Add(time.Second)}
+	_, _, err := adapter.CreateCurrencyRate(ctx, next)
+	assert.Error(t, err)
+	assert.Error(t, adapter.CreateCurrencyRates(ctx, []models.CurrencyRate{next}))
+
+	rates, err := adapter.GetAllCurrencyRates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, rates, 1)
+	assert.Len(t, outboxEvents(t, adapter), 2)
+
+	// Повторный снимок не сохраняется и не порождает события.
+	_, created, err := adapter.CreateCurrencyRate(ctx, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: ts})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Len(t, outboxEvents(t, adapter), 2)
+}
+
+func TestOutbox_Disabled(t *testing.T) {
+	adapter := newSQLiteTestAdapter(t)
+	createRate(t, adapter, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: time.Now()})
+	assert.Empty(t, outboxEvents(t, adapter))
+}
+
+func TestOutbox_Lifecycle(t *testing.T) {
+	adapter := newOutboxTestAdapter(t)
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		createRate(t, adapter, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: now.Add(time.Duration(i) * time.Second)})
+	}
+
+	pending, err := adapter.ListPendingOutboxEvents(ctx, now.Add(time.Second), 2)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Less(t, pending[0].ID, pending[1].ID)
+
+	require.NoError(t, adapter.MarkOutboxEventPublished(ctx, pending[0].ID, now))
+	require.NoError(t, adapter.MarkOutboxEventFailed(ctx, pending[1].ID, "приёмник недоступен", now.Add(time.Minute)))
+
+	// Опубликованное и отложенное события не выбираются до срока повтора.
+	pending, err = adapter.ListPendingOutboxEvents(ctx, now.Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "rate.created:3:1", pending[0].IdempotencyKey)
+
+	pending, err = adapter.ListPendingOutboxEvents(ctx, now.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "приёмник недоступен", pending[0].LastError)
+
+	deleted, err := adapter.DeletePublishedOutboxEvents(ctx, now.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	deleted, err = adapter.DeletePublishedOutboxEvents(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, outboxEvents(t, adapter), 2)
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"time"
+	"usdt/internal/models"
+)
+
+const partitionPrefix = "currency_rates_"
+
+// defaultPartition - секция, в которую попадают строки вне месячных секций.
+const defaultPartition = "currency_rates_default"
+
+var partitionNamePattern = regexp.MustCompile(`^currency_rates_(\d{4}_\d{2})$`)
+
+// PartitionName возвращает имя месячной секции currency_rates, содержащей month.
+func PartitionName(month time.Time) string {
+	return partitionPrefix + month.UTC().Format("2006_01")
+}
+
+// monthStart возвращает начало месяца t по UTC.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ListCurrencyRatePartitions возвращает месячные секции currency_rates по
+// возрастанию. Секция по умолчанию в список не попадает.
+func (adapter *DbAdapter) ListCurrencyRatePartitions(ctx context.Context) ([]models.Partition, error) {
+	var names []string
+	result := adapter.db.WithContext(ctx).Raw(`
+SELECT child.relname
+FROM pg_inherits
+JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+WHERE parent.relname = 'currency_rates'
+ORDER BY child.relname`).Scan(&names)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения секций курсов: %w", result.Error)
+	}
+
+	partitions := make([]models.Partition, 0, len(names))
+	for _, name := range names {
+		match := partitionNamePattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		from, err := time.Parse("2006_01", match[1])
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, models.Partition{Name: name, From: from, To: from.AddDate(0, 1, 0)})
+	}
+	return partitions, nil
+}
+
+// CreateCurrencyRatePartition создаёт секцию currency_rates на месяц, содержащий month.
+// Postgres не создаёт секцию, пока строки её диапазона лежат в секции по
+// умолчанию, поэтому такие строки переносятся: секция по умолчанию
+// отсоединяется, строки копируются в новую секцию и удаляются из неё, после
+// чего она присоединяется обратно. Всё это выполняется в одной транзакции.
+func (adapter *DbAdapter) CreateCurrencyRatePartition(ctx context.Context, month time.Time) (models.Partition, error) {
+	from := monthStart(month)
+	partition := models.Partition{Name: PartitionName(from), From: from, To: from.AddDate(0, 1, 0)}
+	err := adapter.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", partition.Name).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+		// Блокировка не даёт новым строкам диапазона попасть в секцию по
+		// умолчанию между проверкой и созданием секции.
+		if err := tx.Exec(fmt.Sprintf("LOCK TABLE %s IN EXCLUSIVE MODE", defaultPartition)).Error; err != nil {
+			return err
+		}
+		var stray int64
+		err := tx.Raw(fmt.Sprintf("SELECT count(*) FROM %s WHERE timestamp >= ? AND timestamp < ?", defaultPartition),
+			partition.From, partition.To).Scan(&stray).Error
+		if err != nil {
+			return err
+		}
+		create := fmt.Sprintf("CREATE TABLE %s PARTITION OF currency_rates FOR VALUES FROM ('%s') TO ('%s')",
+			partition.Name, partition.From.Format(time.RFC3339), partition.To.Format(time.RFC3339))
+		if stray == 0 {
+			return tx.Exec(create).Error
+		}
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE currency_rates DETACH PARTITION %s", defaultPartition),
+			create,
+			fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE timestamp >= '%s' AND timestamp < '%s'",
+				partition.Name, defaultPartition, partition.From.Format(time.RFC3339), partition.To.Format(time.RFC3339)),
+			fmt.Sprintf("DELETE FROM %s WHERE timestamp >= '%s' AND timestamp < '%s'",
+				defaultPartition, partition.From.Format(time.RFC3339), partition.To.Format(time.RFC3339)),
+			fmt.Sprintf("ALTER TABLE currency_rates ATTACH PARTITION %s DEFAULT", defaultPartition),
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Partition{}, fmt.Errorf("Ошибка создания секции %s: %w", partition.Name, err)
+	}
+	return partition, nil
+}
+
+// DropCurrencyRatePartition отсоединяет секцию от currency_rates и удаляет её.
+func (adapter *DbAdapter) DropCurrencyRatePartition(ctx context.Context, name string) error {
+	// Имя подставляется в DDL как идентификатор, поэтому принимаются только
+	// имена месячных секций.
+	if !partitionNamePattern.MatchString(name) {
+		return fmt.Errorf("Ошибка удаления секции: недопустимое имя %q", name)
+	}
+	err := adapter.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE currency_rates DETACH PARTITION %s", name)).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("DROP TABLE %s", name)).Error
+	})
+	if err != nil {
+		return fmt.Errorf("Ошибка удаления секции %s: %w", name, err)
+	}
+	return nil
+}
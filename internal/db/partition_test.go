@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"usdt/internal/models"
+)
+
+// explainHistory возвращает план запроса истории, который выполняет GetCurrencyRateHistory.
+func explainHistory(t *testing.T, adapter *DbAdapter, pair string, from, to time.Time) string {
+	t.Helper()
+	query := adapter.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return historyQuery(tx, pair, from, to).Find(&[]models.CurrencyRate{})
+	})
+	var lines []string
+	require.NoError(t, adapter.db.Raw("EXPLAIN "+query).Scan(&lines).Error)
+	return strings.Join(lines, "\n")
+}
+
+func TestPartitionName(t *testing.T) {
+	assert.Equal(t, "currency_rates_2024_03", PartitionName(time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)))
+	// Секции нарезаются по UTC: 02:00 по Москве 1 апреля - ещё март.
+	assert.Equal(t, "currency_rates_2024_03", PartitionName(time.Date(2024, 4, 1, 2, 0, 0, 0, time.FixedZone("MSK", 3*3600))))
+}
+
+func TestDbAdapter_CurrencyRatePartitions(t *testing.T) {
+	adapter := newTestAdapter(t)
+	ctx := context.Background()
+	pair := "USDT/TST"
+	january := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := january.AddDate(0, 1, 0)
+	for _, month := range []time.Time{january, february} {
+		adapter.DropCurrencyRatePartition(ctx, PartitionName(month))
+	}
+	t.Cleanup(func() {
+		for _, month := range []time.Time{january, february} {
+			adapter.DropCurrencyRatePartition(ctx, PartitionName(month))
+		}
+	})
+
+	created, err := adapter.CreateCurrencyRatePartition(ctx, january.Add(10*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, models.Partition{Name: "currency_rates_2099_01", From: january, To: february}, created)
+	_, err = adapter.CreateCurrencyRatePartition(ctx, february)
+	require.NoError(t, err)
+	// Повторное создание не должно падать.
+	_, err = adapter.CreateCurrencyRatePartition(ctx, february)
+	require.NoError(t, err)
+
+	partitions, err := adapter.ListCurrencyRatePartitions(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, partitions, created)
+	for _, partition := range partitions {
+		assert.NotEqual(t, "currency_rates_default", partition.Name)
+	}
+
+	createRate(t, adapter, models.CurrencyRate{Pair: pair, AskPrice: 101, BidPrice: 100, Timestamp: january.Add(5 * 24 * time.Hour)})
+	createRate(t, adapter, models.CurrencyRate{Pair: pair, AskPrice: 102, BidPrice: 101, Timestamp: february.Add(5 * 24 * time.Hour)})
+
+	t.Run("PrunesToSinglePartition", func(t *testing.T) {
+		plan := explainHistory(t, adapter, pair, january.Add(24*time.Hour), january.Add(20*24*time.Hour))
+		assert.Contains(t, plan, "currency_rates_2099_01")
+		assert.NotContains(t, plan, "currency_rates_2099_02")
+		assert.NotContains(t, plan, "currency_rates_default")
+
+		rates, err := adapter.GetCurrencyRateHistory(ctx, pair, january.Add(24*time.Hour), january.Add(20*24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, rates, 1)
+		assert.Equal(t, 100.0, rates[0].BidPrice)
+	})
+
+	t.Run("PrunesToOverlappingPartitions", func(t *testing.T) {
+		plan := explainHistory(t, adapter, pair, january.Add(24*time.Hour), february.Add(20*24*time.Hour))
+		assert.Contains(t, plan, "currency_rates_2099_01")
+		assert.Contains(t, plan, "currency_rates_2099_02")
+		assert.NotContains(t, plan, "currency_rates_default")
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		require.NoError(t, adapter.DropCurrencyRatePartition(ctx, created.Name))
+		partitions, err := adapter.ListCurrencyRatePartitions(ctx)
+		require.NoError(t, err)
+		assert.NotContains(t, partitions, created)
+
+		rates, err := adapter.GetCurrencyRateHistory(ctx, pair, january, february.AddDate(0, 1, 0))
+		require.NoError(t, err)
+		require.Len(t, rates, 1)
+		assert.Equal(t, 101.0, rates[0].BidPrice)
+	})
+
+	t.Run("MovesRowsFromDefault", func(t *testing.T) {
+		march := february.AddDate(0, 1, 0)
+		adapter.DropCurrencyRatePartition(ctx, PartitionName(march))
+		t.Cleanup(func() { adapter.DropCurrencyRatePartition(ctx, PartitionName(march)) })
+
+		// Секции марта ещё нет, и курс попадает в секцию по умолчанию.
+		createRate(t, adapter, models.CurrencyRate{Pair: pair, AskPrice: 104, BidPrice: 103, Timestamp: march.Add(5 * 24 * time.Hour)})
+		partition, err := adapter.CreateCurrencyRatePartition(ctx, march)
+		require.NoError(t, err)
+
+		var inPartition, inDefault int64
+		require.NoError(t, adapter.db.Raw("SELECT count(*) FROM "+partition.Name).Scan(&inPartition).Error)
+		require.NoError(t, adapter.db.Raw("SELECT count(*) FROM currency_rates_default WHERE pair = ?", pair).Scan(&inDefault).Error)
+		assert.Equal(t, int64(1), inPartition)
+		assert.Zero(t, inDefault)
+
+		rates, err := adapter.GetCurrencyRateHistory(ctx, pair, march, march.AddDate(0, 1, 0))
+		require.NoError(t, err)
+		require.Len(t, rates, 1)
+		assert.Equal(t, 103.0, rates[0].BidPrice)
+	})
+
+	t.Run("RejectsForeignName", func(t *testing.T) {
+		assert.Error(t, adapter.DropCurrencyRatePartition(ctx, "currency_rates; DROP TABLE quotes"))
+		assert.Error(t, adapter.DropCurrencyRatePartition(ctx, "currency_rates_default"))
+	})
+}
@@ -0,0 +1,11 @@
+package db
+
+import (
+	"context"
+	"usdt/internal/models"
+)
+
+func (adapter *DbAdapter) CreateQuarantinedRate(ctx context.Context, rate models.QuarantinedRate) error {
+	result := adapter.db.WithContext(ctx).Create(&rate)
+	return result.Error
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IncrementClientQuota учитывает запрос клиента за сутки UTC, в которые
+// попадает day, если за эти сутки учтено меньше limit запросов, и сообщает,
+// учтён ли он. Счётчик проверяется и увеличивается одним запросом, поэтому
+// параллельные запросы не теряются и не превышают limit, а отклонённые
+// запросы квоту не расходуют.
+func (adapter *DbAdapter) IncrementClientQuota(ctx context.Context, client string, day time.Time, limit int64) (bool, error) {
+	var used []int64
+	result := adapter.db.WithContext(ctx).Raw(`INSERT INTO client_quotas (client, day, used) VALUES (?, ?, 1)
+		ON CONFLICT (client, day) DO UPDATE SET used = client_quotas.used + 1
+		WHERE client_quotas.used < ?
+		RETURNING used`, client, day.UTC().Format(time.DateOnly), limit).Scan(&used)
+	if result.Error != nil {
+		return false, fmt.Errorf("Ошибка учёта квоты клиента: %w", result.Error)
+	}
+	return len(used) > 0, nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClientQuota(t *testing.T, adapter *DbAdapter) {
+	ctx := context.Background()
+	client := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	day := time.Date(2024, 10, 27, 23, 30, 0, 0, time.UTC)
+
+	const limit = 8
+	admitted, err := adapter.IncrementClientQuota(ctx, client, day, limit)
+	require.NoError(t, err)
+	assert.True(t, admitted)
+
+	// Из 10 параллельных запросов учитываются ровно 7 до квоты.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counted := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			admitted, err := adapter.IncrementClientQuota(ctx, client, day, limit)
+			assert.NoError(t, err)
+			if admitted {
+				mu.Lock()
+				counted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, limit-1, counted, "параллельные запросы не превышают квоту")
+
+	msk := time.FixedZone("MSK", 3*3600)
+	admitted, err = adapter.IncrementClientQuota(ctx, client, day.In(msk), limit+1)
+	require.NoError(t, err)
+	assert.True(t, admitted, "сутки считаются по UTC, а отклонённые запросы квоту не расходуют")
+	admitted, err = adapter.IncrementClientQuota(ctx, client, day.Add(-time.Hour), limit+1)
+	require.NoError(t, err)
+	assert.False(t, admitted)
+
+	admitted, err = adapter.IncrementClientQuota(ctx, client, day.Add(time.Hour), limit)
+	require.NoError(t, err)
+	assert.True(t, admitted, "новые сутки - новый счётчик")
+
+	var used int64
+	require.NoError(t, adapter.db.Raw("SELECT used FROM client_quotas WHERE client = ? AND day = ?", client, day.Format(time.DateOnly)).Scan(&used).Error)
+	assert.Equal(t, int64(limit+1), used)
+}
+
+func TestDbAdapter_ClientQuota(t *testing.T) {
+	testClientQuota(t, newTestAdapter(t))
+}
+
+func TestSQLiteAdapter_ClientQuota(t *testing.T) {
+	testClientQuota(t, newSQLiteTestAdapter(t))
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"usdt/internal/models"
+)
+
+func (adapter *DbAdapter) CreateQuote(ctx context.Context, quote models.Quote) error {
+	result := adapter.db.WithContext(ctx).Create(&quote)
+	return result.Error
+}
+
+func (adapter *DbAdapter) GetQuote(ctx context.Context, id string) (*models.Quote, error) {
+	var quote models.Quote
+	result := adapter.db.WithContext(ctx).Where("id = ?", id).First(&quote)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Ошибка получения котировки по ID: %w", result.Error)
+	}
+	return &quote, nil
+}
+
+// RedeemQuote помечает котировку использованной одним UPDATE, поэтому из
+// нескольких одновременных попыток успешной будет ровно одна.
+func (adapter *DbAdapter) RedeemQuote(ctx context.Context, id string, now time.Time) (*models.Quote, error) {
+	result := adapter.db.WithContext(ctx).Model(&models.Quote{}).
+		Where("id = ? AND redeemed_at IS NULL AND expires_at > ?", id, now).
+		Update("redeemed_at", now)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка погашения котировки: %w", result.Error)
+	}
+
+	quote, err := adapter.GetQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote == nil {
+		return nil, models.ErrQuoteNotFound
+	}
+	if result.RowsAffected == 0 {
+		if quote.RedeemedAt != nil {
+			return nil, models.ErrQuoteRedeemed
+		}
+		return nil, models.ErrQuoteExpired
+	}
+	return quote, nil
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/models"
+)
+
+func newTestQuote(t *testing.T, expiresAt time.Time) models.Quote {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return models.Quote{
+		ID:            hex.EncodeToString(b),
+		Pair:          "USDT/RUB",
+		AskPrice:      101,
+		BidPrice:      98,
+		MarkupPercent: 1,
+		RateTimestamp: time.Now(),
+		ExpiresAt:     expiresAt,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func TestDbAdapter_RedeemQuote(t *testing.T) {
+	adapter := newTestAdapter(t)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		quote := newTestQuote(t, time.Now().Add(time.Minute))
+		require.NoError(t, adapter.CreateQuote(ctx, quote))
+
+		redeemed, err := adapter.RedeemQuote(ctx, quote.ID, time.Now())
+		require.NoError(t, err)
+		assert.NotNil(t, redeemed.RedeemedAt)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		quote := newTestQuote(t, time.Now().Add(-time.Second))
+		require.NoError(t, adapter.CreateQuote(ctx, quote))
+
+		_, err := adapter.RedeemQuote(ctx, quote.ID, time.Now())
+		assert.ErrorIs(t, err, models.ErrQuoteExpired)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := adapter.RedeemQuote(ctx, "missing", time.Now())
+		assert.ErrorIs(t, err, models.ErrQuoteNotFound)
+	})
+
+	t.Run("DoubleRedemption", func(t *testing.T) {
+		quote := newTestQuote(t, time.Now().Add(time.Minute))
+		require.NoError(t, adapter.CreateQuote(ctx, quote))
+
+		_, err := adapter.RedeemQuote(ctx, quote.ID, time.Now())
+		require.NoError(t, err)
+		_, err = adapter.RedeemQuote(ctx, quote.ID, time.Now())
+		assert.ErrorIs(t, err, models.ErrQuoteRedeemed)
+	})
+
+	t.Run("ConcurrentRedemption", func(t *testing.T) {
+		quote := newTestQuote(t, time.Now().Add(time.Minute))
+		require.NoError(t, adapter.CreateQuote(ctx, quote))
+
+		const workers = 20
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			succeeded int
+			redeemed  int
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := adapter.RedeemQuote(ctx, quote.ID, time.Now())
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					succeeded++
+				case assert.ErrorIs(t, err, models.ErrQuoteRedeemed):
+					redeemed++
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, workers-1, redeemed)
+	})
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+// downsampleQuery удаляет пакет самых старых сырых курсов и в том же
+// выражении добавляет их в минутные и часовые агрегаты своего поставщика,
+// поэтому каждая строка учитывается ровно один раз даже при сбое между
+// пакетами.
+const downsampleQuery = `
+WITH batch AS (
+    DELETE FROM currency_rates
+    WHERE (id, timestamp) IN (
+        SELECT id, timestamp FROM currency_rates
+        WHERE timestamp < @before
+        ORDER BY timestamp
+        LIMIT @limit
+    )
+    RETURNING pair, source, ask_price, bid_price, timestamp
+), minute AS (
+    INSERT INTO currency_rate_aggregates AS a
+        (pair, source, resolution, bucket_start, samples, ask_sum, bid_sum, ask_min, ask_max, bid_min, bid_max)
+    SELECT pair, source, 'minute', date_trunc('minute', timestamp, 'UTC'), count(*),
+        sum(ask_price), sum(bid_price), min(ask_price), max(ask_price), min(bid_price), max(bid_price)
+    FROM batch
+    GROUP BY pair, source, date_trunc('minute', timestamp, 'UTC')
+    ON CONFLICT (pair, source, resolution, bucket_start) DO UPDATE SET
+        samples = a.samples + EXCLUDED.samples,
+        ask_sum = a.ask_sum + EXCLUDED.ask_sum,
+        bid_sum = a.bid_sum + EXCLUDED.bid_sum,
+        ask_min = LEAST(a.ask_min, EXCLUDED.ask_min),
+        ask_max = GREATEST(a.ask_max, EXCLUDED.ask_max),
+        bid_min = LEAST(a.bid_min, EXCLUDED.bid_min),
+        bid_max = GREATEST(a.bid_max, EXCLUDED.bid_max)
+    RETURNING 1
+), hour AS (
+    INSERT INTO currency_rate_aggregates AS a
+        (pair, source, resolution, bucket_start, samples, ask_sum, bid_sum, ask_min, ask_max, bid_min, bid_max)
+    SELECT pair, source, 'hour', date_trunc('hour', timestamp, 'UTC'), count(*),
+        sum(ask_price), sum(bid_price), min(ask_price), max(ask_price), min(bid_price), max(bid_price)
+    FROM batch
+    GROUP BY pair, source, date_trunc('hour', timestamp, 'UTC')
+    ON CONFLICT (pair, source, resolution, bucket_start) DO UPDATE SET
+        samples = a.samples + EXCLUDED.samples,
+        ask_sum = a.ask_sum + EXCLUDED.ask_sum,
+        bid_sum = a.bid_sum + EXCLUDED.bid_sum,
+        ask_min = LEAST(a.ask_min, EXCLUDED.ask_min),
+        ask_max = GREATEST(a.ask_max, EXCLUDED.ask_max),
+        bid_min = LEAST(a.bid_min, EXCLUDED.bid_min),
+        bid_max = GREATEST(a.bid_max, EXCLUDED.bid_max)
+    RETURNING 1
+)
+SELECT
+    (SELECT count(*) FROM batch) AS raw_deleted,
+    (SELECT count(*) FROM minute) AS minute_aggregates,
+    (SELECT count(*) FROM hour) AS hour_aggregates`
+
+// DownsampleCurrencyRates переносит в агрегаты не больше limit сырых курсов старше before.
+func (adapter *DbAdapter) DownsampleCurrencyRates(ctx context.Context, before time.Time, limit int) (models.DownsampleResult, error) {
+	var result models.DownsampleResult
+	err := adapter.db.WithContext(ctx).
+		Raw(downsampleQuery, map[string]interface{}{"before": before, "limit": limit}).
+		Row().
+		Scan(&result.RawDeleted, &result.MinuteAggregates, &result.HourAggregates)
+	if err != nil {
+		return models.DownsampleResult{}, fmt.Errorf("Ошибка прореживания курсов: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteCurrencyRateAggregates удаляет не больше limit агрегатов разрешения старше before.
+func (adapter *DbAdapter) DeleteCurrencyRateAggregates(ctx context.Context, resolution string, before time.Time, limit int) (int64, error) {
+	result := adapter.db.WithContext(ctx).Exec(`
+DELETE FROM currency_rate_aggregates
+WHERE id IN (
+    SELECT id FROM currency_rate_aggregates
+    WHERE resolution = ? AND bucket_start < ?
+    ORDER BY bucket_start
+    LIMIT ?
+)`, resolution, before, limit)
+	if result.Error != nil {
+		return 0, fmt.Errorf("Ошибка удаления агрегатов курсов: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetCurrencyRateAggregates возвращает агрегаты пары всех поставщиков по
+// возрастанию начала интервала.
+func (adapter *DbAdapter) GetCurrencyRateAggregates(ctx context.Context, pair, resolution string, from, to time.Time) ([]models.CurrencyRateAggregate, error) {
+	var aggregates []models.CurrencyRateAggregate
+	result := adapter.db.WithContext(ctx).
+		Where("pair = ? AND resolution = ? AND bucket_start >= ? AND bucket_start <= ?", pair, resolution, from, to).
+		Order("bucket_start, source").
+		Find(&aggregates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Ошибка получения агрегатов курсов: %w", result.Error)
+	}
+	return aggregates, nil
+}
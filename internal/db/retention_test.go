@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/models"
+)
+
+func TestDbAdapter_DownsampleCurrencyRates(t *testing.T) {
+	adapter := newTestAdapter(t)
+	ctx := context.Background()
+	pair := "USDT/TST"
+	bucket := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, adapter.db.Exec("DELETE FROM currency_rates WHERE pair = ?", pair).Error)
+	require.NoError(t, adapter.db.Exec("DELETE FROM currency_rate_aggregates WHERE pair = ?", pair).Error)
+
+	prices := []float64{100, 102, 101, 105}
+	for i, price := range prices {
+		rate := models.CurrencyRate{Pair: pair, AskPrice: price + 1, BidPrice: price, Timestamp: bucket.Add(time.Duration(i) * 20 * time.Second), Source: "garantex"}
+		createRate(t, adapter, rate)
+	}
+	// Снимок другого поставщика попадает в свои агрегаты.
+	createRate(t, adapter, models.CurrencyRate{Pair: pair, AskPrice: 51, BidPrice: 50, Timestamp: bucket, Source: "simulate"})
+
+	// Два пакета по 3 строки: агрегаты должны дополниться, а не перезаписаться.
+	first, err := adapter.DownsampleCurrencyRates(ctx, bucket.Add(time.Hour), 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), first.RawDeleted)
+	second, err := adapter.DownsampleCurrencyRates(ctx, bucket.Add(time.Hour), 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), second.RawDeleted)
+
+	hours, err := adapter.GetCurrencyRateAggregates(ctx, pair, models.ResolutionHour, bucket, bucket)
+	require.NoError(t, err)
+	require.Len(t, hours, 2)
+	assert.Equal(t, "garantex", hours[0].Source)
+	assert.Equal(t, "simulate", hours[1].Source)
+	assert.Equal(t, int64(1), hours[1].Samples)
+	assert.Equal(t, 50.0, hours[1].BidMin)
+	assert.Equal(t, int64(4), hours[0].Samples)
+	assert.Equal(t, 100.0, hours[0].BidMin)
+	assert.Equal(t, 105.0, hours[0].BidMax)
+	assert.InDelta(t, 102.0, hours[0].BidAvg(), 1e-9)
+
+	minutes, err := adapter.GetCurrencyRateAggregates(ctx, pair, models.ResolutionMinute, bucket, bucket.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, minutes, 3)
+	assert.Equal(t, int64(3), minutes[0].Samples)
+	assert.Equal(t, "simulate", minutes[1].Source)
+	assert.Equal(t, int64(1), minutes[2].Samples)
+
+	remaining, err := adapter.GetCurrencyRateHistory(ctx, pair, bucket.Add(-time.Hour), bucket.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	deleted, err := adapter.DeleteCurrencyRateAggregates(ctx, models.ResolutionMinute, bucket.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"usdt/config"
+	migrate "usdt/internal/infrastructure/db"
+	"usdt/internal/models"
+	"usdt/internal/modules/storage"
+)
+
+// newSQLiteTestAdapter создаёт базу SQLite во временном каталоге и
+// выполняет на ней миграции SQLite.
+func newSQLiteTestAdapter(t *testing.T) *DbAdapter {
+	t.Helper()
+	conf := config.Config{
+		MigrationsPath: "file://../infrastructure/db/migrations_sqlite",
+		Db: config.DB{
+			Driver: config.DriverSQLite,
+			Path:   filepath.Join(t.TempDir(), "usdt.db"),
+		},
+	}
+	if err := migrate.RunMigrations(conf, zap.NewNop()); err != nil {
+		t.Fatalf("не удалось выполнить миграции: %v", err)
+	}
+	adapter, err := NewDB(conf)
+	if err != nil {
+		t.Fatalf("не удалось открыть базу: %v", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+	return adapter
+}
+
+func TestSQLiteAdapter_MemoryDB(t *testing.T) {
+	conf := config.Config{
+		MigrationsPath: "file://../infrastructure/db/migrations_sqlite",
+		Db:             config.DB{Driver: config.DriverSQLite, Path: config.MemoryDBPath},
+	}
+	// Как и при запуске сервиса, база открывается до миграций, иначе она
+	// исчезнет вместе с соединением мигратора.
+	adapter, err := NewDB(conf)
+	require.NoError(t, err)
+	defer adapter.Close()
+	require.NoError(t, migrate.RunMigrations(conf, zap.NewNop()))
+
+	quote := models.Quote{ID: "q1", Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90,
+		RateTimestamp: time.Now(), ExpiresAt: time.Now().Add(time.Minute), CreatedAt: time.Now()}
+	require.NoError(t, adapter.CreateQuote(context.Background(), quote))
+	stored, err := adapter.GetQuote(context.Background(), "q1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "USDT/RUB", stored.Pair)
+}
+
+func TestSQLiteAdapter_Conformance(t *testing.T) {
+	runUsdtStoragerConformance(t, func(t *testing.T) storage.UsdtStorager {
+		return newSQLiteTestAdapter(t)
+	})
+}
+
+func TestSQLiteAdapter_MixedTimeZones(t *testing.T) {
+	adapter := newSQLiteTestAdapter(t)
+	ctx := context.Background()
+	msk := time.FixedZone("MSK", 3*3600)
+	pair := "USDT/RUB"
+
+	// 01:30 MSK (22:30 UTC) раньше 23:00 UTC, хотя строкой с зоной выглядит позже.
+	createRate(t, adapter, models.CurrencyRate{Pair: pair, AskPrice: 91, BidPrice: 90,
+		Timestamp: time.Date(2024, 5, 2, 1, 30, 0, 0, msk)})
+	createRate(t, adapter, models.CurrencyRate{Pair: pair, AskPrice: 93, BidPrice: 92,
+		Timestamp: time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)})
+
+	history, err := adapter.GetCurrencyRateHistory(ctx, pair,
+		time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 1, 45, 0, 0, msk))
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 90.0, history[0].BidPrice)
+
+	latest, err := adapter.GetLatestCurrencyRate(ctx, pair)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, 92.0, latest.BidPrice)
+	assert.True(t, latest.Timestamp.Equal(time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)))
+}
+
+func TestSQLiteAdapter_MarketStatsHistory(t *testing.T) {
+	adapter := newSQLiteTestAdapter(t)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, adapter.CreateMarketStats(ctx, models.MarketStats{Pair: "USDT/RUB", Timestamp: start.Add(time.Duration(i) * time.Minute),
+			AskPrice: 91, BidPrice: 90}))
+	}
+
+	stats, err := adapter.GetMarketStatsHistory(ctx, "USDT/RUB", start, start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, stats, 2)
+	latest, err := adapter.GetLatestMarketStats(ctx, "USDT/RUB")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.True(t, latest.Timestamp.Equal(start.Add(2*time.Minute)))
+}
@@ -0,0 +1,90 @@
+// Package gateway - REST шлюз к gRPC API: JSON запросы по маршрутам из опций
+// google.api.http в usdt.proto проксируются в gRPC сервер.
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"usdt/internal/modules/interceptor"
+	"usdt/internal/proto/usdt_proto"
+)
+
+// OpenAPIPath - путь документации OpenAPI на порту шлюза.
+const OpenAPIPath = "/openapi.json"
+
+// openAPISpec генерируется protoc-gen-openapiv2 из usdt.proto вместе с кодом шлюза.
+//
+//go:embed usdt.swagger.json
+var openAPISpec []byte
+
+// NewHandler возвращает HTTP обработчик шлюза, который вызывает RPC через conn.
+// Поля JSON называются как в proto (target_currency, ask_price), пустые поля
+// не опускаются. Каждый вызов подписывается secret, чтобы сервер доверял
+// переданному шлюзом адресу клиента.
+func NewHandler(ctx context.Context, conn *grpc.ClientConn, secret string) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+		runtime.WithMetadata(func(context.Context, *http.Request) metadata.MD {
+			return metadata.Pairs(interceptor.GatewaySecretHeader, secret)
+		}),
+	)
+	if err := usdt_proto.RegisterAuthServiceHandler(ctx, mux, conn); err != nil {
+		return nil, fmt.Errorf("не удалось зарегистрировать AuthService в шлюзе: %w", err)
+	}
+	if err := usdt_proto.RegisterRateAdminServiceHandler(ctx, mux, conn); err != nil {
+		return nil, fmt.Errorf("не удалось зарегистрировать RateAdminService в шлюзе: %w", err)
+	}
+
+	root := http.NewServeMux()
+	root.HandleFunc("GET "+OpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	root.Handle("/", mux)
+	return root, nil
+}
+
+// NewSecret создаёт случайный секрет шлюза.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать секрет шлюза: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// headerMatcher передаёт в gRPC заголовок X-Api-Key вдобавок к стандартным;
+// Authorization шлюз передаёт и так. Секрет шлюза от клиента не передаётся.
+func headerMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, interceptor.APIKeyHeader) {
+		return interceptor.APIKeyHeader, true
+	}
+	if strings.EqualFold(key, runtime.MetadataHeaderPrefix+interceptor.GatewaySecretHeader) {
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher отдаёт retry-after из ответа gRPC стандартным
+// заголовком Retry-After; остальные метаданные - с префиксом Grpc-Metadata-.
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == interceptor.RetryAfterHeader {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
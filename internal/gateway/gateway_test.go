@@ -0,0 +1,178 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"usdt/internal/modules/interceptor"
+	"usdt/internal/proto/usdt_proto"
+)
+
+// testSecret - секрет, которым шлюз подписывает вызовы в тестах.
+const testSecret = "gateway-secret"
+
+type fakeAuthServer struct {
+	usdt_proto.UnimplementedAuthServiceServer
+}
+
+func (fakeAuthServer) GetRates(ctx context.Context, req *usdt_proto.GetRatesRequest) (*usdt_proto.GetRatesResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if secrets := md.Get(interceptor.GatewaySecretHeader); len(secrets) != 1 || secrets[0] != testSecret {
+		return nil, status.Error(codes.PermissionDenied, "вызов не подписан шлюзом")
+	}
+	if keys := md.Get(interceptor.APIKeyHeader); len(keys) > 0 && keys[0] != "valid" {
+		return nil, status.Error(codes.Unauthenticated, "клиент не аутентифицирован")
+	}
+	if req.TargetCurrency == "LIMIT" {
+		grpc.SetHeader(ctx, metadata.Pairs(interceptor.RetryAfterHeader, "3"))
+		return nil, status.Error(codes.ResourceExhausted, "слишком много запросов")
+	}
+	if req.TargetCurrency != "RUB" {
+		return nil, status.Error(codes.NotFound, "курс не найден")
+	}
+	return &usdt_proto.GetRatesResponse{Rate: &usdt_proto.CurrencyRate{
+		Pair: "USDT/RUB", AskPrice: 91.5, BidPrice: 90, Timestamp: "2024-10-27T12:00:00Z", Source: "garantex",
+	}}, nil
+}
+
+func (fakeAuthServer) HealthCheck(context.Context, *usdt_proto.HealthCheckRequest) (*usdt_proto.HealthCheckResponse, error) {
+	return &usdt_proto.HealthCheckResponse{Status: "OK"}, nil
+}
+
+type fakeRateAdminServer struct {
+	usdt_proto.UnimplementedRateAdminServiceServer
+	last *usdt_proto.ListRatesRequest
+}
+
+func (s *fakeRateAdminServer) ListRates(_ context.Context, req *usdt_proto.ListRatesRequest) (*usdt_proto.ListRatesResponse, error) {
+	s.last = req
+	return &usdt_proto.ListRatesResponse{Rates: []*usdt_proto.CurrencyRate{{Id: 1, Pair: "USDT/RUB", Version: 1}}}, nil
+}
+
+// newTestGateway запускает gRPC сервер с фейковыми сервисами и шлюз к нему.
+func newTestGateway(t *testing.T, admin *fakeRateAdminServer) *httptest.Server {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	usdt_proto.RegisterAuthServiceServer(server, fakeAuthServer{})
+	usdt_proto.RegisterRateAdminServiceServer(server, admin)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	handler, err := NewHandler(context.Background(), conn, testSecret)
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getJSON(t *testing.T, url string) (int, map[string]any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func TestGateway(t *testing.T) {
+	admin := &fakeRateAdminServer{}
+	srv := newTestGateway(t, admin)
+
+	t.Run("GetRates", func(t *testing.T) {
+		code, body := getJSON(t, srv.URL+"/v1/rates/RUB")
+		assert.Equal(t, http.StatusOK, code)
+		rate := body["rate"].(map[string]any)
+		assert.Equal(t, "USDT/RUB", rate["pair"])
+		assert.Equal(t, 91.5, rate["ask_price"])
+		assert.Equal(t, "garantex", rate["source"])
+		assert.Contains(t, rate, "created_at", "пустые поля не опускаются")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		code, body := getJSON(t, srv.URL+"/v1/rates/XXX")
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, "курс не найден", body["message"])
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/v1/rates/LIMIT")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "3", resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("APIKey", func(t *testing.T) {
+		for key, code := range map[string]int{"valid": http.StatusOK, "stolen": http.StatusUnauthorized} {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/rates/RUB", nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Api-Key", key)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, code, resp.StatusCode, key)
+		}
+	})
+
+	t.Run("ForgedSecret", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/rates/RUB", nil)
+		require.NoError(t, err)
+		req.Header.Set("Grpc-Metadata-X-Gateway-Secret", "forged")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "секрет от клиента не передаётся")
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		code, body := getJSON(t, srv.URL+"/v1/health")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "OK", body["status"])
+	})
+
+	t.Run("History", func(t *testing.T) {
+		code, body := getJSON(t, srv.URL+"/v1/rates/RUB/history?from=2024-10-01T00:00:00Z&to=2024-10-02T00:00:00Z")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, body["rates"], 1)
+		require.NotNil(t, admin.last)
+		assert.Equal(t, "RUB", admin.last.TargetCurrency)
+		assert.Equal(t, "2024-10-01T00:00:00Z", admin.last.From)
+		assert.Equal(t, "2024-10-02T00:00:00Z", admin.last.To)
+	})
+
+	t.Run("OpenAPI", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + OpenAPIPath)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var spec struct {
+			Paths map[string]any `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(data, &spec))
+		assert.Contains(t, spec.Paths, "/v1/rates/{target_currency}")
+		assert.Contains(t, spec.Paths, "/v1/rates/{target_currency}/history")
+		assert.Contains(t, spec.Paths, "/v1/health")
+	})
+}
@@ -3,30 +3,30 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	_ "github.com/glebarez/sqlite"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"go.uber.org/zap"
+	"strings"
 	"usdt/config"
 )
 
 func RunMigrations(cfg config.Config, logger *zap.Logger) error {
-	connString := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
-		cfg.Db.User, cfg.Db.Password, cfg.Db.Host, cfg.Db.Port, cfg.Db.Database)
-
-	db, err := sql.Open("postgres", connString)
+	db, driver, err := openMigrationDriver(cfg.Db)
 	if err != nil {
-		return fmt.Errorf("Ошибка при подключении к базе данных: %w", err)
+		return err
 	}
 	defer db.Close()
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		return fmt.Errorf("Ошибка при подключении к базе данных (migrate): %w", err)
+	databaseName := cfg.Db.Driver
+	if databaseName == "" {
+		databaseName = config.DriverPostgres
 	}
-
-	migrationPath := "file:///app/migrations"
-	m, err := migrate.NewWithDatabaseInstance(migrationPath, "postgres", driver)
+	migrationPath := cfg.MigrationsPath
+	m, err := migrate.NewWithDatabaseInstance(migrationPath, databaseName, driver)
 	if err != nil {
 		return fmt.Errorf("Ошибка при создании мигратора: %w", err)
 	}
@@ -44,3 +44,45 @@ func RunMigrations(cfg config.Config, logger *zap.Logger) error {
 
 	return nil
 }
+
+// openMigrationDriver подключается к базе выбранного драйвера. Для SQLite
+// используется драйвер migrate "sqlite3" поверх соединения чистого Go
+// драйвера "sqlite" из glebarez/sqlite. Драйвер migrate "sqlite" на
+// modernc.org/sqlite не подходит: он регистрирует в database/sql то же имя
+// "sqlite", и процесс падает при инициализации. "sqlite3" только
+// импортирует mattn/go-sqlite3, но соединение через него не открывается,
+// поэтому миграции работают и в сборке с CGO_ENABLED=0 (make check-nocgo).
+func openMigrationDriver(cfg config.DB) (*sql.DB, database.Driver, error) {
+	switch cfg.Driver {
+	case "", config.DriverPostgres:
+		connString := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
+			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+		db, err := sql.Open("postgres", connString)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Ошибка при подключении к базе данных: %w", err)
+		}
+		driver, err := postgres.WithInstance(db, &postgres.Config{})
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("Ошибка при подключении к базе данных (migrate): %w", err)
+		}
+		return db, driver, nil
+	case config.DriverSQLite:
+		sep := "?"
+		if strings.Contains(cfg.Path, "?") {
+			sep = "&"
+		}
+		db, err := sql.Open("sqlite", "file:"+cfg.Path+sep+"_pragma=busy_timeout(5000)")
+		if err != nil {
+			return nil, nil, fmt.Errorf("Ошибка при подключении к базе данных: %w", err)
+		}
+		driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("Ошибка при подключении к базе данных (migrate): %w", err)
+		}
+		return db, driver, nil
+	default:
+		return nil, nil, fmt.Errorf("Неизвестный драйвер базы данных: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,13 @@
+CREATE TABLE quotes (
+    id VARCHAR(32) PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    ask_price DECIMAL(18, 8) NOT NULL,
+    bid_price DECIMAL(18, 8) NOT NULL,
+    markup_percent DECIMAL(6, 3) NOT NULL,
+    rate_timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    redeemed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_quotes_expires_at ON quotes (expires_at);
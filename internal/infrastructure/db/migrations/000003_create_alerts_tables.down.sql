@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_currency_rates_pair_timestamp;
+DROP TABLE IF EXISTS alert_deliveries;
+DROP TABLE IF EXISTS alert_rules;
@@ -0,0 +1,28 @@
+CREATE TABLE alert_rules (
+    id SERIAL PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    condition VARCHAR(32) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    window_seconds BIGINT NOT NULL DEFAULT 0,
+    webhook_url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_alert_rules_pair ON alert_rules (pair);
+
+CREATE TABLE alert_deliveries (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules (id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    delivered BOOLEAN NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_alert_deliveries_rule_id ON alert_deliveries (rule_id, created_at);
+
+CREATE INDEX idx_currency_rates_pair_timestamp ON currency_rates (pair, timestamp);
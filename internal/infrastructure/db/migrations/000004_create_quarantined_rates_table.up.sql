@@ -0,0 +1,12 @@
+CREATE TABLE quarantined_rates (
+    id SERIAL PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    ask_price DECIMAL(10, 2) NOT NULL,
+    bid_price DECIMAL(10, 2) NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_quarantined_rates_pair_created_at ON quarantined_rates (pair, created_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS market_stats;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE market_stats (
+    id SERIAL PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    ask_price DOUBLE PRECISION NOT NULL,
+    bid_price DOUBLE PRECISION NOT NULL,
+    mid_price DOUBLE PRECISION NOT NULL,
+    spread DOUBLE PRECISION NOT NULL,
+    spread_percent DOUBLE PRECISION NOT NULL,
+    ask_depth_05 DOUBLE PRECISION NOT NULL,
+    bid_depth_05 DOUBLE PRECISION NOT NULL,
+    ask_depth_1 DOUBLE PRECISION NOT NULL,
+    bid_depth_1 DOUBLE PRECISION NOT NULL,
+    ask_depth_2 DOUBLE PRECISION NOT NULL,
+    bid_depth_2 DOUBLE PRECISION NOT NULL,
+    imbalance DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_market_stats_pair_timestamp ON market_stats (pair, timestamp);
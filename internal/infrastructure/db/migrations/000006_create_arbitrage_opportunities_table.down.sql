@@ -0,0 +1 @@
+DROP TABLE IF EXISTS arbitrage_opportunities;
\ No newline at end of file
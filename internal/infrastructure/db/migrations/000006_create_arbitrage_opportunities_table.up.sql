@@ -0,0 +1,14 @@
+CREATE TABLE arbitrage_opportunities (
+    id SERIAL PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    buy_venue VARCHAR(32) NOT NULL,
+    sell_venue VARCHAR(32) NOT NULL,
+    buy_price DOUBLE PRECISION NOT NULL,
+    sell_price DOUBLE PRECISION NOT NULL,
+    volume DOUBLE PRECISION NOT NULL,
+    profit DOUBLE PRECISION NOT NULL,
+    profit_percent DOUBLE PRECISION NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_arbitrage_opportunities_pair_detected_at ON arbitrage_opportunities (pair, detected_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS currency_rate_aggregates;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE currency_rate_aggregates (
+    id SERIAL PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    resolution VARCHAR(8) NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    samples BIGINT NOT NULL,
+    ask_sum DOUBLE PRECISION NOT NULL,
+    bid_sum DOUBLE PRECISION NOT NULL,
+    ask_min DOUBLE PRECISION NOT NULL,
+    ask_max DOUBLE PRECISION NOT NULL,
+    bid_min DOUBLE PRECISION NOT NULL,
+    bid_max DOUBLE PRECISION NOT NULL,
+    UNIQUE (pair, resolution, bucket_start)
+);
+
+CREATE INDEX idx_currency_rate_aggregates_resolution_bucket ON currency_rate_aggregates (resolution, bucket_start);
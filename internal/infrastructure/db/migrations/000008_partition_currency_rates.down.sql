@@ -0,0 +1,20 @@
+ALTER TABLE currency_rates RENAME TO currency_rates_partitioned;
+ALTER INDEX idx_currency_rates_pair_timestamp RENAME TO idx_currency_rates_partitioned_pair_timestamp;
+
+CREATE TABLE currency_rates (
+    id SERIAL PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    ask_price DECIMAL(10, 2) NOT NULL,
+    bid_price DECIMAL(10, 2) NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_currency_rates_pair_timestamp ON currency_rates (pair, timestamp);
+
+INSERT INTO currency_rates (id, pair, ask_price, bid_price, timestamp)
+SELECT id, pair, ask_price, bid_price, timestamp FROM currency_rates_partitioned;
+
+SELECT setval(pg_get_serial_sequence('currency_rates', 'id'),
+    COALESCE((SELECT max(id) FROM currency_rates), 0) + 1, false);
+
+DROP TABLE currency_rates_partitioned;
@@ -0,0 +1,43 @@
+ALTER TABLE currency_rates RENAME TO currency_rates_unpartitioned;
+ALTER INDEX idx_currency_rates_pair_timestamp RENAME TO idx_currency_rates_unpartitioned_pair_timestamp;
+
+CREATE TABLE currency_rates (
+    id SERIAL NOT NULL,
+    pair VARCHAR(10) NOT NULL,
+    ask_price DECIMAL(10, 2) NOT NULL,
+    bid_price DECIMAL(10, 2) NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+CREATE INDEX idx_currency_rates_pair_timestamp ON currency_rates (pair, timestamp);
+
+-- Страховочная секция для строк вне созданных месяцев; обычные секции
+-- заранее создаёт задача обслуживания.
+CREATE TABLE currency_rates_default PARTITION OF currency_rates DEFAULT;
+
+DO $$
+DECLARE
+    month_start TIMESTAMP;
+BEGIN
+    FOR month_start IN
+        SELECT generate_series(
+            date_trunc('month', COALESCE((SELECT min(timestamp) FROM currency_rates_unpartitioned), now()) AT TIME ZONE 'UTC'),
+            date_trunc('month', now() AT TIME ZONE 'UTC') + INTERVAL '2 months',
+            INTERVAL '1 month')
+    LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF currency_rates FOR VALUES FROM (%L) TO (%L)',
+            'currency_rates_' || to_char(month_start, 'YYYY_MM'),
+            month_start AT TIME ZONE 'UTC',
+            (month_start + INTERVAL '1 month') AT TIME ZONE 'UTC');
+    END LOOP;
+END $$;
+
+INSERT INTO currency_rates (id, pair, ask_price, bid_price, timestamp)
+SELECT id, pair, ask_price, bid_price, timestamp FROM currency_rates_unpartitioned;
+
+SELECT setval(pg_get_serial_sequence('currency_rates', 'id'),
+    COALESCE((SELECT max(id) FROM currency_rates), 0) + 1, false);
+
+DROP TABLE currency_rates_unpartitioned;
@@ -0,0 +1,2 @@
+ALTER TABLE currency_rates DROP COLUMN IF EXISTS version;
+ALTER TABLE currency_rates DROP COLUMN IF EXISTS created_at;
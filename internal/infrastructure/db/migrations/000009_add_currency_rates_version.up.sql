@@ -0,0 +1,7 @@
+-- Для уже сохранённых строк время создания неизвестно, берём время биржи.
+ALTER TABLE currency_rates ADD COLUMN created_at TIMESTAMP WITH TIME ZONE;
+UPDATE currency_rates SET created_at = timestamp;
+ALTER TABLE currency_rates ALTER COLUMN created_at SET DEFAULT NOW();
+ALTER TABLE currency_rates ALTER COLUMN created_at SET NOT NULL;
+
+ALTER TABLE currency_rates ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
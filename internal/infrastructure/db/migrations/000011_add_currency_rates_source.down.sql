@@ -0,0 +1,3 @@
+CREATE INDEX idx_currency_rates_pair_timestamp ON currency_rates (pair, timestamp);
+DROP INDEX idx_currency_rates_pair_timestamp_source;
+ALTER TABLE currency_rates DROP COLUMN IF EXISTS source;
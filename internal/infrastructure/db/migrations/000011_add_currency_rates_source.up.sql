@@ -0,0 +1,15 @@
+-- Все уже сохранённые курсы получены с Garantex.
+ALTER TABLE currency_rates ADD COLUMN source VARCHAR(32) NOT NULL DEFAULT 'garantex';
+ALTER TABLE currency_rates ALTER COLUMN source SET DEFAULT '';
+
+-- Из повторно сохранённых снимков остаётся самый ранний.
+DELETE FROM currency_rates a
+USING currency_rates b
+WHERE a.pair = b.pair
+  AND a.timestamp = b.timestamp
+  AND a.source = b.source
+  AND a.id > b.id;
+
+-- Уникальный индекс покрывает и запросы по (pair, timestamp).
+CREATE UNIQUE INDEX idx_currency_rates_pair_timestamp_source ON currency_rates (pair, timestamp, source);
+DROP INDEX idx_currency_rates_pair_timestamp;
@@ -0,0 +1,7 @@
+-- Счётчики запросов клиентов за сутки UTC для суточных квот.
+CREATE TABLE client_quotas (
+    client VARCHAR(160) NOT NULL,
+    day DATE NOT NULL,
+    used BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (client, day)
+);
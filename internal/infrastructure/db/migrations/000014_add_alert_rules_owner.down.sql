@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_alert_rules_owner;
+ALTER TABLE alert_rules DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,23 @@
+-- Агрегаты разных поставщиков одного интервала объединяются.
+WITH merged AS (
+    SELECT MIN(id) AS id, pair, resolution, bucket_start,
+        SUM(samples) AS samples, SUM(ask_sum) AS ask_sum, SUM(bid_sum) AS bid_sum,
+        MIN(ask_min) AS ask_min, MAX(ask_max) AS ask_max, MIN(bid_min) AS bid_min, MAX(bid_max) AS bid_max
+    FROM currency_rate_aggregates
+    GROUP BY pair, resolution, bucket_start
+)
+UPDATE currency_rate_aggregates a SET
+    samples = m.samples, ask_sum = m.ask_sum, bid_sum = m.bid_sum,
+    ask_min = m.ask_min, ask_max = m.ask_max, bid_min = m.bid_min, bid_max = m.bid_max
+FROM merged m
+WHERE a.id = m.id;
+DELETE FROM currency_rate_aggregates a
+USING currency_rate_aggregates b
+WHERE a.pair = b.pair
+  AND a.resolution = b.resolution
+  AND a.bucket_start = b.bucket_start
+  AND a.id > b.id;
+
+DROP INDEX idx_currency_rate_aggregates_pair_source_bucket;
+ALTER TABLE currency_rate_aggregates ADD CONSTRAINT currency_rate_aggregates_pair_resolution_bucket_start_key UNIQUE (pair, resolution, bucket_start);
+ALTER TABLE currency_rate_aggregates DROP COLUMN source;
@@ -0,0 +1,6 @@
+-- Агрегаты считаются отдельно по поставщикам. Поставщики уже накопленных
+-- агрегатов неизвестны: в них могли смешаться снимки разных бирж.
+ALTER TABLE currency_rate_aggregates ADD COLUMN source VARCHAR(32) NOT NULL DEFAULT '';
+
+ALTER TABLE currency_rate_aggregates DROP CONSTRAINT currency_rate_aggregates_pair_resolution_bucket_start_key;
+CREATE UNIQUE INDEX idx_currency_rate_aggregates_pair_source_bucket ON currency_rate_aggregates (pair, source, resolution, bucket_start);
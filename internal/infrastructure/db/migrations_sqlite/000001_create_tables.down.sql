@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS currency_rate_aggregates;
+DROP TABLE IF EXISTS arbitrage_opportunities;
+DROP TABLE IF EXISTS market_stats;
+DROP TABLE IF EXISTS quarantined_rates;
+DROP TABLE IF EXISTS alert_deliveries;
+DROP TABLE IF EXISTS alert_rules;
+DROP TABLE IF EXISTS quotes;
+DROP TABLE IF EXISTS currency_rates;
@@ -0,0 +1,116 @@
+CREATE TABLE currency_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    ask_price REAL NOT NULL,
+    bid_price REAL NOT NULL,
+    timestamp DATETIME NOT NULL
+);
+
+CREATE INDEX idx_currency_rates_pair_timestamp ON currency_rates (pair, timestamp);
+
+CREATE TABLE quotes (
+    id VARCHAR(32) PRIMARY KEY,
+    pair VARCHAR(10) NOT NULL,
+    ask_price REAL NOT NULL,
+    bid_price REAL NOT NULL,
+    markup_percent REAL NOT NULL,
+    rate_timestamp DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    redeemed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quotes_expires_at ON quotes (expires_at);
+
+CREATE TABLE alert_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    condition VARCHAR(32) NOT NULL,
+    threshold REAL NOT NULL,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    webhook_url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_alert_rules_pair ON alert_rules (pair);
+
+CREATE TABLE alert_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules (id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    delivered BOOLEAN NOT NULL,
+    payload TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_alert_deliveries_rule_id ON alert_deliveries (rule_id, created_at);
+
+CREATE TABLE quarantined_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    ask_price REAL NOT NULL,
+    bid_price REAL NOT NULL,
+    timestamp DATETIME NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quarantined_rates_pair_created_at ON quarantined_rates (pair, created_at);
+
+CREATE TABLE market_stats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    timestamp DATETIME NOT NULL,
+    ask_price REAL NOT NULL,
+    bid_price REAL NOT NULL,
+    mid_price REAL NOT NULL,
+    spread REAL NOT NULL,
+    spread_percent REAL NOT NULL,
+    ask_depth_05 REAL NOT NULL,
+    bid_depth_05 REAL NOT NULL,
+    ask_depth_1 REAL NOT NULL,
+    bid_depth_1 REAL NOT NULL,
+    ask_depth_2 REAL NOT NULL,
+    bid_depth_2 REAL NOT NULL,
+    imbalance REAL NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_market_stats_pair_timestamp ON market_stats (pair, timestamp);
+
+CREATE TABLE arbitrage_opportunities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    buy_venue VARCHAR(32) NOT NULL,
+    sell_venue VARCHAR(32) NOT NULL,
+    buy_price REAL NOT NULL,
+    sell_price REAL NOT NULL,
+    volume REAL NOT NULL,
+    profit REAL NOT NULL,
+    profit_percent REAL NOT NULL,
+    detected_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_arbitrage_opportunities_pair_detected_at ON arbitrage_opportunities (pair, detected_at);
+
+CREATE TABLE currency_rate_aggregates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    resolution VARCHAR(8) NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    samples INTEGER NOT NULL,
+    ask_sum REAL NOT NULL,
+    bid_sum REAL NOT NULL,
+    ask_min REAL NOT NULL,
+    ask_max REAL NOT NULL,
+    bid_min REAL NOT NULL,
+    bid_max REAL NOT NULL,
+    UNIQUE (pair, resolution, bucket_start)
+);
+
+CREATE INDEX idx_currency_rate_aggregates_resolution_bucket ON currency_rate_aggregates (resolution, bucket_start);
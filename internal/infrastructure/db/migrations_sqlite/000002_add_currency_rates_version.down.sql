@@ -0,0 +1,2 @@
+ALTER TABLE currency_rates DROP COLUMN version;
+ALTER TABLE currency_rates DROP COLUMN created_at;
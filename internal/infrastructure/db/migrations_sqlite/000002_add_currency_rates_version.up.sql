@@ -0,0 +1,6 @@
+-- SQLite не разрешает ADD COLUMN с неконстантным значением по умолчанию,
+-- поэтому время создания старых строк заполняется временем биржи отдельно.
+ALTER TABLE currency_rates ADD COLUMN created_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+UPDATE currency_rates SET created_at = timestamp;
+
+ALTER TABLE currency_rates ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,11 @@
+-- Все уже сохранённые курсы получены с Garantex.
+ALTER TABLE currency_rates ADD COLUMN source VARCHAR(32) NOT NULL DEFAULT '';
+UPDATE currency_rates SET source = 'garantex';
+
+-- Из повторно сохранённых снимков остаётся самый ранний.
+DELETE FROM currency_rates
+WHERE id NOT IN (SELECT MIN(id) FROM currency_rates GROUP BY pair, timestamp, source);
+
+-- Уникальный индекс покрывает и запросы по (pair, timestamp).
+CREATE UNIQUE INDEX idx_currency_rates_pair_timestamp_source ON currency_rates (pair, timestamp, source);
+DROP INDEX idx_currency_rates_pair_timestamp;
@@ -0,0 +1,6 @@
+CREATE TABLE client_quotas (
+    client VARCHAR(160) NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (client, day)
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_alert_rules_owner;
+ALTER TABLE alert_rules DROP COLUMN owner;
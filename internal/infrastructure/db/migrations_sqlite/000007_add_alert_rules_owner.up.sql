@@ -0,0 +1,5 @@
+-- Владелец правила - клиент, создавший его (key:<id> или jwt:<субъект>).
+-- Правила, созданные раньше, остаются без владельца и видны только
+-- администраторам.
+ALTER TABLE alert_rules ADD COLUMN owner VARCHAR(160) NOT NULL DEFAULT '';
+CREATE INDEX idx_alert_rules_owner ON alert_rules (owner);
@@ -0,0 +1,25 @@
+-- Агрегаты разных поставщиков одного интервала объединяются.
+CREATE TABLE currency_rate_aggregates_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    resolution VARCHAR(8) NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    samples INTEGER NOT NULL,
+    ask_sum REAL NOT NULL,
+    bid_sum REAL NOT NULL,
+    ask_min REAL NOT NULL,
+    ask_max REAL NOT NULL,
+    bid_min REAL NOT NULL,
+    bid_max REAL NOT NULL,
+    UNIQUE (pair, resolution, bucket_start)
+);
+INSERT INTO currency_rate_aggregates_old
+    (id, pair, resolution, bucket_start, samples, ask_sum, bid_sum, ask_min, ask_max, bid_min, bid_max)
+SELECT MIN(id), pair, resolution, bucket_start, SUM(samples), SUM(ask_sum), SUM(bid_sum),
+    MIN(ask_min), MAX(ask_max), MIN(bid_min), MAX(bid_max)
+FROM currency_rate_aggregates
+GROUP BY pair, resolution, bucket_start;
+DROP TABLE currency_rate_aggregates;
+ALTER TABLE currency_rate_aggregates_old RENAME TO currency_rate_aggregates;
+
+CREATE INDEX idx_currency_rate_aggregates_resolution_bucket ON currency_rate_aggregates (resolution, bucket_start);
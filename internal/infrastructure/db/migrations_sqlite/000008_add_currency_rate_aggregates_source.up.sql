@@ -0,0 +1,26 @@
+-- Агрегаты считаются отдельно по поставщикам. Ограничение UNIQUE в SQLite
+-- не удаляется, поэтому таблица пересоздаётся. Поставщики уже накопленных
+-- агрегатов неизвестны.
+CREATE TABLE currency_rate_aggregates_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pair VARCHAR(10) NOT NULL,
+    source VARCHAR(32) NOT NULL DEFAULT '',
+    resolution VARCHAR(8) NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    samples INTEGER NOT NULL,
+    ask_sum REAL NOT NULL,
+    bid_sum REAL NOT NULL,
+    ask_min REAL NOT NULL,
+    ask_max REAL NOT NULL,
+    bid_min REAL NOT NULL,
+    bid_max REAL NOT NULL,
+    UNIQUE (pair, source, resolution, bucket_start)
+);
+INSERT INTO currency_rate_aggregates_new
+    (id, pair, resolution, bucket_start, samples, ask_sum, bid_sum, ask_min, ask_max, bid_min, bid_max)
+SELECT id, pair, resolution, bucket_start, samples, ask_sum, bid_sum, ask_min, ask_max, bid_min, bid_max
+FROM currency_rate_aggregates;
+DROP TABLE currency_rate_aggregates;
+ALTER TABLE currency_rate_aggregates_new RENAME TO currency_rate_aggregates;
+
+CREATE INDEX idx_currency_rate_aggregates_resolution_bucket ON currency_rate_aggregates (resolution, bucket_start);
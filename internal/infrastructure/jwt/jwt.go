@@ -0,0 +1,99 @@
+// Package jwt выпускает и проверяет JWT, подписанные HS256 общим секретом.
+// Другие алгоритмы подписи не принимаются.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// leeway - допустимое расхождение часов выпустившего токен и сервиса.
+const leeway = 30 * time.Second
+
+var (
+	ErrInvalidToken = errors.New("некорректный токен")
+	ErrTokenExpired = errors.New("срок действия токена истёк")
+)
+
+// Claims - поля токена. Scope - права через пробел, как в OAuth 2.0;
+// Pairs - доступные пары, пустой список разрешает все. Срок действия
+// ExpiresAt обязателен.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Scope     string   `json:"scope"`
+	Pairs     []string `json:"pairs,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign возвращает токен с claims, подписанный secret.
+func Sign(claims Claims, secret []byte) (string, error) {
+	head, err := json.Marshal(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := encoding.EncodeToString(head) + "." + encoding.EncodeToString(body)
+	return unsigned + "." + encoding.EncodeToString(sign(unsigned, secret)), nil
+}
+
+// Verify проверяет подпись и сроки токена на момент now и возвращает его поля.
+func Verify(token string, secret []byte, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+	var head header
+	if err := decode(parts[0], &head); err != nil || head.Alg != "HS256" {
+		return Claims{}, ErrInvalidToken
+	}
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(parts[0]+"."+parts[1], secret)) {
+		return Claims{}, ErrInvalidToken
+	}
+	var claims Claims
+	if err := decode(parts[1], &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.Subject == "" || claims.ExpiresAt == 0 {
+		return Claims{}, fmt.Errorf("%w: нужны sub и exp", ErrInvalidToken)
+	}
+	if !now.Before(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return Claims{}, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return Claims{}, fmt.Errorf("%w: токен ещё не действует", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func sign(unsigned string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decode(part string, v any) error {
+	data, err := encoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	claims := Claims{Subject: "partner", Scope: "rates:read quotes", Pairs: []string{"USDT/RUB"}, ExpiresAt: now.Add(time.Hour).Unix()}
+
+	t.Run("Valid", func(t *testing.T) {
+		token, err := Sign(claims, secret)
+		require.NoError(t, err)
+		got, err := Verify(token, secret, now)
+		require.NoError(t, err)
+		assert.Equal(t, claims, got)
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		token, err := Sign(claims, secret)
+		require.NoError(t, err)
+		_, err = Verify(token, []byte("other"), now)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("TamperedClaims", func(t *testing.T) {
+		token, err := Sign(claims, secret)
+		require.NoError(t, err)
+		parts := strings.Split(token, ".")
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"partner","scope":"admin","exp":9999999999}`))
+		_, err = Verify(strings.Join(parts, "."), secret, now)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("AlgNone", func(t *testing.T) {
+		head := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+		body := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"partner","scope":"admin","exp":9999999999}`))
+		_, err := Verify(head+"."+body+".", secret, now)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		token, err := Sign(claims, secret)
+		require.NoError(t, err)
+		_, err = Verify(token, secret, now.Add(time.Hour+leeway))
+		assert.ErrorIs(t, err, ErrTokenExpired)
+		_, err = Verify(token, secret, now.Add(time.Hour+leeway-time.Second))
+		assert.NoError(t, err, "расхождение часов в пределах leeway допускается")
+	})
+
+	t.Run("NotBefore", func(t *testing.T) {
+		early := claims
+		early.NotBefore = now.Add(time.Minute).Unix()
+		token, err := Sign(early, secret)
+		require.NoError(t, err)
+		_, err = Verify(token, secret, now)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("MissingExpiry", func(t *testing.T) {
+		eternal := claims
+		eternal.ExpiresAt = 0
+		token, err := Sign(eternal, secret)
+		require.NoError(t, err)
+		_, err = Verify(token, secret, now)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := Verify("not-a-token", secret, now)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
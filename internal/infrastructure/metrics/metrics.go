@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "usdt"
+
+var (
+	RetentionRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "runs_total",
+		Help:      "Количество запусков очистки по результату.",
+	}, []string{"status"})
+
+	RetentionRawDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "raw_rows_deleted_total",
+		Help:      "Количество сырых курсов, перенесённых в агрегаты и удалённых.",
+	})
+
+	RetentionAggregatesUpserted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "aggregates_upserted_total",
+		Help:      "Количество созданных или дополненных агрегатов по разрешению.",
+	}, []string{"resolution"})
+
+	RetentionAggregatesDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "aggregates_deleted_total",
+		Help:      "Количество удалённых устаревших агрегатов по разрешению.",
+	}, []string{"resolution"})
+
+	RetentionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "run_duration_seconds",
+		Help:      "Длительность запуска очистки.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+	})
+
+	RetentionLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Время последнего успешного запуска очистки.",
+	})
+
+	AlertsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "alerts",
+		Name:      "dropped_total",
+		Help:      "Количество оповещений, не поставленных в переполненную очередь вебхуков.",
+	})
+)
+
+// Handler отдаёт метрики в формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"usdt/internal/models"
+)
+
+// Publisher - минимальный клиент брокера сообщений. Его можно реализовать
+// поверх NATS JetStream (key - заголовок Nats-Msg-Id для дедупликации) или
+// Kafka (key - ключ сообщения).
+type Publisher interface {
+	Publish(ctx context.Context, subject, key string, data []byte) error
+}
+
+// BrokerSink публикует событие в тему "<prefix>.<тип события>" с ключом
+// идемпотентности в качестве ключа сообщения.
+type BrokerSink struct {
+	publisher Publisher
+	prefix    string
+}
+
+func NewBrokerSink(publisher Publisher, prefix string) *BrokerSink {
+	return &BrokerSink{publisher: publisher, prefix: prefix}
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	subject := event.EventType
+	if s.prefix != "" {
+		subject = s.prefix + "." + subject
+	}
+	if err := s.publisher.Publish(ctx, subject, event.IdempotencyKey, []byte(event.Payload)); err != nil {
+		return fmt.Errorf("не удалось опубликовать событие в брокер: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"usdt/internal/models"
+)
+
+// FileSink дописывает события в файл по одному JSON на строку. Подходит
+// для тестов и локального запуска.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// fileRecord - строка файла FileSink.
+type fileRecord struct {
+	ID             int64           `json:"id"`
+	EventType      string          `json:"event_type"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func (s *FileSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	line, err := json.Marshal(fileRecord{
+		ID:             event.ID,
+		EventType:      event.EventType,
+		IdempotencyKey: event.IdempotencyKey,
+		Payload:        json.RawMessage(event.Payload),
+		CreatedAt:      event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать событие: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл событий: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("не удалось записать событие в файл: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("не удалось закрыть файл событий: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"usdt/internal/infrastructure/webhook"
+	"usdt/internal/models"
+)
+
+func testEvent(id int64) models.OutboxEvent {
+	return models.OutboxEvent{
+		ID:             id,
+		EventType:      models.EventRateCreated,
+		IdempotencyKey: "rate.created:7:1",
+		Payload:        `{"id":7,"pair":"USDT/RUB"}`,
+		CreatedAt:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookSink_Publish(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		expectErr      bool
+	}{
+		{name: "Delivered", mockStatusCode: http.StatusAccepted, expectErr: false},
+		{name: "Receiver error", mockStatusCode: http.StatusServiceUnavailable, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if got := r.Header.Get(IdempotencyKeyHeader); got != "rate.created:7:1" {
+					t.Errorf("неверный ключ идемпотентности: %q", got)
+				}
+				if got := r.Header.Get(EventTypeHeader); got != models.EventRateCreated {
+					t.Errorf("неверный тип события: %q", got)
+				}
+				if !webhook.Verify("secret", r.Header.Get(webhook.TimestampHeader), body, r.Header.Get(webhook.SignatureHeader)) {
+					t.Errorf("неверная подпись события")
+				}
+				w.WriteHeader(tt.mockStatusCode)
+			}))
+			defer server.Close()
+
+			err := NewWebhookSink(server.URL, "secret", time.Second).Publish(context.Background(), testEvent(1))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ожидали ошибку: %v, получили: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+type fakePublisher struct {
+	subject, key string
+	data         []byte
+	err          error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, subject, key string, data []byte) error {
+	p.subject, p.key, p.data = subject, key, data
+	return p.err
+}
+
+func TestBrokerSink_Publish(t *testing.T) {
+	publisher := &fakePublisher{}
+	if err := NewBrokerSink(publisher, "usdt").Publish(context.Background(), testEvent(1)); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if publisher.subject != "usdt.rate.created" || publisher.key != "rate.created:7:1" {
+		t.Errorf("неверные тема или ключ: %q, %q", publisher.subject, publisher.key)
+	}
+	if string(publisher.data) != testEvent(1).Payload {
+		t.Errorf("неверное тело сообщения: %s", publisher.data)
+	}
+
+	publisher.err = errors.New("брокер недоступен")
+	if err := NewBrokerSink(publisher, "").Publish(context.Background(), testEvent(1)); err == nil {
+		t.Errorf("ожидали ошибку брокера")
+	}
+	if publisher.subject != models.EventRateCreated {
+		t.Errorf("без префикса тема должна совпадать с типом события: %q", publisher.subject)
+	}
+}
+
+func TestFileSink_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := NewFileSink(path)
+	for id := int64(1); id <= 2; id++ {
+		if err := sink.Publish(context.Background(), testEvent(id)); err != nil {
+			t.Fatalf("неожиданная ошибка: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("не удалось открыть файл событий: %v", err)
+	}
+	defer file.Close()
+	var records []fileRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record fileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("строка не является JSON: %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ожидали 2 события, получили %d", len(records))
+	}
+	if records[1].ID != 2 || records[1].IdempotencyKey != "rate.created:7:1" {
+		t.Errorf("неверное событие: %+v", records[1])
+	}
+	if string(records[0].Payload) != testEvent(1).Payload {
+		t.Errorf("payload должен сохраниться как JSON: %s", records[0].Payload)
+	}
+}
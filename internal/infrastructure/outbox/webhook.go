@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"usdt/internal/infrastructure/webhook"
+	"usdt/internal/models"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	EventTypeHeader      = "X-Usdt-Event"
+)
+
+// WebhookSink отправляет событие POST-запросом с телом Payload. Ключ
+// идемпотентности передаётся в заголовке Idempotency-Key; при заданном
+// секрете тело подписывается так же, как вебхуки оповещений.
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookSink(url, secret string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}
+}
+
+// Publish считает доставленным только ответ 2xx.
+func (s *WebhookSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	payload := []byte(event.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("не удалось сформировать запрос публикации события: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, event.IdempotencyKey)
+	req.Header.Set(EventTypeHeader, event.EventType)
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(s.now().Unix(), 10)
+		req.Header.Set(webhook.TimestampHeader, timestamp)
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(s.secret, timestamp, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось выполнить запрос публикации события: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("неправильный статус ответа приёмника событий: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package ratefile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"usdt/internal/models"
+)
+
+// csvHeader - колонки CSV в порядке записи.
+var csvHeader = []string{"id", "pair", "ask_price", "bid_price", "timestamp", "created_at", "version", "source"}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(rate models.CurrencyRate) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return fmt.Errorf("не удалось записать заголовок CSV: %w", err)
+		}
+		c.wroteHeader = true
+	}
+	err := c.w.Write([]string{
+		strconv.FormatInt(rate.ID, 10),
+		rate.Pair,
+		strconv.FormatFloat(rate.AskPrice, 'f', -1, 64),
+		strconv.FormatFloat(rate.BidPrice, 'f', -1, 64),
+		rate.Timestamp.UTC().Format(timeLayout),
+		rate.CreatedAt.UTC().Format(timeLayout),
+		strconv.FormatInt(rate.Version, 10),
+		rate.Source,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось записать строку CSV: %w", err)
+	}
+	return nil
+}
+
+// Close пишет заголовок и в пустой выгрузке, чтобы файл оставался валидным CSV.
+func (c *csvWriter) Close() error {
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return fmt.Errorf("не удалось записать заголовок CSV: %w", err)
+		}
+		c.wroteHeader = true
+	}
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return fmt.Errorf("не удалось записать CSV: %w", err)
+	}
+	return nil
+}
+
+// csvRequired - обязательные колонки CSV импорта; source необязательна.
+var csvRequired = []string{"pair", "ask_price", "bid_price", "timestamp"}
+
+// csvReader сопоставляет колонки по заголовку, поэтому их порядок и лишние
+// колонки не важны.
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	err     error
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &csvReader{r: reader}
+}
+
+func (c *csvReader) Read() (models.CurrencyRate, error) {
+	if c.columns == nil && c.err == nil {
+		c.err = c.readHeader()
+	}
+	if c.err != nil {
+		return models.CurrencyRate{}, c.err
+	}
+	record, err := c.r.Read()
+	if err == io.EOF {
+		return models.CurrencyRate{}, io.EOF
+	}
+	line, _ := c.r.FieldPos(0)
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return models.CurrencyRate{}, fmt.Errorf("%w: строка %d: %v", models.ErrInvalidRecord, parseErr.Line, parseErr.Err)
+	}
+	if err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("не удалось прочитать CSV: %w", err)
+	}
+	rate, err := c.parse(record)
+	if err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("%w: строка %d: %v", models.ErrInvalidRecord, line, err)
+	}
+	return rate, nil
+}
+
+func (c *csvReader) readHeader() error {
+	header, err := c.r.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать заголовок CSV: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequired {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("в заголовке CSV нет колонки %q", name)
+		}
+	}
+	c.columns = columns
+	return nil
+}
+
+func (c *csvReader) parse(record []string) (models.CurrencyRate, error) {
+	field := func(name string) (string, error) {
+		i := c.columns[name]
+		if i >= len(record) {
+			return "", fmt.Errorf("нет колонки %s", name)
+		}
+		return strings.TrimSpace(record[i]), nil
+	}
+	var rate models.CurrencyRate
+	var err error
+	if rate.Pair, err = field("pair"); err != nil {
+		return rate, err
+	}
+	if rate.Pair == "" {
+		return rate, errors.New("пустая пара")
+	}
+	value, err := field("ask_price")
+	if err != nil {
+		return rate, err
+	}
+	if rate.AskPrice, err = strconv.ParseFloat(value, 64); err != nil {
+		return rate, fmt.Errorf("ask_price: %w", err)
+	}
+	if value, err = field("bid_price"); err != nil {
+		return rate, err
+	}
+	if rate.BidPrice, err = strconv.ParseFloat(value, 64); err != nil {
+		return rate, fmt.Errorf("bid_price: %w", err)
+	}
+	if value, err = field("timestamp"); err != nil {
+		return rate, err
+	}
+	if rate.Timestamp, err = parseTime(value); err != nil {
+		return rate, fmt.Errorf("timestamp: %w", err)
+	}
+	if _, ok := c.columns["source"]; ok {
+		if rate.Source, err = field("source"); err != nil {
+			return rate, err
+		}
+	}
+	return rate, nil
+}
@@ -0,0 +1,104 @@
+package ratefile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"usdt/internal/models"
+)
+
+// jsonlRecord - строка JSON Lines; время пишется в UTC.
+type jsonlRecord struct {
+	ID        int64   `json:"id"`
+	Pair      string  `json:"pair"`
+	AskPrice  float64 `json:"ask_price"`
+	BidPrice  float64 `json:"bid_price"`
+	Timestamp string  `json:"timestamp"`
+	CreatedAt string  `json:"created_at"`
+	Version   int64   `json:"version"`
+	Source    string  `json:"source"`
+}
+
+type jsonlWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	buf := bufio.NewWriter(w)
+	return &jsonlWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (j *jsonlWriter) Write(rate models.CurrencyRate) error {
+	err := j.enc.Encode(jsonlRecord{
+		ID:        rate.ID,
+		Pair:      rate.Pair,
+		AskPrice:  rate.AskPrice,
+		BidPrice:  rate.BidPrice,
+		Timestamp: rate.Timestamp.UTC().Format(timeLayout),
+		CreatedAt: rate.CreatedAt.UTC().Format(timeLayout),
+		Version:   rate.Version,
+		Source:    rate.Source,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось записать строку JSONL: %w", err)
+	}
+	return nil
+}
+
+func (j *jsonlWriter) Close() error {
+	if err := j.buf.Flush(); err != nil {
+		return fmt.Errorf("не удалось записать JSONL: %w", err)
+	}
+	return nil
+}
+
+// maxJSONLLine - предел длины строки JSON Lines при импорте.
+const maxJSONLLine = 1 << 20
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+	return &jsonlReader{scanner: scanner}
+}
+
+// Read пропускает пустые строки.
+func (j *jsonlReader) Read() (models.CurrencyRate, error) {
+	for j.scanner.Scan() {
+		j.line++
+		line := bytes.TrimSpace(j.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record jsonlRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return models.CurrencyRate{}, fmt.Errorf("%w: строка %d: %v", models.ErrInvalidRecord, j.line, err)
+		}
+		if record.Pair == "" {
+			return models.CurrencyRate{}, fmt.Errorf("%w: строка %d: пустая пара", models.ErrInvalidRecord, j.line)
+		}
+		timestamp, err := parseTime(record.Timestamp)
+		if err != nil {
+			return models.CurrencyRate{}, fmt.Errorf("%w: строка %d: timestamp: %v", models.ErrInvalidRecord, j.line, err)
+		}
+		return models.CurrencyRate{
+			Pair:      record.Pair,
+			AskPrice:  record.AskPrice,
+			BidPrice:  record.BidPrice,
+			Timestamp: timestamp,
+			Source:    record.Source,
+		}, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("не удалось прочитать JSONL: %w", err)
+	}
+	return models.CurrencyRate{}, io.EOF
+}
@@ -0,0 +1,81 @@
+package ratefile
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"usdt/internal/models"
+)
+
+// parquetRowGroupSize ограничивает число строк в группе: группа копится в
+// памяти до записи, поэтому размер группы определяет потребление памяти.
+const parquetRowGroupSize = 100000
+
+// parquetWriteBatch - сколько строк передаётся в parquet за один вызов Write.
+const parquetWriteBatch = 1024
+
+// parquetRow - схема строки Parquet.
+type parquetRow struct {
+	ID        int64     `parquet:"id"`
+	Pair      string    `parquet:"pair,dict"`
+	AskPrice  float64   `parquet:"ask_price"`
+	BidPrice  float64   `parquet:"bid_price"`
+	Timestamp time.Time `parquet:"timestamp,timestamp(microsecond)"`
+	CreatedAt time.Time `parquet:"created_at,timestamp(microsecond)"`
+	Version   int64     `parquet:"version"`
+	Source    string    `parquet:"source,dict"`
+}
+
+type parquetWriter struct {
+	w    *parquet.GenericWriter[parquetRow]
+	rows []parquetRow
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		w: parquet.NewGenericWriter[parquetRow](w,
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+			parquet.Compression(&parquet.Zstd)),
+		rows: make([]parquetRow, 0, parquetWriteBatch),
+	}
+}
+
+func (p *parquetWriter) Write(rate models.CurrencyRate) error {
+	p.rows = append(p.rows, parquetRow{
+		ID:        rate.ID,
+		Pair:      rate.Pair,
+		AskPrice:  rate.AskPrice,
+		BidPrice:  rate.BidPrice,
+		Timestamp: rate.Timestamp.UTC(),
+		CreatedAt: rate.CreatedAt.UTC(),
+		Version:   rate.Version,
+		Source:    rate.Source,
+	})
+	if len(p.rows) < parquetWriteBatch {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *parquetWriter) flush() error {
+	if len(p.rows) == 0 {
+		return nil
+	}
+	if _, err := p.w.Write(p.rows); err != nil {
+		return fmt.Errorf("не удалось записать строки Parquet: %w", err)
+	}
+	p.rows = p.rows[:0]
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	if err := p.w.Close(); err != nil {
+		return fmt.Errorf("не удалось завершить файл Parquet: %w", err)
+	}
+	return nil
+}
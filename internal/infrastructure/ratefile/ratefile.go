@@ -0,0 +1,85 @@
+// Package ratefile записывает курсы в файлы выгрузки (CSV, JSON Lines и
+// Parquet) и читает их из файлов импорта (CSV и JSON Lines).
+package ratefile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"usdt/internal/models"
+)
+
+// Форматы файлов курсов.
+const (
+	CSV     = "csv"
+	JSONL   = "jsonl"
+	Parquet = "parquet"
+)
+
+var ErrUnknownFormat = errors.New("неизвестный формат файла курсов")
+
+// Writer пишет курсы по одному. Close дописывает буферизованные данные и,
+// для Parquet, метаданные файла; без Close файл неполный. Нижележащий
+// io.Writer Close не закрывает.
+type Writer interface {
+	Write(rate models.CurrencyRate) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w), nil
+	case JSONL:
+		return newJSONLWriter(w), nil
+	case Parquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// Reader читает курсы по одному и возвращает io.EOF после последней строки.
+// Ошибка разбора отдельной строки оборачивает models.ErrInvalidRecord, после
+// неё чтение можно продолжить. Из файла берутся только пара, цены и время
+// биржи; остальные колонки выгрузки игнорируются.
+type Reader interface {
+	Read() (models.CurrencyRate, error)
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r), nil
+	case JSONL:
+		return newJSONLReader(r), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// FormatFromPath определяет формат по расширению файла.
+func FormatFromPath(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return CSV, nil
+	case ".jsonl", ".ndjson":
+		return JSONL, nil
+	case ".parquet":
+		return Parquet, nil
+	default:
+		return "", fmt.Errorf("%w: расширение %q", ErrUnknownFormat, ext)
+	}
+}
+
+// parseTime разбирает время из файла импорта: RFC3339 с долями секунды или без.
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+}
+
+// timeLayout - формат времени в текстовых файлах; время пишется в UTC.
+const timeLayout = time.RFC3339Nano
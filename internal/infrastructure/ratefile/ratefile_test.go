@@ -0,0 +1,209 @@
+package ratefile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"usdt/internal/models"
+)
+
+func testRates(n int) []models.CurrencyRate {
+	msk := time.FixedZone("MSK", 3*3600)
+	start := time.Date(2024, 5, 1, 15, 0, 0, 0, msk)
+	rates := make([]models.CurrencyRate, n)
+	for i := range rates {
+		rates[i] = models.CurrencyRate{
+			ID:        int64(i + 1),
+			Pair:      "USDT/RUB",
+			AskPrice:  91.25 + float64(i),
+			BidPrice:  90.5 + float64(i),
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			CreatedAt: start.Add(time.Duration(i)*time.Second + time.Millisecond),
+			Version:   1,
+			Source:    "garantex",
+		}
+	}
+	return rates
+}
+
+func writeAll(t *testing.T, format string, rates []models.CurrencyRate) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("не удалось создать writer: %v", err)
+	}
+	for _, rate := range rates {
+		if err := w.Write(rate); err != nil {
+			t.Fatalf("не удалось записать курс: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("не удалось закрыть writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, CSV, testRates(2)))).ReadAll()
+	if err != nil {
+		t.Fatalf("невалидный CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("ожидали заголовок и 2 строки, получили %d", len(records))
+	}
+	if strings.Join(records[0], ",") != "id,pair,ask_price,bid_price,timestamp,created_at,version,source" {
+		t.Errorf("неверный заголовок: %v", records[0])
+	}
+	want := "2,USDT/RUB,92.25,91.5,2024-05-01T12:00:01Z,2024-05-01T12:00:01.001Z,1,garantex"
+	if got := strings.Join(records[2], ","); got != want {
+		t.Errorf("ожидали %q, получили %q", want, got)
+	}
+
+	empty, err := csv.NewReader(bytes.NewReader(writeAll(t, CSV, nil))).ReadAll()
+	if err != nil || len(empty) != 1 {
+		t.Errorf("пустая выгрузка должна содержать только заголовок: %v, %v", empty, err)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeAll(t, JSONL, testRates(3)))), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("ожидали 3 строки, получили %d", len(lines))
+	}
+	var record jsonlRecord
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("строка не является JSON: %v", err)
+	}
+	if record.ID != 2 || record.BidPrice != 91.5 || record.Timestamp != "2024-05-01T12:00:01Z" {
+		t.Errorf("неверная строка: %+v", record)
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	// Больше одного пакета записи, чтобы проверить сброс буфера.
+	rates := testRates(parquetWriteBatch + 10)
+	data := writeAll(t, Parquet, rates)
+
+	reader := parquet.NewGenericReader[parquetRow](bytes.NewReader(data))
+	defer reader.Close()
+	if reader.NumRows() != int64(len(rates)) {
+		t.Fatalf("ожидали %d строк, получили %d", len(rates), reader.NumRows())
+	}
+	rows := make([]parquetRow, len(rates))
+	n, err := reader.Read(rows)
+	if err != nil && !errors.Is(err, io.EOF) {
+		t.Fatalf("не удалось прочитать Parquet: %v", err)
+	}
+	if n != len(rates) {
+		t.Fatalf("прочитано %d строк из %d", n, len(rates))
+	}
+	last := rows[len(rows)-1]
+	want := rates[len(rates)-1]
+	if last.ID != want.ID || last.AskPrice != want.AskPrice || !last.Timestamp.Equal(want.Timestamp) {
+		t.Errorf("неверная строка: %+v", last)
+	}
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	if _, err := NewWriter("xlsx", io.Discard); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ожидали ErrUnknownFormat, получили %v", err)
+	}
+}
+
+func readAll(t *testing.T, format string, data string) ([]models.CurrencyRate, int) {
+	t.Helper()
+	r, err := NewReader(format, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("не удалось создать reader: %v", err)
+	}
+	var rates []models.CurrencyRate
+	invalid := 0
+	for {
+		rate, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rates, invalid
+		}
+		if errors.Is(err, models.ErrInvalidRecord) {
+			invalid++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ошибка чтения: %v", err)
+		}
+		rates = append(rates, rate)
+	}
+}
+
+func TestReader_RoundTrip(t *testing.T) {
+	rates := testRates(3)
+	for _, format := range []string{CSV, JSONL} {
+		got, invalid := readAll(t, format, string(writeAll(t, format, rates)))
+		if invalid != 0 || len(got) != len(rates) {
+			t.Fatalf("%s: прочитано %d строк, %d некорректных", format, len(got), invalid)
+		}
+		for i, rate := range got {
+			want := rates[i]
+			if rate.ID != 0 || rate.Pair != want.Pair || rate.Source != want.Source || rate.AskPrice != want.AskPrice ||
+				rate.BidPrice != want.BidPrice || !rate.Timestamp.Equal(want.Timestamp) {
+				t.Errorf("%s: строка %d: %+v", format, i, rate)
+			}
+		}
+	}
+}
+
+func TestCSVReader_ColumnsByHeader(t *testing.T) {
+	data := "timestamp,bid_price,source,ask_price,pair\n" +
+		"2024-05-01T12:00:00Z,90.5,manual,91.25,USDT/RUB\n" +
+		"2024-05-01T12:00:01Z,abc,manual,91.25,USDT/RUB\n" +
+		"2024-05-01T12:00:02,90.5,manual,91.25,USDT/RUB\n" +
+		"2024-05-01T12:00:03.5+03:00,90.5,manual,91.25,USDT/RUB\n"
+	got, invalid := readAll(t, CSV, data)
+	if invalid != 2 || len(got) != 2 {
+		t.Fatalf("ожидали 2 курса и 2 некорректные строки, получили %d и %d", len(got), invalid)
+	}
+	want := time.Date(2024, 5, 1, 9, 0, 3, 500_000_000, time.UTC)
+	if got[1].AskPrice != 91.25 || got[1].BidPrice != 90.5 || got[1].Source != "manual" || !got[1].Timestamp.Equal(want) {
+		t.Errorf("неверный курс: %+v", got[1])
+	}
+}
+
+func TestCSVReader_MissingColumn(t *testing.T) {
+	r, _ := NewReader(CSV, strings.NewReader("pair,ask_price,timestamp\nUSDT/RUB,91,2024-05-01T12:00:00Z\n"))
+	if _, err := r.Read(); err == nil || errors.Is(err, models.ErrInvalidRecord) {
+		t.Errorf("ожидали ошибку заголовка, получили %v", err)
+	}
+}
+
+func TestJSONLReader_InvalidLines(t *testing.T) {
+	data := `{"pair":"USDT/RUB","ask_price":91.25,"bid_price":90.5,"timestamp":"2024-05-01T12:00:00Z"}` + "\n" +
+		"\n" +
+		"{не json}\n" +
+		`{"ask_price":91.25,"bid_price":90.5,"timestamp":"2024-05-01T12:00:00Z"}` + "\n" +
+		`{"pair":"USDT/USD","ask_price":1.01,"bid_price":0.99,"timestamp":"2024-05-01T12:00:01Z"}` + "\n"
+	got, invalid := readAll(t, JSONL, data)
+	if invalid != 2 || len(got) != 2 || got[1].Pair != "USDT/USD" {
+		t.Errorf("ожидали 2 курса и 2 некорректные строки, получили %+v и %d", got, invalid)
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	for path, want := range map[string]string{"rates.csv": CSV, "/tmp/May.JSONL": JSONL, "a.ndjson": JSONL} {
+		if got, err := FormatFromPath(path); err != nil || got != want {
+			t.Errorf("%s: ожидали %s, получили %s, %v", path, want, got, err)
+		}
+	}
+	if _, err := FormatFromPath("rates.xlsx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ожидали ErrUnknownFormat, получили %v", err)
+	}
+	if _, err := NewReader(Parquet, strings.NewReader("")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("чтение Parquet не поддерживается, получили %v", err)
+	}
+}
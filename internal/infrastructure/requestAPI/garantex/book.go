@@ -0,0 +1,92 @@
+package garantex
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"usdt/internal/models"
+)
+
+// localBook - стакан рынка, собранный из снимка и последующих изменений
+// потока. Уровни хранятся по цене; нулевой объём в изменении удаляет уровень.
+type localBook struct {
+	seq       int64
+	synced    bool
+	timestamp time.Time
+	asks      map[float64]float64
+	bids      map[float64]float64
+}
+
+// reset заменяет стакан снимком. Если снимок не разобран, стакан остаётся
+// несинхронизированным.
+func (b *localBook) reset(msg streamMessage) error {
+	b.asks = make(map[float64]float64, len(msg.Asks))
+	b.bids = make(map[float64]float64, len(msg.Bids))
+	b.synced = false
+	if err := b.update(msg); err != nil {
+		return err
+	}
+	b.synced = true
+	return nil
+}
+
+// update применяет изменение, следующее по номеру за текущим. Изменение с
+// неразобранным уровнем не применяется совсем.
+func (b *localBook) update(msg streamMessage) error {
+	asks, err := parseLevels(msg.Asks)
+	if err != nil {
+		return fmt.Errorf("некорректный уровень asks: %w", err)
+	}
+	bids, err := parseLevels(msg.Bids)
+	if err != nil {
+		return fmt.Errorf("некорректный уровень bids: %w", err)
+	}
+	applyLevels(b.asks, asks)
+	applyLevels(b.bids, bids)
+	b.seq = msg.Seq
+	b.timestamp = time.Unix(msg.Timestamp, 0)
+	return nil
+}
+
+func parseLevels(levels []streamLevel) ([]models.BookLevel, error) {
+	parsed := make([]models.BookLevel, 0, len(levels))
+	for _, level := range levels {
+		l, err := parseLevel(level.Price, level.Volume)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, l)
+	}
+	return parsed, nil
+}
+
+func applyLevels(side map[float64]float64, levels []models.BookLevel) {
+	for _, level := range levels {
+		if level.Price <= 0 {
+			continue
+		}
+		if level.Volume > 0 {
+			side[level.Price] = level.Volume
+		} else {
+			delete(side, level.Price)
+		}
+	}
+}
+
+// orderBook возвращает копию стакана: asks по возрастанию цены, bids по убыванию.
+func (b *localBook) orderBook() models.OrderBook {
+	return models.OrderBook{
+		Timestamp: b.timestamp,
+		Asks:      sortedLevels(b.asks, func(a, b float64) bool { return a < b }),
+		Bids:      sortedLevels(b.bids, func(a, b float64) bool { return a > b }),
+	}
+}
+
+func sortedLevels(side map[float64]float64, less func(a, b float64) bool) []models.BookLevel {
+	levels := make([]models.BookLevel, 0, len(side))
+	for price, volume := range side {
+		levels = append(levels, models.BookLevel{Price: price, Volume: volume})
+	}
+	sort.Slice(levels, func(i, j int) bool { return less(levels[i].Price, levels[j].Price) })
+	return levels
+}
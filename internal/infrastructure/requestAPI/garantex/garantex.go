@@ -1,13 +1,32 @@
 package garantex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
+	"usdt/internal/infrastructure/requestAPI/httpclient"
+	"usdt/internal/infrastructure/requestAPI/replay"
+	"usdt/internal/models"
 )
 
+// Name - название биржи, под которым сохраняются её курсы.
+const Name = "garantex"
+
+// ReplayName - поставщик курсов, воспроизведённых из записи обменов с биржей:
+// они не смешиваются с историей настоящей биржи.
+const ReplayName = Name + "-replay"
+
+// markets - рынки биржи по валюте пары USDT.
+var markets = map[string]string{
+	"RUB": "usdtrub",
+	"USD": "usdtusd",
+	"EUR": "usdteur",
+	"KGS": "usdtkgs",
+}
+
 type GarantexDepth struct {
 	Timestamp int64 `json:"timestamp"`
 	Asks      []struct {
@@ -27,65 +46,115 @@ type GarantexDepth struct {
 type GrantexAPI struct {
 	m       map[string]string
 	baseURL string
+	client  *http.Client
+	retrier *httpclient.Retrier
 }
 
-func NewGrantexAPI(baseURL string) *GrantexAPI {
+// NewGrantexAPI создаёт клиент биржи поверх общего client, при nil -
+// http.DefaultClient. Запросы идут через retrier, при nil - одной попыткой
+// без ограничения частоты.
+func NewGrantexAPI(baseURL string, client *http.Client, retrier *httpclient.Retrier) *GrantexAPI {
 	if baseURL == "" {
 		baseURL = "https://garantex.org/api/v2/depth"
 	}
-	m := map[string]string{
-		"RUB": "usdtrub",
-		"USD": "usdtusd",
-		"EUR": "usdteur",
-		"KGS": "usdtkgs",
+	if client == nil {
+		client = http.DefaultClient
 	}
-
 	return &GrantexAPI{
-		m:       m,
+		m:       markets,
 		baseURL: baseURL,
+		client:  client,
+		retrier: retrier,
 	}
 }
 
-func (g *GrantexAPI) GetRates(market string) (askPrice, bidPrice float64, timestamp time.Time, err error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// NewReplayAPI создаёт клиент биржи, который вместо сети отвечает
+// записанными обменами replay.Transport: стакан рынка берётся из последнего
+// ответа, записанного к текущему времени воспроизведения.
+func NewReplayAPI(transport *replay.Transport) *GrantexAPI {
+	return NewGrantexAPI("", &http.Client{Transport: transport}, nil)
+}
+
+func (g *GrantexAPI) GetRates(ctx context.Context, market string) (askPrice, bidPrice float64, timestamp time.Time, err error) {
+	book, err := g.GetOrderBook(ctx, market)
+	if err != nil {
+		return 0, 0, time.Time{}, err
 	}
+	return book.Asks[0].Price, book.Bids[0].Price, book.Timestamp, nil
+}
+
+// GetOrderBook загружает стакан рынка; asks и bids гарантированно не пусты.
+// Повторы запроса укладываются в дедлайн ctx.
+func (g *GrantexAPI) GetOrderBook(ctx context.Context, market string) (models.OrderBook, error) {
 	market, ok := g.m[market]
 	if !ok {
-		return 0, 0, time.Time{}, fmt.Errorf("market not exist")
+		return models.OrderBook{}, fmt.Errorf("%w: рынок не поддерживается", models.ErrRateNotFound)
 	}
 
 	url := fmt.Sprintf("%s?market=%s", g.baseURL, market)
-	resp, err := client.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return 0, 0, time.Time{}, fmt.Errorf("не удалось выполнить запрос к API Garantex: %w", err)
+		return models.OrderBook{}, fmt.Errorf("не удалось сформировать запрос к API Garantex: %w", err)
+	}
+	resp, err := g.retrier.Do(ctx, g.client, req)
+	if err != nil {
+		return models.OrderBook{}, fmt.Errorf("не удалось выполнить запрос к API Garantex: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, 0, time.Time{}, fmt.Errorf("неправильный статус ответа от API: %s", resp.Status)
+		return models.OrderBook{}, fmt.Errorf("неправильный статус ответа от API: %s", resp.Status)
 	}
 
 	var depth GarantexDepth
 	if err := json.NewDecoder(resp.Body).Decode(&depth); err != nil {
-		return 0, 0, time.Time{}, fmt.Errorf("ошибка при декодировании ответа: %w", err)
+		return models.OrderBook{}, fmt.Errorf("ошибка при декодировании ответа: %w", err)
 	}
 
 	if len(depth.Asks) == 0 || len(depth.Bids) == 0 {
-		return 0, 0, time.Time{}, fmt.Errorf("не удалось найти данные о ценах в ответе")
+		return models.OrderBook{}, fmt.Errorf("не удалось найти данные о ценах в ответе")
 	}
 
-	askPrice = parsePrice(depth.Asks[0].Price)
-	bidPrice = parsePrice(depth.Bids[0].Price)
-	timestamp = time.Unix(depth.Timestamp, 0)
+	book := models.OrderBook{
+		Timestamp: time.Unix(depth.Timestamp, 0),
+		Asks:      make([]models.BookLevel, 0, len(depth.Asks)),
+		Bids:      make([]models.BookLevel, 0, len(depth.Bids)),
+	}
+	// Один неразобранный уровень искажает цены и объёмы стакана, поэтому
+	// такой ответ отвергается целиком.
+	for _, level := range depth.Asks {
+		parsed, err := parseLevel(level.Price, level.Volume)
+		if err != nil {
+			return models.OrderBook{}, fmt.Errorf("некорректный уровень asks в ответе: %w", err)
+		}
+		book.Asks = append(book.Asks, parsed)
+	}
+	for _, level := range depth.Bids {
+		parsed, err := parseLevel(level.Price, level.Volume)
+		if err != nil {
+			return models.OrderBook{}, fmt.Errorf("некорректный уровень bids в ответе: %w", err)
+		}
+		book.Bids = append(book.Bids, parsed)
+	}
+	return book, nil
+}
 
-	return askPrice, bidPrice, timestamp, nil
+func parseLevel(price, volume string) (models.BookLevel, error) {
+	p, err := parsePrice(price)
+	if err != nil {
+		return models.BookLevel{}, err
+	}
+	v, err := parsePrice(volume)
+	if err != nil {
+		return models.BookLevel{}, err
+	}
+	return models.BookLevel{Price: p, Volume: v}, nil
 }
 
-func parsePrice(price string) float64 {
+func parsePrice(price string) (float64, error) {
 	p, err := strconv.ParseFloat(price, 64)
 	if err != nil {
-		return 0
+		return 0, fmt.Errorf("не удалось разобрать число %q: %w", price, err)
 	}
-	return p
+	return p, nil
 }
@@ -1,9 +1,12 @@
 package garantex
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+	"usdt/internal/infrastructure/requestAPI/replay"
 )
 
 func TestGetRates(t *testing.T) {
@@ -63,8 +66,8 @@ func TestGetRates(t *testing.T) {
 			}))
 			defer server.Close()
 
-			api := NewGrantexAPI(server.URL)
-			ask, bid, ts, err := api.GetRates(tt.market)
+			api := NewGrantexAPI(server.URL, server.Client(), nil)
+			ask, bid, ts, err := api.GetRates(context.Background(), tt.market)
 
 			if (err != nil) != tt.expectErr {
 				t.Fatalf("ожидали ошибку: %v, получили: %v", tt.expectErr, err)
@@ -84,3 +87,81 @@ func TestGetRates(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOrderBook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("market") != "usdtrub" {
+			t.Errorf("ожидали market=usdtrub, получили: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"timestamp": 1698405000,
+			"asks": [{"price": "100.5", "volume": "10"}, {"price": "101", "volume": "20"}],
+			"bids": [{"price": "99.5", "volume": "5"}, {"price": "99", "volume": "7"}]}`))
+	}))
+	defer server.Close()
+
+	api := NewGrantexAPI(server.URL, server.Client(), nil)
+	book, err := api.GetOrderBook(context.Background(), "RUB")
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if len(book.Asks) != 2 || len(book.Bids) != 2 {
+		t.Fatalf("ожидали по 2 уровня, получили asks=%d bids=%d", len(book.Asks), len(book.Bids))
+	}
+	if book.Asks[1].Price != 101 || book.Asks[1].Volume != 20 {
+		t.Errorf("неверный второй уровень asks: %+v", book.Asks[1])
+	}
+	if book.Timestamp.Unix() != 1698405000 {
+		t.Errorf("неверный timestamp: %v", book.Timestamp)
+	}
+}
+
+func TestGetOrderBook_BadLevel(t *testing.T) {
+	for name, level := range map[string]string{
+		"Price":  `{"price": "bad", "volume": "7"}`,
+		"Volume": `{"price": "99", "volume": ""}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"timestamp": 1698405000,
+					"asks": [{"price": "100.5", "volume": "10"}],
+					"bids": [{"price": "99.5", "volume": "5"}, ` + level + `]}`))
+			}))
+			defer server.Close()
+
+			api := NewGrantexAPI(server.URL, server.Client(), nil)
+			if _, err := api.GetOrderBook(context.Background(), "RUB"); err == nil {
+				t.Fatal("ожидали ошибку для неразобранного уровня")
+			}
+		})
+	}
+}
+
+func TestReplayAPI(t *testing.T) {
+	exchanges, err := replay.Load("testdata/depth_usdtrub.jsonl")
+	if err != nil {
+		t.Fatalf("не удалось загрузить запись: %v", err)
+	}
+	start := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		offset    time.Duration
+		market    string
+		expectErr bool
+		expectAsk float64
+	}{
+		{offset: 0, market: "RUB", expectAsk: 100.5},
+		{offset: 0, market: "USD", expectErr: true},
+		{offset: 7 * time.Second, market: "USD", expectAsk: 1.01},
+		{offset: 12 * time.Second, market: "RUB", expectErr: true},
+		{offset: time.Hour, market: "RUB", expectAsk: 101},
+	}
+	for _, tc := range cases {
+		transport := replay.NewTransport(exchanges, start.Add(tc.offset), 1)
+		book, err := NewReplayAPI(transport).GetOrderBook(context.Background(), tc.market)
+		if (err != nil) != tc.expectErr {
+			t.Fatalf("%s +%s: ожидали ошибку: %v, получили: %v", tc.market, tc.offset, tc.expectErr, err)
+		}
+		if !tc.expectErr && book.Asks[0].Price != tc.expectAsk {
+			t.Errorf("%s +%s: ожидали ask %f, получили %f", tc.market, tc.offset, tc.expectAsk, book.Asks[0].Price)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package garantex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"usdt/config"
+	"usdt/internal/infrastructure/requestAPI/httpclient"
+	"usdt/internal/models"
+)
+
+// Поток стаканов. Протокол синтетический: документации WebSocket API
+// Garantex и записи его настоящего трафика нет, поэтому сообщения ниже -
+// допущение по образцу REST depth, и перед включением потока на бирже его
+// нужно сверить с записью реального обмена. Клиент подписывается на каждый
+// рынок сообщением {"event":"subscribe","channel":"depth","market":"usdtrub"};
+// сервер отвечает снимком "snapshot" и затем присылает изменения "update" с
+// номерами seq, идущими подряд. Уровни передаются так же, как в REST depth;
+// нулевой объём удаляет уровень. Пропущенный номер означает потерянное
+// изменение: стакан не используется до нового снимка, за которым клиент
+// подписывается повторно.
+const (
+	eventSubscribe = "subscribe"
+	eventSnapshot  = "snapshot"
+	eventUpdate    = "update"
+	eventError     = "error"
+	depthChannel   = "depth"
+
+	streamSubscriberBuffer = 16
+)
+
+// ErrBookNotSynced - стакан рынка ещё не получен или потерял синхронизацию.
+var ErrBookNotSynced = errors.New("стакан потока не синхронизирован")
+
+type streamLevel struct {
+	Price  string `json:"price"`
+	Volume string `json:"volume"`
+}
+
+type streamMessage struct {
+	Event     string        `json:"event"`
+	Channel   string        `json:"channel,omitempty"`
+	Market    string        `json:"market"`
+	Seq       int64         `json:"seq,omitempty"`
+	Timestamp int64         `json:"timestamp,omitempty"`
+	Asks      []streamLevel `json:"asks,omitempty"`
+	Bids      []streamLevel `json:"bids,omitempty"`
+	Message   string        `json:"message,omitempty"`
+}
+
+// Stream ведёт стаканы рынков по подписке WebSocket и отдаёт их без запроса
+// к бирже. При обрыве соединения стаканы потока не используются, пока поток
+// не переподключится и не пришлёт новые снимки; до этого стаканы
+// запрашиваются у REST API биржи.
+type Stream struct {
+	conf   config.Exchange
+	dialer *httpclient.Dialer
+	rest   *GrantexAPI
+	logger *zap.Logger
+	// currencies - валюта пары по рынку биржи.
+	currencies map[string]string
+
+	mu          sync.Mutex
+	books       map[string]*localBook
+	nextID      int
+	subscribers map[int]chan string
+}
+
+// NewStream создаёт поток по рынкам валют currencies; валюты без рынка на
+// бирже пропускаются. Соединение открывает dialer - через тот же прокси, что
+// и запросы HTTP клиента. Пока стакан не синхронизирован, он запрашивается у
+// rest; при nil возвращается ErrBookNotSynced.
+func NewStream(conf config.Exchange, dialer *httpclient.Dialer, rest *GrantexAPI, currencies []string, logger *zap.Logger) *Stream {
+	s := &Stream{
+		conf:        conf,
+		dialer:      dialer,
+		rest:        rest,
+		logger:      logger,
+		currencies:  make(map[string]string),
+		books:       make(map[string]*localBook),
+		subscribers: make(map[int]chan string),
+	}
+	for _, currency := range currencies {
+		market, ok := markets[currency]
+		if !ok {
+			logger.Warn("Рынок валюты не поддерживается потоком", zap.String("currency", currency))
+			continue
+		}
+		s.currencies[market] = currency
+		s.books[market] = &localBook{}
+	}
+	return s
+}
+
+// Run держит подписку до отмены ctx, переподключаясь с экспоненциальной
+// задержкой. Задержка сбрасывается, если соединение успело прислать снимок.
+func (s *Stream) Run(ctx context.Context) {
+	for attempt := 1; ; attempt++ {
+		synced, err := s.session(ctx)
+		s.desync()
+		if ctx.Err() != nil {
+			return
+		}
+		if synced {
+			attempt = 1
+		}
+		delay := s.backoff(attempt)
+		s.logger.Warn("Поток стаканов прерван, переподключение", zap.Error(err), zap.Duration("retry_in", delay))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// session подключается к потоку и обрабатывает сообщения до ошибки.
+func (s *Stream) session(ctx context.Context) (bool, error) {
+	wsConf, err := websocket.NewConfig(s.conf.StreamURL, origin(s.conf.StreamURL))
+	if err != nil {
+		return false, fmt.Errorf("некорректный адрес потока Garantex: %w", err)
+	}
+	raw, err := s.dialer.Dial(ctx, wsConf.Location)
+	if err != nil {
+		return false, fmt.Errorf("не удалось подключиться к потоку Garantex: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { raw.Close() })
+	defer stop()
+	// Рукопожатие WebSocket не знает о ctx, поэтому ограничено тем же
+	// таймаутом, что и чтение.
+	if s.conf.StreamReadTimeout > 0 {
+		raw.SetDeadline(time.Now().Add(s.conf.StreamReadTimeout))
+	}
+	conn, err := websocket.NewClient(wsConf, raw)
+	if err != nil {
+		raw.Close()
+		return false, fmt.Errorf("не удалось подключиться к потоку Garantex: %w", err)
+	}
+	defer conn.Close()
+	raw.SetDeadline(time.Time{})
+
+	for market := range s.currencies {
+		if err := subscribe(conn, market); err != nil {
+			return false, err
+		}
+	}
+	synced := false
+	for {
+		if s.conf.StreamReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.conf.StreamReadTimeout))
+		}
+		var msg streamMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return synced, fmt.Errorf("ошибка чтения потока Garantex: %w", err)
+		}
+		resync, err := s.apply(msg)
+		if err != nil {
+			return synced, err
+		}
+		if msg.Event == eventSnapshot {
+			synced = true
+		}
+		if resync {
+			s.logger.Warn("Пропуск в потоке стакана, запрошен новый снимок", zap.String("market", msg.Market))
+			if err := subscribe(conn, msg.Market); err != nil {
+				return synced, err
+			}
+		}
+	}
+}
+
+func subscribe(conn *websocket.Conn, market string) error {
+	msg := streamMessage{Event: eventSubscribe, Channel: depthChannel, Market: market}
+	if err := websocket.JSON.Send(conn, msg); err != nil {
+		return fmt.Errorf("не удалось подписаться на стакан %s: %w", market, err)
+	}
+	return nil
+}
+
+// apply применяет сообщение к стакану рынка и сообщает, нужен ли новый снимок.
+func (s *Stream) apply(msg streamMessage) (bool, error) {
+	switch msg.Event {
+	case eventError:
+		return false, fmt.Errorf("поток Garantex вернул ошибку: %s", msg.Message)
+	case eventSnapshot, eventUpdate:
+	default:
+		return false, nil
+	}
+	currency, ok := s.currencies[msg.Market]
+	if !ok {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	book := s.books[msg.Market]
+	var err error
+	switch {
+	case msg.Event == eventSnapshot:
+		err = book.reset(msg)
+	case !book.synced || msg.Seq <= book.seq:
+		// До снимка изменения не к чему применять, а старые уже учтены.
+		s.mu.Unlock()
+		return false, nil
+	case msg.Seq != book.seq+1:
+		book.synced = false
+		s.mu.Unlock()
+		return true, nil
+	default:
+		err = book.update(msg)
+	}
+	if err != nil {
+		// Как и при пропуске изменения, стакан не используется до нового снимка.
+		book.synced = false
+		s.mu.Unlock()
+		s.logger.Warn("Не удалось разобрать сообщение потока стакана", zap.String("market", msg.Market), zap.Error(err))
+		return true, nil
+	}
+	s.mu.Unlock()
+	s.publish(currency)
+	return false, nil
+}
+
+// desync помечает все стаканы устаревшими после обрыва соединения.
+func (s *Stream) desync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, book := range s.books {
+		book.synced = false
+	}
+}
+
+func (s *Stream) backoff(attempt int) time.Duration {
+	delay := s.conf.RetryBackoff << (attempt - 1)
+	if delay <= 0 || (s.conf.MaxBackoff > 0 && delay > s.conf.MaxBackoff) {
+		return s.conf.MaxBackoff
+	}
+	return delay
+}
+
+// GetOrderBook возвращает текущий стакан потока, а если он не
+// синхронизирован - стакан REST API; asks и bids гарантированно не пусты.
+func (s *Stream) GetOrderBook(ctx context.Context, currency string) (models.OrderBook, error) {
+	market, ok := markets[currency]
+	if !ok {
+		return models.OrderBook{}, fmt.Errorf("%w: рынок не поддерживается", models.ErrRateNotFound)
+	}
+	s.mu.Lock()
+	book, ok := s.books[market]
+	if !ok || !book.synced {
+		s.mu.Unlock()
+		if s.rest != nil {
+			return s.rest.GetOrderBook(ctx, currency)
+		}
+		return models.OrderBook{}, fmt.Errorf("%s: %w", market, ErrBookNotSynced)
+	}
+	orderBook := book.orderBook()
+	s.mu.Unlock()
+
+	if len(orderBook.Asks) == 0 || len(orderBook.Bids) == 0 {
+		return models.OrderBook{}, fmt.Errorf("не удалось найти данные о ценах в стакане потока")
+	}
+	return orderBook, nil
+}
+
+// Subscribe возвращает канал валют, стакан которых изменился, и функцию
+// отписки. Медленный подписчик теряет уведомления, а не тормозит поток.
+func (s *Stream) Subscribe() (<-chan string, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID
+	s.nextID++
+	ch := make(chan string, streamSubscriberBuffer)
+	s.subscribers[id] = ch
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
+func (s *Stream) publish(currency string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- currency:
+		default:
+		}
+	}
+}
+
+// origin - заголовок Origin для адреса потока: тот же хост по http(s).
+func origin(streamURL string) string {
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return "http://localhost"
+	}
+	scheme := "http"
+	if u.Scheme == "wss" {
+		scheme = "https"
+	}
+	return (&url.URL{Scheme: scheme, Host: u.Host}).String()
+}
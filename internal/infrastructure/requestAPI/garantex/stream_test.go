@@ -0,0 +1,178 @@
+package garantex
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+	"usdt/config"
+	"usdt/internal/infrastructure/requestAPI/httpclient"
+	"usdt/internal/models"
+)
+
+// replayServer - WebSocket сервер, который проигрывает сообщения потока из
+// файла. Файл testdata/stream_usdtrub_synthetic.jsonl составлен вручную по
+// синтетическому протоколу из stream.go, а не записан с биржи. Каждый снимок
+// отправляется только в ответ на подписку на его рынок; после файла
+// соединение держится до hold. Файл проигрывается один раз, следующие
+// соединения сразу закрываются.
+type replayServer struct {
+	*httptest.Server
+	served     atomic.Bool
+	subscribes atomic.Int32
+	hold       chan struct{}
+}
+
+func newReplayServer(t *testing.T, path string) *replayServer {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var recorded []streamMessage
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var msg streamMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		recorded = append(recorded, msg)
+	}
+	require.NoError(t, scanner.Err())
+
+	s := &replayServer{hold: make(chan struct{})}
+	s.Server = httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		if s.served.Swap(true) {
+			return
+		}
+		subscribed := map[string]int{}
+		for _, msg := range recorded {
+			for msg.Event == eventSnapshot && subscribed[msg.Market] == 0 {
+				var request streamMessage
+				if err := websocket.JSON.Receive(conn, &request); err != nil {
+					return
+				}
+				if request.Event == eventSubscribe && request.Channel == depthChannel {
+					s.subscribes.Add(1)
+					subscribed[request.Market]++
+				}
+			}
+			if msg.Event == eventSnapshot {
+				subscribed[msg.Market]--
+			}
+			if err := websocket.JSON.Send(conn, msg); err != nil {
+				return
+			}
+		}
+		<-s.hold
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestStream(t *testing.T, url string) *Stream {
+	t.Helper()
+	dialer, err := httpclient.NewDialer(config.HTTPClient{DialTimeout: time.Second})
+	require.NoError(t, err)
+	conf := config.Exchange{
+		StreamURL:         "ws" + strings.TrimPrefix(url, "http"),
+		RetryBackoff:      10 * time.Millisecond,
+		MaxBackoff:        10 * time.Millisecond,
+		StreamReadTimeout: time.Second,
+	}
+	return NewStream(conf, dialer, nil, []string{"RUB", "XXX"}, zap.NewNop())
+}
+
+func TestStream_Replay(t *testing.T) {
+	server := newReplayServer(t, "testdata/stream_usdtrub_synthetic.jsonl")
+	stream := newTestStream(t, server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := stream.GetOrderBook(ctx, "RUB")
+	assert.ErrorIs(t, err, ErrBookNotSynced)
+	_, err = stream.GetOrderBook(ctx, "XXX")
+	assert.Error(t, err)
+
+	updates, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+	done := make(chan struct{})
+	go func() {
+		stream.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 4; i++ {
+		select {
+		case currency := <-updates:
+			assert.Equal(t, "RUB", currency)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("получено %d уведомлений из 4", i)
+		}
+	}
+	book, err := stream.GetOrderBook(ctx, "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, []models.BookLevel{{Price: 100.7, Volume: 3}}, book.Asks, "изменения после пропуска не применяются")
+	assert.Equal(t, []models.BookLevel{{Price: 99.9, Volume: 1}, {Price: 99.6, Volume: 4}}, book.Bids)
+	assert.Equal(t, int64(1698405006), book.Timestamp.Unix())
+	assert.Equal(t, int32(2), server.subscribes.Load(), "пропуск номера вызывает повторную подписку")
+
+	close(server.hold)
+	assert.Eventually(t, func() bool {
+		_, err := stream.GetOrderBook(ctx, "RUB")
+		return errors.Is(err, ErrBookNotSynced)
+	}, 2*time.Second, 5*time.Millisecond, "после обрыва стакан не используется")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+}
+
+func TestStream_RESTFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"timestamp": 1698405000, "asks": [{"price": "100.5", "volume": "10"}], "bids": [{"price": "99.5", "volume": "10"}]}`))
+	}))
+	defer server.Close()
+	stream := newTestStream(t, "http://127.0.0.1:1")
+	stream.rest = NewGrantexAPI(server.URL, server.Client(), nil)
+
+	book, err := stream.GetOrderBook(context.Background(), "RUB")
+	require.NoError(t, err, "пока поток не синхронизирован, стакан берётся из REST")
+	assert.Equal(t, []models.BookLevel{{Price: 100.5, Volume: 10}}, book.Asks)
+	assert.Equal(t, int64(1698405000), book.Timestamp.Unix())
+}
+
+func TestLocalBook(t *testing.T) {
+	var book localBook
+	require.NoError(t, book.reset(streamMessage{Seq: 1, Timestamp: 1698405000,
+		Asks: []streamLevel{{Price: "101", Volume: "1"}, {Price: "100", Volume: "2"}},
+		Bids: []streamLevel{{Price: "98", Volume: "1"}, {Price: "99", Volume: "2"}}}))
+	require.NoError(t, book.update(streamMessage{Seq: 2, Timestamp: 1698405001,
+		Asks: []streamLevel{{Price: "101", Volume: "0"}, {Price: "100", Volume: "5"}},
+		Bids: []streamLevel{{Price: "99.5", Volume: "1"}}}))
+	assert.Error(t, book.update(streamMessage{Seq: 3, Timestamp: 1698405002,
+		Asks: []streamLevel{{Price: "100", Volume: "9"}, {Price: "bad", Volume: "3"}}}))
+
+	got := book.orderBook()
+	assert.Equal(t, []models.BookLevel{{Price: 100, Volume: 5}}, got.Asks)
+	assert.Equal(t, []models.BookLevel{{Price: 99.5, Volume: 1}, {Price: 99, Volume: 2}, {Price: 98, Volume: 1}}, got.Bids)
+	assert.Equal(t, int64(2), book.seq)
+
+	got.Asks[0].Price = 0
+	assert.Equal(t, 100.0, book.orderBook().Asks[0].Price, "стакан отдаётся копией")
+
+	assert.Error(t, book.reset(streamMessage{Seq: 4, Bids: []streamLevel{{Price: "99", Volume: "x"}}}))
+	assert.False(t, book.synced, "неразобранный снимок не синхронизирует стакан")
+}
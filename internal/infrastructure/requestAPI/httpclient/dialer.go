@@ -0,0 +1,178 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"usdt/config"
+)
+
+// Dialer открывает соединения с биржами для протоколов, которые не проходят
+// через http.Transport, например WebSocket. Прокси, CA и таймауты те же, что
+// у HTTP клиента: через HTTP прокси соединение идёт запросом CONNECT, через
+// SOCKS5 - по протоколу SOCKS.
+type Dialer struct {
+	proxy            func(*http.Request) (*url.URL, error)
+	direct           *net.Dialer
+	tlsConf          *tls.Config
+	handshakeTimeout time.Duration
+}
+
+func NewDialer(conf config.HTTPClient) (*Dialer, error) {
+	proxyURL, err := proxyFunc(conf)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := TLSConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Dialer{
+		proxy:            proxyURL,
+		direct:           &net.Dialer{Timeout: conf.DialTimeout, KeepAlive: 30 * time.Second},
+		tlsConf:          tlsConf,
+		handshakeTimeout: conf.TLSHandshakeTimeout,
+	}, nil
+}
+
+// Dial соединяется с хостом target. Для схем https и wss поверх соединения
+// выполняется TLS рукопожатие с биржей.
+func (d *Dialer) Dial(ctx context.Context, target *url.URL) (net.Conn, error) {
+	addr := hostPort(target)
+	proxyURL, err := d.proxy(&http.Request{URL: proxyTarget(target)})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось выбрать прокси: %w", err)
+	}
+	var conn net.Conn
+	switch {
+	case proxyURL == nil:
+		conn, err = d.direct.DialContext(ctx, "tcp", addr)
+	case proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h":
+		conn, err = d.dialSOCKS(ctx, proxyURL, addr)
+	default:
+		conn, err = d.dialConnect(ctx, proxyURL, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "https" && target.Scheme != "wss" {
+		return conn, nil
+	}
+	return d.handshake(ctx, conn, target.Hostname())
+}
+
+func (d *Dialer) dialSOCKS(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	dialer, err := proxy.FromURL(proxyURL, d.direct)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный адрес прокси: %w", err)
+	}
+	conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться к %s через прокси: %w", addr, err)
+	}
+	return conn, nil
+}
+
+// dialConnect открывает туннель к addr запросом CONNECT к HTTP прокси.
+func (d *Dialer) dialConnect(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	conn, err := d.direct.DialContext(ctx, "tcp", hostPort(proxyURL))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться к прокси: %w", err)
+	}
+	if proxyURL.Scheme == "https" {
+		if conn, err = d.handshake(ctx, conn, proxyURL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Отмена ctx прерывает ожидание ответа прокси.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	err = req.Write(conn)
+	var resp *http.Response
+	reader := bufio.NewReader(conn)
+	if err == nil {
+		resp, err = http.ReadResponse(reader, req)
+	}
+	if !stop() {
+		err = errors.Join(err, ctx.Err())
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ошибка запроса CONNECT к прокси: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("прокси отказал в соединении с %s: %s", addr, resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		conn.Close()
+		return nil, fmt.Errorf("прокси прислал данные до установки туннеля к %s", addr)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (d *Dialer) handshake(ctx context.Context, conn net.Conn, serverName string) (net.Conn, error) {
+	tlsConf := d.tlsConf.Clone()
+	tlsConf.ServerName = serverName
+	tlsConn := tls.Client(conn, tlsConf)
+	if d.handshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.handshakeTimeout)
+		defer cancel()
+	}
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ошибка TLS рукопожатия с %s: %w", serverName, err)
+	}
+	return tlsConn, nil
+}
+
+// proxyTarget - адрес для выбора прокси: ws и wss выбираются как http и
+// https, иначе HTTPS_PROXY и HTTP_PROXY к ним не применяются.
+func proxyTarget(target *url.URL) *url.URL {
+	u := *target
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	return &u
+}
+
+// hostPort - хост и порт адреса; без порта берётся порт схемы.
+func hostPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	switch u.Scheme {
+	case "https", "wss":
+		return net.JoinHostPort(u.Hostname(), "443")
+	case "socks5", "socks5h":
+		return net.JoinHostPort(u.Hostname(), "1080")
+	default:
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+}
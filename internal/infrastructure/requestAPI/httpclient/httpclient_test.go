@@ -0,0 +1,336 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/config"
+)
+
+// fakeClock - часы, которые двигает только sleep.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func newTestRetrier(limit config.Limit, clock *fakeClock) *Retrier {
+	limiter := NewLimiter(limit)
+	limiter.now, limiter.sleep = clock.Now, clock.Sleep
+	r := NewRetrier(limiter, config.Exchange{MaxAttempts: 4, RetryBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond})
+	r.now, r.sleep = clock.Now, clock.Sleep
+	r.jitter = func(n int64) int64 { return n }
+	return r
+}
+
+func TestLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(config.Limit{RPS: 2, Burst: 2})
+	limiter.now, limiter.sleep = clock.Now, clock.Sleep
+	ctx := context.Background()
+
+	require.NoError(t, limiter.Wait(ctx))
+	require.NoError(t, limiter.Wait(ctx))
+	assert.Empty(t, clock.sleeps, "запас расходуется без ожидания")
+	require.NoError(t, limiter.Wait(ctx))
+	assert.Equal(t, []time.Duration{500 * time.Millisecond}, clock.sleeps)
+
+	deadline, cancel := context.WithDeadline(ctx, clock.now.Add(100*time.Millisecond))
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(deadline), ErrBudgetExceeded)
+	assert.Len(t, clock.sleeps, 1, "очередь за дедлайном не ждём")
+
+	require.NoError(t, limiter.Wait(ctx))
+	assert.Equal(t, 500*time.Millisecond, clock.sleeps[1], "отказ возвращает занятую очередь")
+
+	var unlimited *Limiter
+	assert.NoError(t, unlimited.Wait(ctx))
+}
+
+func TestRetrier(t *testing.T) {
+	var calls atomic.Int32
+	statuses := map[int32]int{}
+	retryAfter := map[int32]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if value, ok := retryAfter[n]; ok {
+			w.Header().Set("Retry-After", value)
+		}
+		if code, ok := statuses[n]; ok {
+			w.WriteHeader(code)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	do := func(t *testing.T, ctx context.Context, r *Retrier) (*http.Response, error) {
+		t.Helper()
+		calls.Store(0)
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		return r.Do(ctx, server.Client(), req)
+	}
+
+	t.Run("Backoff", func(t *testing.T) {
+		statuses = map[int32]int{1: http.StatusBadGateway, 2: http.StatusTooManyRequests, 3: http.StatusServiceUnavailable}
+		retryAfter = map[int32]string{}
+		clock := &fakeClock{}
+		resp, err := do(t, context.Background(), newTestRetrier(config.Limit{}, clock))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(4), calls.Load())
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, clock.sleeps)
+	})
+
+	t.Run("RetryAfter", func(t *testing.T) {
+		statuses = map[int32]int{1: http.StatusTooManyRequests}
+		retryAfter = map[int32]string{1: "2"}
+		clock := &fakeClock{}
+		resp, err := do(t, context.Background(), newTestRetrier(config.Limit{}, clock))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, []time.Duration{2 * time.Second}, clock.sleeps)
+	})
+
+	t.Run("NotRetryable", func(t *testing.T) {
+		statuses = map[int32]int{1: http.StatusNotFound}
+		clock := &fakeClock{}
+		resp, err := do(t, context.Background(), newTestRetrier(config.Limit{}, clock))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		statuses = map[int32]int{1: 500, 2: 500, 3: 500, 4: 500}
+		retryAfter = map[int32]string{}
+		resp, err := do(t, context.Background(), newTestRetrier(config.Limit{}, &fakeClock{}))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, "последний ответ возвращается вызывающему")
+		assert.Equal(t, int32(4), calls.Load())
+	})
+
+	t.Run("Budget", func(t *testing.T) {
+		statuses = map[int32]int{1: http.StatusServiceUnavailable}
+		retryAfter = map[int32]string{1: "30"}
+		clock := &fakeClock{now: time.Now()}
+		ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(10*time.Second))
+		defer cancel()
+		_, err := do(t, ctx, newTestRetrier(config.Limit{}, clock))
+		assert.ErrorIs(t, err, ErrBudgetExceeded)
+		assert.Contains(t, err.Error(), "503")
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Empty(t, clock.sleeps)
+	})
+
+	t.Run("NetworkError", func(t *testing.T) {
+		clock := &fakeClock{}
+		r := newTestRetrier(config.Limit{}, clock)
+		req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:1", nil)
+		require.NoError(t, err)
+		_, err = r.Do(context.Background(), http.DefaultClient, req)
+		assert.Error(t, err)
+		assert.Len(t, clock.sleeps, 3, "сетевые ошибки повторяются")
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, retryAfter("5", now))
+	assert.Equal(t, 90*time.Second, retryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, retryAfter("", now))
+	assert.Zero(t, retryAfter("скоро", now))
+	assert.Zero(t, retryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
+
+func testHTTPClient() config.HTTPClient {
+	return config.HTTPClient{
+		DialTimeout:           time.Second,
+		TLSHandshakeTimeout:   time.Second,
+		ResponseHeaderTimeout: time.Second,
+		RequestTimeout:        5 * time.Second,
+		IdleConnTimeout:       time.Minute,
+		MaxIdleConns:          10,
+		MaxConnsPerHost:       2,
+	}
+}
+
+func TestClient_KeepAlive(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient(testHTTPClient())
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	assert.Equal(t, int32(1), conns.Load(), "соединение переиспользуется")
+}
+
+func TestClient_Proxy(t *testing.T) {
+	var target string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.URL.String()
+		w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	conf := testHTTPClient()
+	conf.ProxyURL = proxy.URL
+	client, err := NewClient(conf)
+	require.NoError(t, err)
+	resp, err := client.Get("http://exchange.invalid/api/v2/depth?market=usdtrub")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "http://exchange.invalid/api/v2/depth?market=usdtrub", target)
+
+	conf.ProxyURL = "socks5://127.0.0.1:1080"
+	_, err = NewTransport(conf)
+	assert.NoError(t, err)
+	conf.ProxyURL = "ftp://proxy"
+	_, err = NewTransport(conf)
+	assert.Error(t, err)
+}
+
+func TestClient_CAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(testHTTPClient())
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err, "сертификат тестового сервера не доверен")
+
+	conf := testHTTPClient()
+	conf.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(conf.CAFile, cert, 0o600))
+	client, err = NewClient(conf)
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, os.WriteFile(conf.CAFile, []byte("не PEM"), 0o600))
+	_, err = NewClient(conf)
+	assert.Error(t, err)
+}
+
+// get отправляет запрос GET по готовому соединению и возвращает тело ответа.
+func get(t *testing.T, conn net.Conn) string {
+	t.Helper()
+	defer conn.Close()
+	_, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: exchange\r\nConnection: close\r\n\r\n"))
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestDialer_Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+	requests := make(chan *http.Request, 2)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		upstream, err := net.Dial("tcp", r.Host)
+		if r.Method != http.MethodConnect || err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+
+	conf := testHTTPClient()
+	conf.ProxyURL = "http://user:secret@" + proxy.Listener.Addr().String()
+	dialer, err := NewDialer(conf)
+	require.NoError(t, err)
+	targetURL, err := url.Parse("ws" + strings.TrimPrefix(target.URL, "http"))
+	require.NoError(t, err)
+	conn, err := dialer.Dial(context.Background(), targetURL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", get(t, conn))
+	req := <-requests
+	assert.Equal(t, http.MethodConnect, req.Method)
+	assert.Equal(t, targetURL.Host, req.Host)
+	assert.Equal(t, "Basic dXNlcjpzZWNyZXQ=", req.Header.Get("Proxy-Authorization"))
+
+	_, err = dialer.Dial(context.Background(), &url.URL{Scheme: "ws", Host: "127.0.0.1:1"})
+	assert.ErrorContains(t, err, "502", "отказ прокси")
+}
+
+func TestDialer_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	targetURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dialer, err := NewDialer(testHTTPClient())
+	require.NoError(t, err)
+	_, err = dialer.Dial(context.Background(), targetURL)
+	assert.Error(t, err, "сертификат тестового сервера не доверен")
+
+	conf := testHTTPClient()
+	conf.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(conf.CAFile, cert, 0o600))
+	dialer, err = NewDialer(conf)
+	require.NoError(t, err)
+	conn, err := dialer.Dial(context.Background(), targetURL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", get(t, conn))
+}
@@ -0,0 +1,93 @@
+// Package httpclient - общие средства запросов к биржам: ограничение частоты
+// исходящих запросов и повтор неудачных запросов в пределах дедлайна.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"usdt/config"
+)
+
+// ErrBudgetExceeded - ожидание очереди или повтора не укладывается в дедлайн вызывающего.
+var ErrBudgetExceeded = errors.New("запрос к бирже не укладывается в дедлайн")
+
+// Limiter - token bucket исходящих запросов к одной бирже. Один Limiter
+// разделяется всеми, кто обращается к бирже, чтобы вместе не превысить её
+// лимиты. Нулевой RPS снимает ограничение.
+type Limiter struct {
+	limit config.Limit
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+}
+
+func NewLimiter(limit config.Limit) *Limiter {
+	return &Limiter{
+		limit:  limit,
+		now:    time.Now,
+		sleep:  sleep,
+		tokens: float64(max(limit.Burst, 1)),
+	}
+}
+
+// Wait ждёт своей очереди на запрос. Если очередь наступит позже дедлайна
+// ctx, Wait сразу возвращает ErrBudgetExceeded, не занимая её.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil || l.limit.RPS <= 0 {
+		return nil
+	}
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && l.now().Add(wait).After(deadline) {
+		l.cancel()
+		return ErrBudgetExceeded
+	}
+	if err := l.sleep(ctx, wait); err != nil {
+		l.cancel()
+		return err
+	}
+	return nil
+}
+
+// reserve занимает токен, при необходимости в долг, и возвращает время до
+// его появления.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !l.updated.IsZero() {
+		l.tokens = min(float64(max(l.limit.Burst, 1)), l.tokens+now.Sub(l.updated).Seconds()*l.limit.RPS)
+	}
+	l.updated = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.limit.RPS * float64(time.Second))
+}
+
+// cancel возвращает токен, который не был использован.
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"usdt/config"
+)
+
+// Retrier выполняет запросы к бирже в очереди её Limiter и повторяет
+// неудачные: сетевые ошибки и ответы 429 и 5xx. Задержка растёт вдвое с
+// каждой попыткой от RetryBackoff до MaxBackoff со случайным разбросом в
+// половину задержки; Retry-After биржи удлиняет её. Повтор, который не
+// успеет до дедлайна ctx, не выполняется.
+type Retrier struct {
+	limiter     *Limiter
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	now         func() time.Time
+	sleep       func(ctx context.Context, d time.Duration) error
+	jitter      func(n int64) int64
+}
+
+func NewRetrier(limiter *Limiter, conf config.Exchange) *Retrier {
+	return &Retrier{
+		limiter:     limiter,
+		maxAttempts: max(conf.MaxAttempts, 1),
+		backoff:     conf.RetryBackoff,
+		maxBackoff:  conf.MaxBackoff,
+		now:         time.Now,
+		sleep:       sleep,
+		jitter:      rand.Int64N,
+	}
+}
+
+// Do выполняет запрос без тела. Ответ с кодом 429 или 5xx после последней
+// попытки возвращается вызывающему как есть; остальные ответы возвращаются
+// сразу. Nil Retrier выполняет запрос один раз без ограничений.
+func (r *Retrier) Do(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	if r == nil {
+		return client.Do(req)
+	}
+	for attempt := 1; ; attempt++ {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil && ctx.Err() != nil {
+			return nil, err
+		}
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= r.maxAttempts {
+			return resp, err
+		}
+
+		delay := r.delay(attempt)
+		reason := err
+		if resp != nil {
+			delay = max(delay, retryAfter(resp.Header.Get("Retry-After"), r.now()))
+			reason = fmt.Errorf("ответ %s", resp.Status)
+			resp.Body.Close()
+		}
+		if deadline, ok := ctx.Deadline(); ok && r.now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("%w: %w", ErrBudgetExceeded, reason)
+		}
+		if err := r.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// delay - задержка перед повтором после attempt неудачных попыток.
+func (r *Retrier) delay(attempt int) time.Duration {
+	delay := r.backoff << (attempt - 1)
+	if delay <= 0 || (r.maxBackoff > 0 && delay > r.maxBackoff) {
+		delay = r.maxBackoff
+	}
+	if half := int64(delay / 2); half > 0 {
+		return time.Duration(half + r.jitter(half))
+	}
+	return delay
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryAfter разбирает Retry-After в секундах или в виде HTTP даты.
+func retryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
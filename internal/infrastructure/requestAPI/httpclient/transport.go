@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"usdt/config"
+)
+
+// NewClient создаёт HTTP клиент для запросов к биржам. Клиент держит пул
+// соединений и должен быть общим для всех бирж: создавать его на каждый
+// запрос - значит каждый раз заново устанавливать TCP и TLS соединение.
+func NewClient(conf config.HTTPClient) (*http.Client, error) {
+	transport, err := NewTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: conf.RequestTimeout}, nil
+}
+
+// NewTransport настраивает пул соединений, таймауты отдельных фаз запроса,
+// прокси и доверенные CA. Прокси задаётся URL со схемой http, https или
+// socks5; без него используются переменные HTTPS_PROXY, HTTP_PROXY и
+// NO_PROXY.
+func NewTransport(conf config.HTTPClient) (*http.Transport, error) {
+	proxy, err := proxyFunc(conf)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := TLSConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: conf.DialTimeout, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConf,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   conf.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: conf.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       conf.IdleConnTimeout,
+		MaxIdleConns:          conf.MaxIdleConns,
+		MaxIdleConnsPerHost:   conf.MaxConnsPerHost,
+		MaxConnsPerHost:       conf.MaxConnsPerHost,
+	}, nil
+}
+
+// proxyFunc выбирает прокси запроса: ProxyURL, а без него - по переменным
+// окружения.
+func proxyFunc(conf config.HTTPClient) (func(*http.Request) (*url.URL, error), error) {
+	if conf.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxyURL, err := url.Parse(conf.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный адрес прокси: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("неподдерживаемая схема прокси: %q", proxyURL.Scheme)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// TLSConfig - настройки TLS соединений с биржами, в том числе WebSocket.
+func TLSConfig(conf config.HTTPClient) (*tls.Config, error) {
+	tlsConf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if conf.CAFile != "" {
+		pool, err := loadCABundle(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.RootCAs = pool
+	}
+	return tlsConf, nil
+}
+
+// loadCABundle добавляет CA из PEM файла к системным: биржа остаётся
+// доступной напрямую, а прокси с подменой сертификатов - через свой CA.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать CA бирж: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("в %s нет сертификатов PEM", path)
+	}
+	return pool, nil
+}
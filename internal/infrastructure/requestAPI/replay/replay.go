@@ -0,0 +1,212 @@
+// Package replay записывает HTTP обмены с биржей в файл и воспроизводит их:
+// так инцидент можно повторить локально, а сервис - запустить без сети на
+// вчерашнем рынке. Файл записи - JSONL, по обмену в строке.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recordedHeaders - заголовки ответа, которые сохраняются в записи; cookies и
+// прочие заголовки в неё не попадают.
+var recordedHeaders = []string{"Content-Type", "Date", "Retry-After"}
+
+// Exchange - записанный обмен: запрос без заголовков и ответ биржи.
+type Exchange struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Recorder - http.RoundTripper, который дописывает в файл каждый полученный
+// ответ. Сетевые ошибки не записываются; ошибка записи файла не мешает
+// запросу.
+type Recorder struct {
+	next   http.RoundTripper
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecorder открывает файл path на дозапись; запросы выполняет next.
+func NewRecorder(next http.RoundTripper, path string, logger *zap.Logger) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл записи обменов: %w", err)
+	}
+	return &Recorder{next: next, logger: logger, now: time.Now, file: file}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{
+		Time:   r.now().UTC(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: resp.StatusCode,
+		Header: make(http.Header),
+		Body:   string(body),
+	}
+	for _, key := range recordedHeaders {
+		if value := resp.Header.Values(key); len(value) > 0 {
+			exchange.Header[key] = value
+		}
+	}
+	line, err := json.Marshal(exchange)
+	if err == nil {
+		r.mu.Lock()
+		_, err = r.file.Write(append(line, '\n'))
+		r.mu.Unlock()
+	}
+	if err != nil {
+		r.logger.Error("Не удалось записать обмен с биржей:", zap.String("url", exchange.URL), zap.Error(err))
+	}
+	return resp, nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Load читает записанные обмены из файла path.
+func Load(path string) ([]Exchange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть запись обменов: %w", err)
+	}
+	defer file.Close()
+	var exchanges []Exchange
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("строка %d записи обменов: %w", line, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения записи обменов: %w", err)
+	}
+	return exchanges, nil
+}
+
+// Transport - http.RoundTripper, который отвечает записанными обменами. Время
+// записи идёт от from со скоростью speed относительно часов сервиса; на
+// запрос возвращается последний ответ на тот же путь и параметры (то есть
+// тот же рынок), записанный не позже текущего времени записи. Хост запроса
+// не учитывается. Без подходящей записи отвечает 404.
+type Transport struct {
+	exchanges map[string][]Exchange
+	from      time.Time
+	speed     float64
+	started   time.Time
+	now       func() time.Time
+}
+
+// NewTransport начинает воспроизведение; нулевой from - время первого
+// обмена, speed не больше нуля считается за 1.
+func NewTransport(exchanges []Exchange, from time.Time, speed float64) *Transport {
+	t := &Transport{
+		exchanges: make(map[string][]Exchange),
+		from:      from,
+		speed:     speed,
+		now:       time.Now,
+	}
+	if t.speed <= 0 {
+		t.speed = 1
+	}
+	for _, exchange := range exchanges {
+		u, err := url.Parse(exchange.URL)
+		if err != nil {
+			continue
+		}
+		key := requestKey(exchange.Method, u)
+		t.exchanges[key] = append(t.exchanges[key], exchange)
+		if from.IsZero() && (t.from.IsZero() || exchange.Time.Before(t.from)) {
+			t.from = exchange.Time
+		}
+	}
+	for _, list := range t.exchanges {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Time.Before(list[j].Time) })
+	}
+	t.started = t.now()
+	return t
+}
+
+// Now - текущее время записи.
+func (t *Transport) Now() time.Time {
+	elapsed := float64(t.now().Sub(t.started)) * t.speed
+	return t.from.Add(time.Duration(elapsed))
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	at := t.Now()
+	list := t.exchanges[requestKey(req.Method, req.URL)]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Time.After(at) }) - 1
+	if i < 0 {
+		return response(req, http.StatusNotFound, nil, fmt.Sprintf("нет записи %s на %s", req.URL.RequestURI(), at.Format(time.RFC3339))), nil
+	}
+	return response(req, list[i].Status, list[i].Header, list[i].Body), nil
+}
+
+func response(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// requestKey - метод, путь и параметры запроса в каноническом порядке.
+func requestKey(method string, u *url.URL) string {
+	if method == "" {
+		method = http.MethodGet
+	}
+	return method + " " + u.Path + "?" + u.Query().Encode()
+}
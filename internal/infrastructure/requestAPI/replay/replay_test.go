@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("market") == "usdtusd" {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"market":"` + r.URL.Query().Get("market") + `"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "exchanges.jsonl")
+	recorder, err := NewRecorder(http.DefaultTransport, path, zap.NewNop())
+	require.NoError(t, err)
+	clock := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return clock }
+	recording := &http.Client{Transport: recorder}
+
+	code, body := get(t, recording, server.URL+"/api/v2/depth?market=usdtrub")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"market":"usdtrub"}`, body, "запись не мешает читать ответ")
+	clock = clock.Add(10 * time.Second)
+	get(t, recording, server.URL+"/api/v2/depth?market=usdtusd")
+	require.NoError(t, recorder.Close())
+
+	exchanges, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 2)
+	assert.Equal(t, http.MethodGet, exchanges[0].Method)
+	assert.Empty(t, exchanges[0].Header.Get("Set-Cookie"), "cookies не записываются")
+	assert.Equal(t, "3", exchanges[1].Header.Get("Retry-After"))
+
+	transport := NewTransport(exchanges, time.Time{}, 60)
+	now := time.Now()
+	transport.started, transport.now = now, func() time.Time { return now }
+	replaying := &http.Client{Transport: transport}
+
+	code, body = get(t, replaying, "http://offline/api/v2/depth?market=usdtrub")
+	assert.Equal(t, http.StatusOK, code, "хост запроса не учитывается")
+	assert.Equal(t, `{"market":"usdtrub"}`, body)
+	code, _ = get(t, replaying, "http://offline/api/v2/depth?market=usdtusd")
+	assert.Equal(t, http.StatusNotFound, code, "ответ ещё не записан к этому времени")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, exchanges[0].Time.Add(time.Minute), transport.Now(), "время записи идёт со скоростью speed")
+	resp, err := replaying.Get("http://offline/api/v2/depth?market=usdtusd")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("Retry-After"))
+
+	code, _ = get(t, replaying, "http://offline/api/v2/depth?market=usdteur")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Load(filepath.Join(dir, "missing.jsonl"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "broken.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"status\":200}\n\nне JSON\n"), 0o600))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "строка 3")
+}
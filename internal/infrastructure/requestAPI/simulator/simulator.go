@@ -0,0 +1,123 @@
+// Package simulator - синтетическая биржа для нагрузочных и демонстрационных
+// стендов. Цена каждой пары - случайное блуждание, стаканы строятся вокруг
+// неё с заданным спредом и глубиной; при одном и том же seed
+// последовательность стаканов пары повторяется. Сбои биржи - задержки,
+// ошибки и перевёрнутые стаканы - включаются вероятностями.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// Name - поставщик, под которым сохраняются синтетические курсы.
+const Name = "simulator"
+
+// ErrSimulatedFault - ошибка биржи, внесённая симуляцией.
+var ErrSimulatedFault = errors.New("симулированная ошибка биржи")
+
+type pair struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+	mid float64
+}
+
+// Market - биржа, которая генерирует стаканы вместо запросов. Каждый вызов
+// GetOrderBook делает один шаг блуждания пары, поэтому последовательность
+// стаканов зависит только от seed и числа вызовов, но не от времени.
+type Market struct {
+	conf  config.Simulation
+	pairs map[string]*pair
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewMarket(conf config.Simulation) *Market {
+	m := &Market{
+		conf:  conf,
+		pairs: make(map[string]*pair, len(conf.Prices)),
+		now:   time.Now,
+		sleep: sleep,
+	}
+	for currency, price := range conf.Prices {
+		// У каждой пары свой генератор: порядок запросов разных пар не влияет
+		// на их цены.
+		h := fnv.New64a()
+		h.Write([]byte(currency))
+		m.pairs[currency] = &pair{rng: rand.New(rand.NewPCG(uint64(conf.Seed), h.Sum64())), mid: price}
+	}
+	return m
+}
+
+// GetOrderBook делает шаг цены пары и возвращает стакан вокруг неё.
+func (m *Market) GetOrderBook(ctx context.Context, currency string) (models.OrderBook, error) {
+	p, ok := m.pairs[currency]
+	if !ok {
+		return models.OrderBook{}, fmt.Errorf("%w: рынок не поддерживается", models.ErrRateNotFound)
+	}
+	p.mu.Lock()
+	latency := time.Duration(p.rng.Float64() * float64(m.conf.Latency))
+	fail := p.rng.Float64() < m.conf.ErrorRate
+	crossed := p.rng.Float64() < m.conf.CrossedRate
+	book := m.step(p)
+	p.mu.Unlock()
+
+	if latency > 0 {
+		if err := m.sleep(ctx, latency); err != nil {
+			return models.OrderBook{}, err
+		}
+	}
+	if fail {
+		return models.OrderBook{}, ErrSimulatedFault
+	}
+	if crossed {
+		// Лучший bid выше лучшего ask: так выглядит стакан сбойной биржи.
+		book.Asks, book.Bids = book.Bids, book.Asks
+	}
+	return book, nil
+}
+
+// step сдвигает цену логнормальным шагом и строит стакан: уровни отстоят
+// друг от друга на половину спреда, объёмы случайны вокруг LevelVolume.
+func (m *Market) step(p *pair) models.OrderBook {
+	p.mid *= math.Exp(m.conf.Volatility * p.rng.NormFloat64())
+	half := p.mid * m.conf.SpreadPercent / 200
+	depth := max(m.conf.Depth, 1)
+	book := models.OrderBook{
+		Timestamp: m.now(),
+		Asks:      make([]models.BookLevel, 0, depth),
+		Bids:      make([]models.BookLevel, 0, depth),
+	}
+	for i := 0; i < depth; i++ {
+		offset := half * float64(i+1)
+		book.Asks = append(book.Asks, models.BookLevel{Price: p.mid + offset, Volume: m.volume(p)})
+		if bid := p.mid - offset; bid > 0 {
+			book.Bids = append(book.Bids, models.BookLevel{Price: bid, Volume: m.volume(p)})
+		}
+	}
+	return book
+}
+
+func (m *Market) volume(p *pair) float64 {
+	return m.conf.LevelVolume * (0.5 + p.rng.Float64())
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
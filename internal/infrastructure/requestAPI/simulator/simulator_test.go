@@ -0,0 +1,107 @@
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+func testSimulation() config.Simulation {
+	return config.Simulation{
+		Seed:          42,
+		Prices:        map[string]float64{"RUB": 95, "USD": 1},
+		Volatility:    0.01,
+		SpreadPercent: 0.2,
+		Depth:         5,
+		LevelVolume:   100,
+	}
+}
+
+func books(t *testing.T, m *Market, currency string, n int) []models.OrderBook {
+	t.Helper()
+	var result []models.OrderBook
+	for i := 0; i < n; i++ {
+		book, err := m.GetOrderBook(context.Background(), currency)
+		require.NoError(t, err)
+		book.Timestamp = time.Time{}
+		result = append(result, book)
+	}
+	return result
+}
+
+func TestMarket_Reproducible(t *testing.T) {
+	first := NewMarket(testSimulation())
+	second := NewMarket(testSimulation())
+	// Запросы другой пары между шагами не меняют последовательность RUB.
+	books(t, second, "USD", 3)
+	assert.Equal(t, books(t, first, "RUB", 10), books(t, second, "RUB", 10))
+
+	other := testSimulation()
+	other.Seed = 7
+	assert.NotEqual(t, books(t, NewMarket(testSimulation()), "RUB", 3), books(t, NewMarket(other), "RUB", 3))
+
+	_, err := first.GetOrderBook(context.Background(), "XXX")
+	assert.Error(t, err)
+}
+
+func TestMarket_Book(t *testing.T) {
+	m := NewMarket(testSimulation())
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	prev := 95.0
+	for i := 0; i < 100; i++ {
+		book, err := m.GetOrderBook(context.Background(), "RUB")
+		require.NoError(t, err)
+		assert.Equal(t, now, book.Timestamp)
+		require.Len(t, book.Asks, 5)
+		require.Len(t, book.Bids, 5)
+		mid := (book.Asks[0].Price + book.Bids[0].Price) / 2
+		assert.InDelta(t, 0.2, (book.Asks[0].Price-book.Bids[0].Price)/mid*100, 1e-9, "спред в процентах от цены")
+		assert.InDelta(t, 0, mid/prev-1, 0.06, "шаг цены соответствует волатильности")
+		prev = mid
+		for j := 1; j < 5; j++ {
+			assert.Greater(t, book.Asks[j].Price, book.Asks[j-1].Price)
+			assert.Less(t, book.Bids[j].Price, book.Bids[j-1].Price)
+			assert.InDelta(t, 100, book.Asks[j].Volume, 50)
+		}
+	}
+}
+
+func TestMarket_Faults(t *testing.T) {
+	conf := testSimulation()
+	conf.ErrorRate = 1
+	_, err := NewMarket(conf).GetOrderBook(context.Background(), "RUB")
+	assert.ErrorIs(t, err, ErrSimulatedFault)
+
+	conf = testSimulation()
+	conf.CrossedRate = 1
+	book, err := NewMarket(conf).GetOrderBook(context.Background(), "RUB")
+	require.NoError(t, err)
+	assert.Greater(t, book.Bids[0].Price, book.Asks[0].Price, "перевёрнутый стакан")
+
+	conf = testSimulation()
+	conf.Latency = time.Second
+	m := NewMarket(conf)
+	var slept []time.Duration
+	m.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	books(t, m, "RUB", 20)
+	require.Len(t, slept, 20)
+	for _, d := range slept {
+		assert.LessOrEqual(t, d, time.Second)
+	}
+
+	m = NewMarket(conf)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.GetOrderBook(ctx, "RUB")
+	assert.ErrorIs(t, err, context.Canceled, "задержка прерывается отменой запроса")
+}
@@ -0,0 +1,87 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloader хранит значение, загруженное из файлов, и загружает его заново,
+// когда меняется время изменения или размер одного из файлов. Если новые
+// файлы не загружаются (например, сертификат уже заменён, а ключ ещё нет),
+// остаётся прежнее значение, а загрузка повторяется при следующем обращении.
+type reloader[T any] struct {
+	files []string
+	load  func() (T, error)
+
+	mu     sync.Mutex
+	value  T
+	stamps []fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newReloader[T any](load func() (T, error), files ...string) (*reloader[T], error) {
+	r := &reloader[T]{files: files, load: load}
+	stamps, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	if r.value, err = load(); err != nil {
+		return nil, err
+	}
+	r.stamps = stamps
+	return r, nil
+}
+
+func newKeyPairReloader(certFile, keyFile string) (*reloader[*tls.Certificate], error) {
+	return newReloader(func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить сертификат: %w", err)
+		}
+		return &cert, nil
+	}, certFile, keyFile)
+}
+
+// get проверяет файлы при каждом вызове: обращения происходят на TLS
+// рукопожатиях, и stat несравнимо дешевле самого рукопожатия.
+func (r *reloader[T]) get() T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stamps, err := r.stat()
+	if err != nil || !r.changed(stamps) {
+		return r.value
+	}
+	if value, err := r.load(); err == nil {
+		r.value = value
+		r.stamps = stamps
+	}
+	return r.value
+}
+
+func (r *reloader[T]) stat() ([]fileStamp, error) {
+	stamps := make([]fileStamp, len(r.files))
+	for i, file := range r.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать %s: %w", file, err)
+		}
+		stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+func (r *reloader[T]) changed(stamps []fileStamp) bool {
+	for i := range stamps {
+		if !stamps[i].modTime.Equal(r.stamps[i].modTime) || stamps[i].size != r.stamps[i].size {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Package tlsconfig собирает TLS конфигурации gRPC сервера и его клиентов.
+// Сертификаты и CA перечитываются при изменении файлов, поэтому их можно
+// обновлять без перезапуска сервиса.
+package tlsconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"usdt/config"
+)
+
+// ErrOwnCertificate - сервер на локальном порту предъявил не собственный сертификат.
+var ErrOwnCertificate = errors.New("сертификат сервера не совпадает с собственным")
+
+// Server возвращает конфигурацию gRPC сервера. С conf.ClientCAFile клиент
+// обязан предъявить сертификат, подписанный одним из CA этого файла.
+func Server(conf config.TLS) (*tls.Config, error) {
+	minVersion, err := parseVersion(conf.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := newKeyPairReloader(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	base := &tls.Config{
+		MinVersion: minVersion,
+		NextProtos: []string{"h2"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cert.get(), nil
+		},
+	}
+	if conf.ClientCAFile == "" {
+		return base, nil
+	}
+
+	clientCAs, err := newReloader(func() (*x509.CertPool, error) { return loadPool(conf.ClientCAFile) }, conf.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	base.ClientAuth = tls.RequireAndVerifyClientCert
+	base.ClientCAs = clientCAs.get()
+	server := base.Clone()
+	// Набор CA подставляется на каждое подключение, чтобы подхватить обновлённый файл.
+	server.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.ClientCAs = clientCAs.get()
+		return c, nil
+	}
+	return server, nil
+}
+
+// Loopback возвращает конфигурацию клиента, которым процесс обращается к
+// собственному gRPC серверу, например из REST шлюза. Сервер проверяется по
+// совпадению с его текущим сертификатом, поэтому имя хоста и CA не важны.
+func Loopback(conf config.TLS) (*tls.Config, error) {
+	minVersion, err := parseVersion(conf.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	own, err := newKeyPairReloader(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	c := &tls.Config{
+		MinVersion: minVersion,
+		// Цепочка не проверяется: сертификат сверяется с собственным в VerifyPeerCertificate.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], own.get().Certificate[0]) {
+				return ErrOwnCertificate
+			}
+			return nil
+		},
+	}
+	if conf.ClientCAFile == "" {
+		return c, nil
+	}
+
+	if conf.GatewayCertFile == "" || conf.GatewayKeyFile == "" {
+		return nil, fmt.Errorf("при проверке клиентских сертификатов нужны %s и %s", config.TLSGatewayCert, config.TLSGatewayKey)
+	}
+	client, err := newKeyPairReloader(conf.GatewayCertFile, conf.GatewayKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return client.get(), nil
+	}
+	return c, nil
+}
+
+// Client возвращает конфигурацию внешнего клиента. Без caFile сервер
+// проверяется по системным CA; certFile и keyFile нужны для взаимного TLS.
+func Client(caFile, certFile, keyFile, serverName string) (*tls.Config, error) {
+	c := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName}
+	if caFile != "" {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		c.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить клиентский сертификат: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+// Identity возвращает имя клиента из проверенного клиентского сертификата:
+// CommonName субъекта, а без него - субъект целиком. ok == false, если
+// клиент подключился без проверенного сертификата.
+func Identity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	subject := info.State.VerifiedChains[0][0].Subject
+	if subject.CommonName != "" {
+		return subject.CommonName, true
+	}
+	return subject.String(), true
+}
+
+func parseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("неизвестная минимальная версия TLS %q, допустимы 1.2 и 1.3", version)
+	}
+}
+
+func loadPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("в %s нет сертификатов PEM", file)
+	}
+	return pool, nil
+}
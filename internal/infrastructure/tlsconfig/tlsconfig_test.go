@@ -0,0 +1,291 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"usdt/config"
+	"usdt/internal/proto/usdt_proto"
+)
+
+// testCA - самоподписанный CA, которым тесты выпускают сертификаты.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+var serial int64
+
+func newTestCA(t *testing.T, dir string) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "usdt test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	file := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return testCA{cert: cert, key: key, file: file}
+}
+
+// issue выпускает сертификат с CommonName name и пишет его в name.pem и name-key.pem.
+func (ca testCA) issue(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// identityServer возвращает в HealthCheck имя клиента из его сертификата.
+type identityServer struct {
+	usdt_proto.UnimplementedAuthServiceServer
+}
+
+func (identityServer) HealthCheck(ctx context.Context, _ *usdt_proto.HealthCheckRequest) (*usdt_proto.HealthCheckResponse, error) {
+	identity, ok := Identity(ctx)
+	if !ok {
+		identity = "anonymous"
+	}
+	return &usdt_proto.HealthCheckResponse{Status: identity}, nil
+}
+
+func serveTLS(t *testing.T, conf config.TLS) string {
+	t.Helper()
+	tlsConf, err := Server(conf)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConf)))
+	usdt_proto.RegisterAuthServiceServer(server, identityServer{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func healthCheck(t *testing.T, addr string, tlsConf *tls.Config) (string, error) {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := usdt_proto.NewAuthServiceClient(conn).HealthCheck(ctx, &usdt_proto.HealthCheckRequest{})
+	if err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+
+	t.Run("TLS", func(t *testing.T) {
+		addr := serveTLS(t, config.TLS{CertFile: certFile, KeyFile: keyFile})
+		client, err := Client(ca.file, "", "", "localhost")
+		require.NoError(t, err)
+		identity, err := healthCheck(t, addr, client)
+		require.NoError(t, err)
+		assert.Equal(t, "anonymous", identity)
+	})
+
+	t.Run("UntrustedServer", func(t *testing.T) {
+		addr := serveTLS(t, config.TLS{CertFile: certFile, KeyFile: keyFile})
+		other := newTestCA(t, t.TempDir())
+		client, err := Client(other.file, "", "", "localhost")
+		require.NoError(t, err)
+		_, err = healthCheck(t, addr, client)
+		assert.Error(t, err)
+	})
+
+	t.Run("MutualTLS", func(t *testing.T) {
+		addr := serveTLS(t, config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: ca.file})
+		clientCert, clientKey := ca.issue(t, dir, "client-1")
+		client, err := Client(ca.file, clientCert, clientKey, "localhost")
+		require.NoError(t, err)
+		identity, err := healthCheck(t, addr, client)
+		require.NoError(t, err)
+		assert.Equal(t, "client-1", identity)
+
+		anonymous, err := Client(ca.file, "", "", "localhost")
+		require.NoError(t, err)
+		_, err = healthCheck(t, addr, anonymous)
+		assert.Error(t, err, "без клиентского сертификата подключение отклоняется")
+
+		foreign := newTestCA(t, t.TempDir())
+		foreignCert, foreignKey := foreign.issue(t, t.TempDir(), "client-2")
+		stranger, err := Client(ca.file, foreignCert, foreignKey, "localhost")
+		require.NoError(t, err)
+		_, err = healthCheck(t, addr, stranger)
+		assert.Error(t, err, "сертификат чужого CA отклоняется")
+	})
+
+	t.Run("MinVersion", func(t *testing.T) {
+		tlsConf, err := Server(config.TLS{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+		require.NoError(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConf.MinVersion)
+
+		_, err = Server(config.TLS{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"})
+		assert.Error(t, err)
+	})
+
+	t.Run("MissingFiles", func(t *testing.T) {
+		_, err := Server(config.TLS{CertFile: filepath.Join(dir, "none.pem"), KeyFile: keyFile})
+		assert.Error(t, err)
+	})
+}
+
+// touch сдвигает время изменения файлов, чтобы замена была заметна
+// независимо от точности времени файловой системы.
+func touch(t *testing.T, files ...string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	for _, file := range files {
+		require.NoError(t, os.Chtimes(file, later, later))
+	}
+}
+
+func peerSerial(t *testing.T, addr string, tlsConf *tls.Config) *big.Int {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, tlsConf)
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber
+}
+
+func TestServer_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+	addr := serveTLS(t, config.TLS{CertFile: certFile, KeyFile: keyFile})
+	client, err := Client(ca.file, "", "", "localhost")
+	require.NoError(t, err)
+	client.NextProtos = []string{"h2"}
+	first := peerSerial(t, addr, client)
+
+	t.Run("Certificate", func(t *testing.T) {
+		ca.issue(t, dir, "server")
+		touch(t, certFile, keyFile)
+		assert.NotEqual(t, first, peerSerial(t, addr, client))
+	})
+
+	t.Run("BrokenFileKeepsPrevious", func(t *testing.T) {
+		current := peerSerial(t, addr, client)
+		require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+		touch(t, keyFile)
+		assert.Equal(t, current, peerSerial(t, addr, client))
+	})
+}
+
+func TestServer_ReloadClientCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+	clientCAFile := filepath.Join(dir, "clients.pem")
+	oldCA := newTestCA(t, t.TempDir())
+	data, err := os.ReadFile(oldCA.file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(clientCAFile, data, 0o600))
+	addr := serveTLS(t, config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: clientCAFile})
+
+	newCA := newTestCA(t, t.TempDir())
+	clientCert, clientKey := newCA.issue(t, t.TempDir(), "client-1")
+	client, err := Client(ca.file, clientCert, clientKey, "localhost")
+	require.NoError(t, err)
+	_, err = healthCheck(t, addr, client)
+	require.Error(t, err, "CA клиента ещё не доверен")
+
+	data, err = os.ReadFile(newCA.file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(clientCAFile, data, 0o600))
+	touch(t, clientCAFile)
+	identity, err := healthCheck(t, addr, client)
+	require.NoError(t, err)
+	assert.Equal(t, "client-1", identity)
+}
+
+func TestLoopback(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+
+	t.Run("OwnCertificate", func(t *testing.T) {
+		addr := serveTLS(t, config.TLS{CertFile: certFile, KeyFile: keyFile})
+		loopback, err := Loopback(config.TLS{CertFile: certFile, KeyFile: keyFile})
+		require.NoError(t, err)
+		_, err = healthCheck(t, addr, loopback)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ForeignServer", func(t *testing.T) {
+		otherDir := t.TempDir()
+		otherCert, otherKey := ca.issue(t, otherDir, "server")
+		addr := serveTLS(t, config.TLS{CertFile: otherCert, KeyFile: otherKey})
+		loopback, err := Loopback(config.TLS{CertFile: certFile, KeyFile: keyFile})
+		require.NoError(t, err)
+		_, err = healthCheck(t, addr, loopback)
+		assert.Error(t, err)
+	})
+
+	t.Run("MutualTLS", func(t *testing.T) {
+		conf := config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: ca.file}
+		addr := serveTLS(t, conf)
+		_, err := Loopback(conf)
+		assert.Error(t, err, "без сертификата шлюза подключиться нельзя")
+
+		conf.GatewayCertFile, conf.GatewayKeyFile = ca.issue(t, dir, "gateway")
+		loopback, err := Loopback(conf)
+		require.NoError(t, err)
+		identity, err := healthCheck(t, addr, loopback)
+		require.NoError(t, err)
+		assert.Equal(t, "gateway", identity)
+	})
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Usdt-Signature"
+	TimestampHeader = "X-Usdt-Timestamp"
+)
+
+// ErrForbiddenAddress - получатель вебхука находится во внутренней сети.
+var ErrForbiddenAddress = errors.New("адрес получателя вебхука во внутренней сети")
+
+// Sender отправляет JSON-вебхуки, подписанные HMAC-SHA256.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender без allowPrivate не соединяется с loopback, link-local и частными
+// адресами. Проверка идёт при установке соединения, поэтому её не обойти
+// ни DNS-именем, ни редиректом на внутренний адрес.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: denyPrivate}
+		transport.DialContext = dialer.DialContext
+		// Через прокси адрес получателя разрешает уже прокси, и проверка не сработала бы.
+		transport.Proxy = nil
+	}
+	return &Sender{
+		client: &http.Client{Timeout: timeout, Transport: transport},
+		now:    time.Now,
+	}
+}
+
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return nil
+}
+
+// IsPublic сообщает, что адрес не относится к loopback, link-local,
+// частным, multicast и неуказанным адресам.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() && !addr.IsUnspecified()
+}
+
+// Send возвращает код ответа получателя; любой ответ кроме 2xx считается ошибкой.
+func (s *Sender) Send(ctx context.Context, url, secret string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("не удалось сформировать запрос вебхука: %w", err)
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось выполнить запрос вебхука: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("неправильный статус ответа вебхука: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign считает подпись "sha256=<hex>" от строки "<timestamp>.<payload>".
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись, полученную от Send.
+func Verify(secret, timestamp string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
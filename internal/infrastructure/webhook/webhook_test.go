@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		expectErr      bool
+	}{
+		{name: "Delivered", mockStatusCode: http.StatusNoContent, expectErr: false},
+		{name: "Receiver error", mockStatusCode: http.StatusInternalServerError, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(`{"rule_id":1}`)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if !Verify("secret", r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader)) {
+					t.Errorf("неверная подпись вебхука")
+				}
+				w.WriteHeader(tt.mockStatusCode)
+			}))
+			defer server.Close()
+
+			sender := NewSender(time.Second, true)
+			code, err := sender.Send(context.Background(), server.URL, "secret", payload)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ожидали ошибку: %v, получили: %v", tt.expectErr, err)
+			}
+			if code != tt.mockStatusCode {
+				t.Errorf("ожидали статус: %d, получили: %d", tt.mockStatusCode, code)
+			}
+		})
+	}
+}
+
+func TestSend_PrivateAddress(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second, false)
+	_, err := sender.Send(context.Background(), server.URL, "secret", []byte(`{}`))
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("ожидали ErrForbiddenAddress, получили: %v", err)
+	}
+	if called {
+		t.Errorf("запрос не должен был дойти до внутреннего адреса")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signature := Sign("secret", "1698405000", []byte("payload"))
+	if !Verify("secret", "1698405000", []byte("payload"), signature) {
+		t.Errorf("ожидали валидную подпись")
+	}
+	if Verify("other", "1698405000", []byte("payload"), signature) {
+		t.Errorf("подпись с другим секретом не должна проходить проверку")
+	}
+	if Verify("secret", "1698405001", []byte("payload"), signature) {
+		t.Errorf("подпись с другим временем не должна проходить проверку")
+	}
+}
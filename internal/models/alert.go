@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Условия срабатывания правил оповещения.
+const (
+	AlertBidAbove    = "bid_above"
+	AlertBidBelow    = "bid_below"
+	AlertAskAbove    = "ask_above"
+	AlertAskBelow    = "ask_below"
+	AlertSpreadAbove = "spread_above_percent"
+	AlertChangeAbove = "change_above_percent"
+)
+
+var (
+	ErrAlertRuleNotFound = errors.New("правило оповещения не найдено")
+	ErrInvalidAlertRule  = errors.New("некорректное правило оповещения")
+)
+
+// AlertRule - пороговое правило, при срабатывании которого вызывается вебхук.
+// Owner - Principal.ID создавшего его клиента.
+type AlertRule struct {
+	ID            int64     `json:"id" gorm:"primaryKey"`
+	Owner         string    `json:"owner"`
+	Pair          string    `json:"pair"`
+	Condition     string    `json:"condition"`
+	Threshold     float64   `json:"threshold"`
+	WindowSeconds int64     `json:"window_seconds"`
+	WebhookURL    string    `json:"webhook_url"`
+	Secret        string    `json:"-"`
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (r AlertRule) Window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+// AlertDelivery - запись журнала доставки вебхука, по одной на попытку.
+type AlertDelivery struct {
+	ID         int64     `json:"id" gorm:"primaryKey"`
+	RuleID     int64     `json:"rule_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error"`
+	Delivered  bool      `json:"delivered"`
+	Payload    string    `json:"payload"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Права клиентов API. ScopeAdmin включает все остальные.
+const (
+	ScopeRatesRead  = "rates:read"
+	ScopeRatesWrite = "rates:write"
+	ScopeQuotes     = "quotes"
+	ScopeAlerts     = "alerts"
+	ScopeAdmin      = "admin"
+)
+
+// Scopes - все известные права.
+var Scopes = []string{ScopeRatesRead, ScopeRatesWrite, ScopeQuotes, ScopeAlerts, ScopeAdmin}
+
+var (
+	ErrAPIKeyNotFound   = errors.New("API ключ не найден")
+	ErrInvalidAPIKey    = errors.New("некорректный API ключ")
+	ErrUnauthenticated  = errors.New("клиент не аутентифицирован")
+	ErrPermissionDenied = errors.New("недостаточно прав")
+)
+
+// APIKey - ключ клиента API. В базе хранится только хеш ключа; сам ключ
+// показывается один раз при выпуске, а Prefix позволяет узнать его в списке.
+// Пустой Pairs разрешает все пары.
+type APIKey struct {
+	ID        int64      `json:"id" gorm:"primaryKey"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes" gorm:"serializer:json"`
+	Pairs     []string   `json:"pairs" gorm:"serializer:json"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Active сообщает, можно ли пользоваться ключом в момент now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Principal - клиент, от имени которого выполняется запрос: владелец API
+// ключа или субъект JWT. Пустой Pairs разрешает все пары.
+type Principal struct {
+	Name   string
+	KeyID  int64
+	Scopes []string
+	Pairs  []string
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal возвращает контекст запроса клиента principal.
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает клиента запроса. Без клиента - запросы
+// внутренних модулей или сервер без аутентификации - ok ложно, и
+// ограничения клиентов не применяются.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// ID - идентификатор клиента: key:<id> для API ключа, jwt:<субъект> для JWT.
+func (p Principal) ID() string {
+	if p.KeyID != 0 {
+		return fmt.Sprintf("key:%d", p.KeyID)
+	}
+	return "jwt:" + p.Name
+}
+
+// Owns сообщает, доступна ли клиенту запись владельца owner. Администратору
+// доступны все записи, в том числе без владельца.
+func (p Principal) Owns(owner string) bool {
+	return p.HasScope(ScopeAdmin) || (owner != "" && owner == p.ID())
+}
+
+// HasScope сообщает, есть ли у клиента право scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, ScopeAdmin) || slices.Contains(p.Scopes, scope)
+}
+
+// AllowsPair сообщает, доступна ли клиенту пара; пустая пара означает все
+// пары и доступна только клиентам без ограничения по парам.
+func (p Principal) AllowsPair(pair string) bool {
+	if len(p.Pairs) == 0 {
+		return true
+	}
+	return pair != "" && slices.Contains(p.Pairs, pair)
+}
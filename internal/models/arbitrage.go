@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ArbitrageOpportunity - возможность купить на одной бирже и продать на другой
+// с прибылью после комиссий. Цены средневзвешенные по объёму Volume, который
+// ограничен глубиной обоих стаканов.
+type ArbitrageOpportunity struct {
+	ID            int64     `json:"id" gorm:"primaryKey"`
+	Pair          string    `json:"pair"`
+	BuyVenue      string    `json:"buy_venue"`
+	SellVenue     string    `json:"sell_venue"`
+	BuyPrice      float64   `json:"buy_price"`
+	SellPrice     float64   `json:"sell_price"`
+	Volume        float64   `json:"volume"`
+	Profit        float64   `json:"profit"`
+	ProfitPercent float64   `json:"profit_percent"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// RateCursor - позиция в выгрузке курсов, упорядоченной по времени биржи и
+// ID. Нулевой курсор означает начало периода.
+type RateCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+func (c RateCursor) IsZero() bool {
+	return c.ID == 0 && c.Timestamp.IsZero()
+}
+
+// CursorAfter возвращает курсор, указывающий за курс rate.
+func CursorAfter(rate CurrencyRate) RateCursor {
+	return RateCursor{Timestamp: rate.Timestamp, ID: rate.ID}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "errors"
+
+// RejectInvalidRecord - строка файла импорта не разобрана.
+const RejectInvalidRecord = "invalid_record"
+
+// SourceImport - поставщик импортированных курсов, если в файле он не указан.
+const SourceImport = "import"
+
+// ErrInvalidRecord - строка файла импорта не разобрана; импорт её пропускает
+// и продолжает со следующей.
+var ErrInvalidRecord = errors.New("некорректная строка файла")
+
+// ImportProgress - ход импорта истории курсов. Processed - число уже
+// обработанных строк файла, с которых продолжается прерванный импорт;
+// Rejected - число отклонённых строк по причинам.
+type ImportProgress struct {
+	Processed  int64            `json:"processed"`
+	Imported   int64            `json:"imported"`
+	Duplicates int64            `json:"duplicates"`
+	Rejected   map[string]int64 `json:"rejected,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// BookLevel - уровень стакана: цена и объём в USDT.
+type BookLevel struct {
+	Price  float64 `json:"price"`
+	Volume float64 `json:"volume"`
+}
+
+// OrderBook - стакан биржи; лучшие цены стоят первыми.
+type OrderBook struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Asks      []BookLevel `json:"asks"`
+	Bids      []BookLevel `json:"bids"`
+}
+
+// MarketStats - производные метрики стакана для одного снимка.
+// Глубина - суммарный объём заявок в пределах заданного отклонения от
+// средней цены, дисбаланс считается по полосе ±1%.
+type MarketStats struct {
+	ID            int64     `json:"id" gorm:"primaryKey"`
+	Pair          string    `json:"pair"`
+	Timestamp     time.Time `json:"timestamp"`
+	AskPrice      float64   `json:"ask_price"`
+	BidPrice      float64   `json:"bid_price"`
+	MidPrice      float64   `json:"mid_price"`
+	Spread        float64   `json:"spread"`
+	SpreadPercent float64   `json:"spread_percent"`
+	AskDepth05    float64   `json:"ask_depth_05" gorm:"column:ask_depth_05"`
+	BidDepth05    float64   `json:"bid_depth_05" gorm:"column:bid_depth_05"`
+	AskDepth1     float64   `json:"ask_depth_1" gorm:"column:ask_depth_1"`
+	BidDepth1     float64   `json:"bid_depth_1" gorm:"column:bid_depth_1"`
+	AskDepth2     float64   `json:"ask_depth_2" gorm:"column:ask_depth_2"`
+	BidDepth2     float64   `json:"bid_depth_2" gorm:"column:bid_depth_2"`
+	Imbalance     float64   `json:"imbalance"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (MarketStats) TableName() string {
+	return "market_stats"
+}
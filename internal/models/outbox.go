@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Типы событий об изменении курсов.
+const (
+	EventRateCreated = "rate.created"
+	EventRateUpdated = "rate.updated"
+	EventRateDeleted = "rate.deleted"
+)
+
+// OutboxEvent - событие, записанное в одной транзакции с изменением курса и
+// ожидающее публикации. Payload - курс в JSON. IdempotencyKey не меняется
+// между повторными публикациями, по нему получатель отбрасывает дубли.
+type OutboxEvent struct {
+	ID             int64      `json:"id" gorm:"primaryKey"`
+	EventType      string     `json:"event_type"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Payload        string     `json:"payload"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	PublishedAt    *time.Time `json:"published_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
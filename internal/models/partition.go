@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Partition - месячная секция таблицы currency_rates с курсами из [From, To).
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Причины отклонения снимка курса.
+const (
+	RejectZeroPrice   = "zero_price"
+	RejectCrossedBook = "crossed_book"
+	RejectStale       = "stale_timestamp"
+	RejectFuture      = "future_timestamp"
+	RejectOutOfOrder  = "out_of_order_timestamp"
+	RejectPriceJump   = "price_jump"
+)
+
+var ErrSnapshotRejected = errors.New("снимок курса отклонён проверкой")
+
+// QuarantinedRate - снимок курса, не прошедший проверку, с причиной отклонения.
+type QuarantinedRate struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
+	Pair      string    `json:"pair"`
+	AskPrice  float64   `json:"ask_price"`
+	BidPrice  float64   `json:"bid_price"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+import "errors"
+
+var (
+	ErrRateLimited   = errors.New("слишком много запросов")
+	ErrQuotaExceeded = errors.New("исчерпана суточная квота запросов")
+)
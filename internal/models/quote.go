@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrQuoteNotFound = errors.New("котировка не найдена")
+	ErrQuoteExpired  = errors.New("срок действия котировки истёк")
+	ErrQuoteRedeemed = errors.New("котировка уже использована")
+	ErrInvalidQuote  = errors.New("некорректный запрос котировки")
+)
+
+// Quote - зафиксированная котировка с наценкой и сроком действия.
+type Quote struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	Pair          string     `json:"pair"`
+	AskPrice      float64    `json:"ask_price"`
+	BidPrice      float64    `json:"bid_price"`
+	MarkupPercent float64    `json:"markup_percent"`
+	RateTimestamp time.Time  `json:"rate_timestamp"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RedeemedAt    *time.Time `json:"redeemed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Разрешения агрегатов курсов.
+const (
+	ResolutionMinute = "minute"
+	ResolutionHour   = "hour"
+)
+
+// CurrencyRateAggregate - агрегат сырых курсов пары одного поставщика за
+// минуту или час.
+// Суммы хранятся вместо средних, чтобы агрегаты можно было дополнять.
+type CurrencyRateAggregate struct {
+	ID          int64     `json:"id" gorm:"primaryKey"`
+	Pair        string    `json:"pair"`
+	Source      string    `json:"source"`
+	Resolution  string    `json:"resolution"`
+	BucketStart time.Time `json:"bucket_start"`
+	Samples     int64     `json:"samples"`
+	AskSum      float64   `json:"ask_sum"`
+	BidSum      float64   `json:"bid_sum"`
+	AskMin      float64   `json:"ask_min"`
+	AskMax      float64   `json:"ask_max"`
+	BidMin      float64   `json:"bid_min"`
+	BidMax      float64   `json:"bid_max"`
+}
+
+func (a CurrencyRateAggregate) AskAvg() float64 {
+	if a.Samples == 0 {
+		return 0
+	}
+	return a.AskSum / float64(a.Samples)
+}
+
+func (a CurrencyRateAggregate) BidAvg() float64 {
+	if a.Samples == 0 {
+		return 0
+	}
+	return a.BidSum / float64(a.Samples)
+}
+
+// DownsampleResult - итог одного пакета прореживания сырых курсов.
+type DownsampleResult struct {
+	RawDeleted       int64
+	MinuteAggregates int64
+	HourAggregates   int64
+}
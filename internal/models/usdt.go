@@ -1,10 +1,39 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
+var (
+	ErrRateNotFound        = errors.New("курс не найден")
+	ErrRateVersionConflict = errors.New("курс изменён другим запросом")
+	ErrInvalidRate         = errors.New("некорректный курс")
+	ErrRateDuplicate       = errors.New("снимок с такими парой, временем и поставщиком уже сохранён")
+)
+
+// CurrencyRate - снимок курса пары. Version растёт на единицу при каждом
+// изменении записи и используется для оптимистичной блокировки. Source -
+// поставщик снимка; пара, время биржи и поставщик уникальны.
 type CurrencyRate struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
 	Pair      string    `json:"pair"`
 	AskPrice  float64   `json:"ask_price"`
 	BidPrice  float64   `json:"bid_price"`
 	Timestamp time.Time `json:"timestamp"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int64     `json:"version" gorm:"default:1"`
+	Source    string    `json:"source"`
+}
+
+// NormalizeCurrency приводит валюту запроса к единому виду: "rub" и "RUB" -
+// одна и та же валюта и для проверки доступа, и для хранения.
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(currency)
+}
+
+// CurrencyPair возвращает пару USDT для валюты запроса.
+func CurrencyPair(currency string) string {
+	return "USDT/" + NormalizeCurrency(currency)
 }
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+const defaultDeliveriesLimit = 50
+
+type AlertController struct {
+	service AlertControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedAlertServiceServer
+}
+
+func NewAlertController(service AlertControllerInterface, logger *zap.Logger) *AlertController {
+	return &AlertController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *AlertController) CreateAlertRule(ctx context.Context, req *usdt_proto.CreateAlertRuleRequest) (*usdt_proto.AlertRuleResponse, error) {
+	rule, err := s.service.CreateRule(ctx, models.AlertRule{
+		Pair:          models.CurrencyPair(req.TargetCurrency),
+		Condition:     req.Condition,
+		Threshold:     req.Threshold,
+		WindowSeconds: req.WindowSeconds,
+		WebhookURL:    req.WebhookUrl,
+		Secret:        req.Secret,
+		Enabled:       true,
+	})
+	if err != nil {
+		return nil, s.alertError("Controller.CreateAlertRule error:", err)
+	}
+	resp := alertRuleToProto(rule)
+	resp.Secret = rule.Secret
+	return &usdt_proto.AlertRuleResponse{Rule: resp}, nil
+}
+
+func (s *AlertController) GetAlertRule(ctx context.Context, req *usdt_proto.GetAlertRuleRequest) (*usdt_proto.AlertRuleResponse, error) {
+	rule, err := s.service.GetRule(ctx, req.Id)
+	if err != nil {
+		return nil, s.alertError("Controller.GetAlertRule error:", err)
+	}
+	return &usdt_proto.AlertRuleResponse{Rule: alertRuleToProto(rule)}, nil
+}
+
+func (s *AlertController) ListAlertRules(ctx context.Context, req *usdt_proto.ListAlertRulesRequest) (*usdt_proto.ListAlertRulesResponse, error) {
+	pair := ""
+	if req.TargetCurrency != "" {
+		pair = models.CurrencyPair(req.TargetCurrency)
+	}
+	rules, err := s.service.ListRules(ctx, pair)
+	if err != nil {
+		return nil, s.alertError("Controller.ListAlertRules error:", err)
+	}
+	resp := &usdt_proto.ListAlertRulesResponse{}
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, alertRuleToProto(rule))
+	}
+	return resp, nil
+}
+
+func (s *AlertController) UpdateAlertRule(ctx context.Context, req *usdt_proto.UpdateAlertRuleRequest) (*usdt_proto.AlertRuleResponse, error) {
+	rule, err := s.service.UpdateRule(ctx, models.AlertRule{
+		ID:            req.Id,
+		Pair:          models.CurrencyPair(req.TargetCurrency),
+		Condition:     req.Condition,
+		Threshold:     req.Threshold,
+		WindowSeconds: req.WindowSeconds,
+		WebhookURL:    req.WebhookUrl,
+		Secret:        req.Secret,
+		Enabled:       req.Enabled,
+	})
+	if err != nil {
+		return nil, s.alertError("Controller.UpdateAlertRule error:", err)
+	}
+	return &usdt_proto.AlertRuleResponse{Rule: alertRuleToProto(rule)}, nil
+}
+
+func (s *AlertController) DeleteAlertRule(ctx context.Context, req *usdt_proto.DeleteAlertRuleRequest) (*usdt_proto.DeleteAlertRuleResponse, error) {
+	if err := s.service.DeleteRule(ctx, req.Id); err != nil {
+		return nil, s.alertError("Controller.DeleteAlertRule error:", err)
+	}
+	return &usdt_proto.DeleteAlertRuleResponse{}, nil
+}
+
+func (s *AlertController) ListAlertDeliveries(ctx context.Context, req *usdt_proto.ListAlertDeliveriesRequest) (*usdt_proto.ListAlertDeliveriesResponse, error) {
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	deliveries, err := s.service.ListDeliveries(ctx, req.RuleId, limit)
+	if err != nil {
+		return nil, s.alertError("Controller.ListAlertDeliveries error:", err)
+	}
+	resp := &usdt_proto.ListAlertDeliveriesResponse{}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, &usdt_proto.AlertDelivery{
+			Id:         d.ID,
+			RuleId:     d.RuleID,
+			Attempt:    int32(d.Attempt),
+			StatusCode: int32(d.StatusCode),
+			Error:      d.Error,
+			Delivered:  d.Delivered,
+			Payload:    d.Payload,
+			CreatedAt:  d.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp, nil
+}
+
+func (s *AlertController) alertError(msg string, err error) error {
+	switch {
+	case errors.Is(err, models.ErrAlertRuleNotFound):
+		return status.Error(codes.NotFound, models.ErrAlertRuleNotFound.Error())
+	case errors.Is(err, models.ErrInvalidAlertRule):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.logger.Error(msg, zap.Error(err))
+	return status.Error(codes.Internal, "внутренняя ошибка")
+}
+
+func alertRuleToProto(rule models.AlertRule) *usdt_proto.AlertRule {
+	return &usdt_proto.AlertRule{
+		Id:            rule.ID,
+		Pair:          rule.Pair,
+		Condition:     rule.Condition,
+		Threshold:     rule.Threshold,
+		WindowSeconds: rule.WindowSeconds,
+		WebhookUrl:    rule.WebhookURL,
+		Enabled:       rule.Enabled,
+		CreatedAt:     rule.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type KeyController struct {
+	service KeyControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedKeyServiceServer
+}
+
+func NewKeyController(service KeyControllerInterface, logger *zap.Logger) *KeyController {
+	return &KeyController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *KeyController) CreateAPIKey(ctx context.Context, req *usdt_proto.CreateAPIKeyRequest) (*usdt_proto.CreateAPIKeyResponse, error) {
+	key := models.APIKey{Name: req.Name, Scopes: req.Scopes}
+	for _, currency := range req.TargetCurrencies {
+		key.Pairs = append(key.Pairs, models.CurrencyPair(currency))
+	}
+	created, secret, err := s.service.Issue(ctx, key, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, s.keyError("Controller.CreateAPIKey error:", err)
+	}
+	s.logger.Info("Выпущен API ключ", zap.Int64("id", created.ID), zap.String("name", created.Name), zap.String("prefix", created.Prefix))
+	return &usdt_proto.CreateAPIKeyResponse{Key: apiKeyToProto(created), Secret: secret}, nil
+}
+
+func (s *KeyController) ListAPIKeys(ctx context.Context, _ *usdt_proto.ListAPIKeysRequest) (*usdt_proto.ListAPIKeysResponse, error) {
+	keys, err := s.service.List(ctx)
+	if err != nil {
+		return nil, s.keyError("Controller.ListAPIKeys error:", err)
+	}
+	resp := &usdt_proto.ListAPIKeysResponse{}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, apiKeyToProto(key))
+	}
+	return resp, nil
+}
+
+func (s *KeyController) RevokeAPIKey(ctx context.Context, req *usdt_proto.RevokeAPIKeyRequest) (*usdt_proto.RevokeAPIKeyResponse, error) {
+	if err := s.service.Revoke(ctx, req.Id); err != nil {
+		return nil, s.keyError("Controller.RevokeAPIKey error:", err)
+	}
+	s.logger.Info("Отозван API ключ", zap.Int64("id", req.Id))
+	return &usdt_proto.RevokeAPIKeyResponse{}, nil
+}
+
+func (s *KeyController) keyError(msg string, err error) error {
+	switch {
+	case errors.Is(err, models.ErrAPIKeyNotFound):
+		return status.Error(codes.NotFound, models.ErrAPIKeyNotFound.Error())
+	case errors.Is(err, models.ErrInvalidAPIKey):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.logger.Error(msg, zap.Error(err))
+	return status.Error(codes.Internal, "внутренняя ошибка")
+}
+
+func apiKeyToProto(key models.APIKey) *usdt_proto.APIKey {
+	resp := &usdt_proto.APIKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		Pairs:     key.Pairs,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.ExpiresAt != nil {
+		resp.ExpiresAt = key.ExpiresAt.Format(time.RFC3339)
+	}
+	if key.RevokedAt != nil {
+		resp.RevokedAt = key.RevokedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"go.uber.org/zap"
+	"time"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type ArbitrageController struct {
+	service ArbitrageControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedArbitrageServiceServer
+}
+
+func NewArbitrageController(service ArbitrageControllerInterface, logger *zap.Logger) *ArbitrageController {
+	return &ArbitrageController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *ArbitrageController) StreamArbitrage(req *usdt_proto.StreamArbitrageRequest, stream usdt_proto.ArbitrageService_StreamArbitrageServer) error {
+	pair := ""
+	if req.TargetCurrency != "" {
+		pair = models.CurrencyPair(req.TargetCurrency)
+	}
+	opportunities, unsubscribe := s.service.Subscribe(pair)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case opportunity := <-opportunities:
+			if err := stream.Send(arbitrageToProto(opportunity)); err != nil {
+				s.logger.Warn("Controller.StreamArbitrage send error:", zap.Error(err))
+				return err
+			}
+		}
+	}
+}
+
+func arbitrageToProto(opportunity models.ArbitrageOpportunity) *usdt_proto.ArbitrageOpportunity {
+	return &usdt_proto.ArbitrageOpportunity{
+		Pair:          opportunity.Pair,
+		BuyVenue:      opportunity.BuyVenue,
+		SellVenue:     opportunity.SellVenue,
+		BuyPrice:      opportunity.BuyPrice,
+		SellPrice:     opportunity.SellPrice,
+		Volume:        opportunity.Volume,
+		Profit:        opportunity.Profit,
+		ProfitPercent: opportunity.ProfitPercent,
+		DetectedAt:    opportunity.DetectedAt.Format(time.RFC3339Nano),
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"go.uber.org/zap"
+	"usdt/internal/models"
 	"usdt/internal/proto/usdt_proto"
 )
 
@@ -27,19 +28,17 @@ func NewController(service ControllerInterface, logger *zap.Logger) UsdtInterfac
 }
 
 func (s *UsdtController) GetRates(ctx context.Context, req *usdt_proto.GetRatesRequest) (*usdt_proto.GetRatesResponse, error) {
-	rate, err := s.service.GetRates(ctx, req.TargetCurrency)
+	rate, err := s.service.GetRates(ctx, models.NormalizeCurrency(req.TargetCurrency))
 	if err != nil {
 		s.logger.Error("Controller.GetRates error:", zap.Error(err))
 		return nil, errors.Unwrap(err)
 	}
-	cr := usdt_proto.CurrencyRate{
-		Pair:      rate.Pair,
-		AskPrice:  rate.AskPrice,
-		BidPrice:  rate.BidPrice,
-		Timestamp: rate.Timestamp.String(),
-	}
+	cr := rateToProto(rate)
+	// Время курса в GetRates остаётся в прежнем формате time.Time.String(),
+	// на который рассчитаны существующие клиенты; RFC3339 - в новых полях и методах.
+	cr.Timestamp = rate.Timestamp.String()
 	resp := &usdt_proto.GetRatesResponse{
-		Rate: &cr,
+		Rate: cr,
 	}
 
 	return resp, nil
@@ -2,9 +2,50 @@ package controller
 
 import (
 	"context"
+	"time"
 	"usdt/internal/models"
 )
 
 type ControllerInterface interface {
 	GetRates(ctx context.Context, pair string) (models.CurrencyRate, error)
 }
+
+type QuoteControllerInterface interface {
+	CreateQuote(ctx context.Context, pair string) (models.Quote, error)
+	RedeemQuote(ctx context.Context, id string) (models.Quote, error)
+}
+
+type AlertControllerInterface interface {
+	CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	GetRule(ctx context.Context, id int64) (models.AlertRule, error)
+	ListRules(ctx context.Context, pair string) ([]models.AlertRule, error)
+	UpdateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error)
+}
+
+type MarketStatsControllerInterface interface {
+	GetCurrent(ctx context.Context, pair string) (models.MarketStats, error)
+	GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error)
+}
+
+type ArbitrageControllerInterface interface {
+	Subscribe(pair string) (<-chan models.ArbitrageOpportunity, func())
+}
+
+type RateAdminControllerInterface interface {
+	GetRate(ctx context.Context, id int64) (models.CurrencyRate, error)
+	ListRates(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error)
+	UpdateRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, error)
+	DeleteRate(ctx context.Context, id, version int64) error
+}
+
+type ExportControllerInterface interface {
+	Export(ctx context.Context, pairs []string, from, to time.Time, write func(models.CurrencyRate) error) error
+}
+
+type KeyControllerInterface interface {
+	Issue(ctx context.Context, key models.APIKey, ttl time.Duration) (models.APIKey, string, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
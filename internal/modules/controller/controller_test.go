@@ -39,10 +39,13 @@ func TestUsdtController_GetRates(t *testing.T) {
 		testQuery := "RUB"
 		timeNow := time.Now()
 		expectedResponse := models.CurrencyRate{
+			ID:        7,
 			Pair:      "USDT/RUB",
 			AskPrice:  1.1,
 			BidPrice:  2.2,
 			Timestamp: timeNow,
+			CreatedAt: timeNow,
+			Version:   1,
 		}
 
 		mockController := new(MockControllerInterface)
@@ -58,7 +61,10 @@ func TestUsdtController_GetRates(t *testing.T) {
 		assert.Equal(t, expectedResponse.Pair, resp.Rate.Pair)
 		assert.Equal(t, expectedResponse.AskPrice, resp.Rate.AskPrice)
 		assert.Equal(t, expectedResponse.BidPrice, resp.Rate.BidPrice)
-		assert.Equal(t, expectedResponse.Timestamp.String(), resp.Rate.Timestamp)
+		assert.Equal(t, expectedResponse.Timestamp.String(), resp.Rate.Timestamp, "формат времени GetRates не меняется")
+		assert.Equal(t, expectedResponse.ID, resp.Rate.Id)
+		assert.Equal(t, expectedResponse.CreatedAt.Format(time.RFC3339), resp.Rate.CreatedAt)
+		assert.Equal(t, expectedResponse.Version, resp.Rate.Version)
 
 	})
 
@@ -70,8 +76,9 @@ func TestUsdtController_GetRates(t *testing.T) {
 		mockController.On("GetRates", context.Background(), testQuery).Return(models.CurrencyRate{}, expectedError)
 
 		controller := NewController(mockController, zap.NewNop())
+		// Валюта приводится к верхнему регистру до вызова сервиса.
 		reqProto := &usdt_proto.GetRatesRequest{
-			TargetCurrency: "EUR",
+			TargetCurrency: "eur",
 		}
 
 		_, err := controller.GetRates(context.Background(), reqProto)
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"usdt/internal/infrastructure/ratefile"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+// exportChunkSize - размер данных в одном сообщении потока выгрузки.
+const exportChunkSize = 64 * 1024
+
+type ExportController struct {
+	service ExportControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedExportServiceServer
+}
+
+func NewExportController(service ExportControllerInterface, logger *zap.Logger) *ExportController {
+	return &ExportController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *ExportController) ExportRates(req *usdt_proto.ExportRatesRequest, stream usdt_proto.ExportService_ExportRatesServer) error {
+	if len(req.TargetCurrencies) == 0 {
+		return status.Error(codes.InvalidArgument, "не указаны валюты")
+	}
+	from, to, err := parsePeriod(req.From, req.To)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	chunks := &chunkWriter{stream: stream}
+	writer, err := ratefile.NewWriter(req.Format, chunks)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	pairs := make([]string, 0, len(req.TargetCurrencies))
+	for _, currency := range req.TargetCurrencies {
+		pairs = append(pairs, models.CurrencyPair(currency))
+	}
+
+	err = s.service.Export(stream.Context(), pairs, from, to, writer.Write)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = chunks.Flush()
+	}
+	if err != nil {
+		if ctxErr := stream.Context().Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return status.FromContextError(ctxErr).Err()
+		}
+		s.logger.Error("Controller.ExportRates error:", zap.Error(err))
+		return status.Error(codes.Internal, "не удалось выгрузить курсы")
+	}
+	return nil
+}
+
+// chunkWriter копит вывод формата и отправляет его сообщениями по
+// exportChunkSize байт.
+type chunkWriter struct {
+	stream usdt_proto.ExportService_ExportRatesServer
+	buf    []byte
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= exportChunkSize {
+		// Send сериализует сообщение до возврата, поэтому буфер можно переиспользовать.
+		if err := w.stream.Send(&usdt_proto.ExportRatesChunk{Data: w.buf[:exportChunkSize]}); err != nil {
+			return 0, err
+		}
+		w.buf = append(w.buf[:0], w.buf[exportChunkSize:]...)
+	}
+	return len(p), nil
+}
+
+// Flush отправляет остаток буфера.
+func (w *chunkWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if err := w.stream.Send(&usdt_proto.ExportRatesChunk{Data: w.buf}); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type MockExportService struct {
+	mock.Mock
+}
+
+func (m *MockExportService) Export(ctx context.Context, pairs []string, from, to time.Time, write func(models.CurrencyRate) error) error {
+	args := m.Called(ctx, pairs, from, to, write)
+	return args.Error(0)
+}
+
+// fakeExportStream собирает отправленные чанки выгрузки.
+type fakeExportStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks [][]byte
+}
+
+func (f *fakeExportStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeExportStream) Send(chunk *usdt_proto.ExportRatesChunk) error {
+	f.chunks = append(f.chunks, bytes.Clone(chunk.Data))
+	return nil
+}
+
+func TestExportController_ExportRates(t *testing.T) {
+	to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-time.Hour)
+	req := &usdt_proto.ExportRatesRequest{
+		TargetCurrencies: []string{"RUB", "USD"},
+		From:             from.Format(time.RFC3339),
+		To:               to.Format(time.RFC3339),
+		Format:           "csv",
+	}
+
+	t.Run("Chunked", func(t *testing.T) {
+		// Строк хватает на несколько чанков.
+		const rows = 3000
+		mockService := new(MockExportService)
+		mockService.On("Export", mock.Anything, []string{"USDT/RUB", "USDT/USD"}, from, to, mock.Anything).
+			Run(func(args mock.Arguments) {
+				write := args.Get(4).(func(models.CurrencyRate) error)
+				for i := 0; i < rows; i++ {
+					require.NoError(t, write(models.CurrencyRate{ID: int64(i + 1), Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90,
+						Timestamp: from.Add(time.Duration(i) * time.Second), Version: 1}))
+				}
+			}).Return(nil)
+
+		stream := &fakeExportStream{ctx: context.Background()}
+		err := NewExportController(mockService, zap.NewNop()).ExportRates(req, stream)
+		require.NoError(t, err)
+		require.Greater(t, len(stream.chunks), 1)
+		for _, chunk := range stream.chunks[:len(stream.chunks)-1] {
+			assert.Len(t, chunk, exportChunkSize)
+		}
+
+		records, err := csv.NewReader(bytes.NewReader(bytes.Join(stream.chunks, nil))).ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, records, rows+1)
+		assert.Equal(t, fmt.Sprint(rows), records[rows][0])
+	})
+
+	t.Run("InvalidRequest", func(t *testing.T) {
+		controller := NewExportController(new(MockExportService), zap.NewNop())
+		stream := &fakeExportStream{ctx: context.Background()}
+		for name, bad := range map[string]*usdt_proto.ExportRatesRequest{
+			"NoCurrencies": {From: req.From, Format: "csv"},
+			"BadPeriod":    {TargetCurrencies: []string{"RUB"}, From: "вчера", Format: "csv"},
+			"BadFormat":    {TargetCurrencies: []string{"RUB"}, From: req.From, Format: "xlsx"},
+		} {
+			err := controller.ExportRates(bad, stream)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), name)
+		}
+		assert.Empty(t, stream.chunks)
+	})
+
+	t.Run("ServiceError", func(t *testing.T) {
+		mockService := new(MockExportService)
+		mockService.On("Export", mock.Anything, mock.Anything, from, to, mock.Anything).Return(errors.New("db error"))
+
+		err := NewExportController(mockService, zap.NewNop()).ExportRates(req, &fakeExportStream{ctx: context.Background()})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("ClientGone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		mockService := new(MockExportService)
+		mockService.On("Export", mock.Anything, mock.Anything, from, to, mock.Anything).Return(fmt.Errorf("Service.Export: %w", context.Canceled))
+
+		err := NewExportController(mockService, zap.NewNop()).ExportRates(req, &fakeExportStream{ctx: ctx})
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+}
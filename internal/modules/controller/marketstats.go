@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type MarketStatsController struct {
+	service MarketStatsControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedMarketServiceServer
+}
+
+func NewMarketStatsController(service MarketStatsControllerInterface, logger *zap.Logger) *MarketStatsController {
+	return &MarketStatsController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *MarketStatsController) GetMarketStats(ctx context.Context, req *usdt_proto.GetMarketStatsRequest) (*usdt_proto.GetMarketStatsResponse, error) {
+	pair := models.CurrencyPair(req.TargetCurrency)
+	current, err := s.service.GetCurrent(ctx, pair)
+	if err != nil {
+		s.logger.Error("Controller.GetMarketStats error:", zap.Error(err))
+		return nil, status.Error(codes.Internal, "не удалось получить метрики рынка")
+	}
+	resp := &usdt_proto.GetMarketStatsResponse{}
+	if !current.Timestamp.IsZero() {
+		resp.Current = marketStatsToProto(current)
+	}
+	if req.From == "" && req.To == "" {
+		return resp, nil
+	}
+
+	from, to, err := parsePeriod(req.From, req.To)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	history, err := s.service.GetHistory(ctx, pair, from, to)
+	if err != nil {
+		s.logger.Error("Controller.GetMarketStats error:", zap.Error(err))
+		return nil, status.Error(codes.Internal, "не удалось получить историю метрик рынка")
+	}
+	for _, stats := range history {
+		resp.History = append(resp.History, marketStatsToProto(stats))
+	}
+	return resp, nil
+}
+
+// parsePeriod разбирает границы периода в RFC3339; пустой to означает "сейчас".
+func parsePeriod(fromValue, toValue string) (time.Time, time.Time, error) {
+	from, err := time.Parse(time.RFC3339, fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("некорректное значение from: %w", err)
+	}
+	to := time.Now()
+	if toValue != "" {
+		to, err = time.Parse(time.RFC3339, toValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("некорректное значение to: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to раньше from")
+	}
+	return from, to, nil
+}
+
+func marketStatsToProto(stats models.MarketStats) *usdt_proto.MarketStats {
+	return &usdt_proto.MarketStats{
+		Pair:          stats.Pair,
+		Timestamp:     stats.Timestamp.Format(time.RFC3339),
+		AskPrice:      stats.AskPrice,
+		BidPrice:      stats.BidPrice,
+		MidPrice:      stats.MidPrice,
+		Spread:        stats.Spread,
+		SpreadPercent: stats.SpreadPercent,
+		AskDepth_05:   stats.AskDepth05,
+		BidDepth_05:   stats.BidDepth05,
+		AskDepth_1:    stats.AskDepth1,
+		BidDepth_1:    stats.BidDepth1,
+		AskDepth_2:    stats.AskDepth2,
+		BidDepth_2:    stats.BidDepth2,
+		Imbalance:     stats.Imbalance,
+	}
+}
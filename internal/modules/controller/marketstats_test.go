@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type MockMarketStatsService struct {
+	mock.Mock
+}
+
+func (m *MockMarketStatsService) GetCurrent(ctx context.Context, pair string) (models.MarketStats, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).(models.MarketStats), args.Error(1)
+}
+
+func (m *MockMarketStatsService) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error) {
+	args := m.Called(ctx, pair, from, to)
+	return args.Get(0).([]models.MarketStats), args.Error(1)
+}
+
+func TestMarketStatsController_GetMarketStats(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	current := models.MarketStats{Pair: "USDT/RUB", Timestamp: now, MidPrice: 100, AskDepth1: 3, BidDepth1: 8}
+
+	t.Run("Current", func(t *testing.T) {
+		mockService := new(MockMarketStatsService)
+		mockService.On("GetCurrent", mock.Anything, "USDT/RUB").Return(current, nil)
+
+		controller := NewMarketStatsController(mockService, zap.NewNop())
+		resp, err := controller.GetMarketStats(context.Background(), &usdt_proto.GetMarketStatsRequest{TargetCurrency: "RUB"})
+		assert.NoError(t, err)
+		assert.Equal(t, 100.0, resp.Current.MidPrice)
+		assert.Equal(t, 8.0, resp.Current.BidDepth_1)
+		assert.Empty(t, resp.History)
+		mockService.AssertNotCalled(t, "GetHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		from := now.Add(-time.Hour)
+		mockService := new(MockMarketStatsService)
+		mockService.On("GetCurrent", mock.Anything, "USDT/RUB").Return(current, nil)
+		mockService.On("GetHistory", mock.Anything, "USDT/RUB", from, now).Return([]models.MarketStats{current, current}, nil)
+
+		controller := NewMarketStatsController(mockService, zap.NewNop())
+		resp, err := controller.GetMarketStats(context.Background(), &usdt_proto.GetMarketStatsRequest{
+			TargetCurrency: "RUB",
+			From:           from.Format(time.RFC3339),
+			To:             now.Format(time.RFC3339),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, resp.History, 2)
+	})
+
+	t.Run("InvalidPeriod", func(t *testing.T) {
+		mockService := new(MockMarketStatsService)
+		mockService.On("GetCurrent", mock.Anything, "USDT/RUB").Return(current, nil)
+
+		controller := NewMarketStatsController(mockService, zap.NewNop())
+		_, err := controller.GetMarketStats(context.Background(), &usdt_proto.GetMarketStatsRequest{TargetCurrency: "RUB", From: "yesterday"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type QuoteController struct {
+	service QuoteControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedQuoteServiceServer
+}
+
+func NewQuoteController(service QuoteControllerInterface, logger *zap.Logger) *QuoteController {
+	return &QuoteController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *QuoteController) CreateQuote(ctx context.Context, req *usdt_proto.CreateQuoteRequest) (*usdt_proto.CreateQuoteResponse, error) {
+	quote, err := s.service.CreateQuote(ctx, models.NormalizeCurrency(req.TargetCurrency))
+	if err != nil {
+		return nil, s.quoteError("Controller.CreateQuote error:", err, codes.Unavailable, "не удалось создать котировку")
+	}
+	return &usdt_proto.CreateQuoteResponse{Quote: quoteToProto(quote)}, nil
+}
+
+func (s *QuoteController) RedeemQuote(ctx context.Context, req *usdt_proto.RedeemQuoteRequest) (*usdt_proto.RedeemQuoteResponse, error) {
+	quote, err := s.service.RedeemQuote(ctx, req.QuoteId)
+	if err != nil {
+		return nil, s.quoteError("Controller.RedeemQuote error:", err, codes.Internal, "не удалось погасить котировку")
+	}
+	return &usdt_proto.RedeemQuoteResponse{Quote: quoteToProto(quote)}, nil
+}
+
+// quoteError переводит ошибку сервиса в статус gRPC; неизвестные ошибки
+// логируются и возвращаются с кодом code и сообщением msg.
+func (s *QuoteController) quoteError(logMsg string, err error, code codes.Code, msg string) error {
+	switch {
+	case errors.Is(err, models.ErrQuoteNotFound):
+		return status.Error(codes.NotFound, models.ErrQuoteNotFound.Error())
+	case errors.Is(err, models.ErrRateNotFound):
+		return status.Error(codes.NotFound, models.ErrRateNotFound.Error())
+	case errors.Is(err, models.ErrQuoteExpired):
+		return status.Error(codes.FailedPrecondition, models.ErrQuoteExpired.Error())
+	case errors.Is(err, models.ErrSnapshotRejected):
+		return status.Error(codes.FailedPrecondition, models.ErrSnapshotRejected.Error())
+	case errors.Is(err, models.ErrQuoteRedeemed):
+		return status.Error(codes.AlreadyExists, models.ErrQuoteRedeemed.Error())
+	case errors.Is(err, models.ErrInvalidQuote):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, models.ErrPermissionDenied.Error())
+	}
+	s.logger.Error(logMsg, zap.Error(err))
+	return status.Error(code, msg)
+}
+
+func quoteToProto(quote models.Quote) *usdt_proto.Quote {
+	q := &usdt_proto.Quote{
+		Id:            quote.ID,
+		Pair:          quote.Pair,
+		AskPrice:      quote.AskPrice,
+		BidPrice:      quote.BidPrice,
+		MarkupPercent: quote.MarkupPercent,
+		RateTimestamp: quote.RateTimestamp.Format(time.RFC3339),
+		ExpiresAt:     quote.ExpiresAt.Format(time.RFC3339),
+	}
+	if quote.RedeemedAt != nil {
+		q.RedeemedAt = quote.RedeemedAt.Format(time.RFC3339)
+	}
+	return q
+}
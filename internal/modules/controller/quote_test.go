@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type MockQuoteService struct {
+	mock.Mock
+}
+
+func (m *MockQuoteService) CreateQuote(ctx context.Context, pair string) (models.Quote, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func (m *MockQuoteService) RedeemQuote(ctx context.Context, id string) (models.Quote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func TestQuoteController_CreateQuote(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		quote := models.Quote{ID: "q1", Pair: "USDT/RUB", RateTimestamp: now, ExpiresAt: now.Add(30*time.Second + 500*time.Millisecond)}
+		mockService := new(MockQuoteService)
+		mockService.On("CreateQuote", mock.Anything, "RUB").Return(quote, nil)
+
+		controller := NewQuoteController(mockService, zap.NewNop())
+		resp, err := controller.CreateQuote(context.Background(), &usdt_proto.CreateQuoteRequest{TargetCurrency: "RUB"})
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-10-27T12:00:00Z", resp.Quote.RateTimestamp)
+		assert.Equal(t, "2024-10-27T12:00:30Z", resp.Quote.ExpiresAt, "все метки времени в RFC3339")
+	})
+
+	for name, tc := range map[string]struct {
+		err  error
+		code codes.Code
+	}{
+		"NotFound":   {models.ErrRateNotFound, codes.NotFound},
+		"Stale":      {models.ErrSnapshotRejected, codes.FailedPrecondition},
+		"Invalid":    {models.ErrInvalidQuote, codes.InvalidArgument},
+		"Permission": {models.ErrPermissionDenied, codes.PermissionDenied},
+		"Upstream":   {errors.New("API недоступен"), codes.Unavailable},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockService := new(MockQuoteService)
+			mockService.On("CreateQuote", mock.Anything, "RUB").Return(models.Quote{}, fmt.Errorf("Service.CreateQuote: %w", tc.err))
+
+			controller := NewQuoteController(mockService, zap.NewNop())
+			_, err := controller.CreateQuote(context.Background(), &usdt_proto.CreateQuoteRequest{TargetCurrency: "RUB"})
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+func TestQuoteController_RedeemQuote(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		code codes.Code
+	}{
+		"NotFound": {models.ErrQuoteNotFound, codes.NotFound},
+		"Expired":  {models.ErrQuoteExpired, codes.FailedPrecondition},
+		"Redeemed": {models.ErrQuoteRedeemed, codes.AlreadyExists},
+		"Internal": {errors.New("база недоступна"), codes.Internal},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockService := new(MockQuoteService)
+			mockService.On("RedeemQuote", mock.Anything, "q1").Return(models.Quote{}, fmt.Errorf("Service.RedeemQuote: %w", tc.err))
+
+			controller := NewQuoteController(mockService, zap.NewNop())
+			_, err := controller.RedeemQuote(context.Background(), &usdt_proto.RedeemQuoteRequest{QuoteId: "q1"})
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type RateAdminController struct {
+	service RateAdminControllerInterface
+	logger  *zap.Logger
+	usdt_proto.UnimplementedRateAdminServiceServer
+}
+
+func NewRateAdminController(service RateAdminControllerInterface, logger *zap.Logger) *RateAdminController {
+	return &RateAdminController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *RateAdminController) GetRate(ctx context.Context, req *usdt_proto.GetRateRequest) (*usdt_proto.RateResponse, error) {
+	rate, err := s.service.GetRate(ctx, req.Id)
+	if err != nil {
+		return nil, s.rateError("Controller.GetRate error:", err)
+	}
+	return &usdt_proto.RateResponse{Rate: rateToProto(rate)}, nil
+}
+
+func (s *RateAdminController) ListRates(ctx context.Context, req *usdt_proto.ListRatesRequest) (*usdt_proto.ListRatesResponse, error) {
+	from, to, err := parsePeriod(req.From, req.To)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	rates, err := s.service.ListRates(ctx, models.CurrencyPair(req.TargetCurrency), from, to)
+	if err != nil {
+		return nil, s.rateError("Controller.ListRates error:", err)
+	}
+	resp := &usdt_proto.ListRatesResponse{}
+	for _, rate := range rates {
+		resp.Rates = append(resp.Rates, rateToProto(rate))
+	}
+	return resp, nil
+}
+
+func (s *RateAdminController) UpdateRate(ctx context.Context, req *usdt_proto.UpdateRateRequest) (*usdt_proto.RateResponse, error) {
+	timestamp, err := time.Parse(time.RFC3339, req.Timestamp)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("некорректное значение timestamp: %v", err))
+	}
+	rate, err := s.service.UpdateRate(ctx, models.CurrencyRate{
+		ID:        req.Id,
+		AskPrice:  req.AskPrice,
+		BidPrice:  req.BidPrice,
+		Timestamp: timestamp,
+		Version:   req.Version,
+	})
+	if err != nil {
+		return nil, s.rateError("Controller.UpdateRate error:", err)
+	}
+	return &usdt_proto.RateResponse{Rate: rateToProto(rate)}, nil
+}
+
+func (s *RateAdminController) DeleteRate(ctx context.Context, req *usdt_proto.DeleteRateRequest) (*usdt_proto.DeleteRateResponse, error) {
+	if err := s.service.DeleteRate(ctx, req.Id, req.Version); err != nil {
+		return nil, s.rateError("Controller.DeleteRate error:", err)
+	}
+	return &usdt_proto.DeleteRateResponse{}, nil
+}
+
+func (s *RateAdminController) rateError(msg string, err error) error {
+	switch {
+	case errors.Is(err, models.ErrRateNotFound):
+		return status.Error(codes.NotFound, models.ErrRateNotFound.Error())
+	case errors.Is(err, models.ErrRateVersionConflict):
+		return status.Error(codes.Aborted, models.ErrRateVersionConflict.Error())
+	case errors.Is(err, models.ErrRateDuplicate):
+		return status.Error(codes.AlreadyExists, models.ErrRateDuplicate.Error())
+	case errors.Is(err, models.ErrInvalidRate):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, models.ErrPermissionDenied.Error())
+	}
+	s.logger.Error(msg, zap.Error(err))
+	return status.Error(codes.Internal, "внутренняя ошибка")
+}
+
+func rateToProto(rate models.CurrencyRate) *usdt_proto.CurrencyRate {
+	return &usdt_proto.CurrencyRate{
+		Id:        rate.ID,
+		Pair:      rate.Pair,
+		AskPrice:  rate.AskPrice,
+		BidPrice:  rate.BidPrice,
+		Timestamp: rate.Timestamp.Format(time.RFC3339),
+		CreatedAt: rate.CreatedAt.Format(time.RFC3339),
+		Version:   rate.Version,
+		Source:    rate.Source,
+	}
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	"usdt/internal/proto/usdt_proto"
+)
+
+type MockRateAdminService struct {
+	mock.Mock
+}
+
+func (m *MockRateAdminService) GetRate(ctx context.Context, id int64) (models.CurrencyRate, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.CurrencyRate), args.Error(1)
+}
+
+func (m *MockRateAdminService) ListRates(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	args := m.Called(ctx, pair, from, to)
+	return args.Get(0).([]models.CurrencyRate), args.Error(1)
+}
+
+func (m *MockRateAdminService) UpdateRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, error) {
+	args := m.Called(ctx, rate)
+	return args.Get(0).(models.CurrencyRate), args.Error(1)
+}
+
+func (m *MockRateAdminService) DeleteRate(ctx context.Context, id, version int64) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func TestRateAdminController_GetRate(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	rate := models.CurrencyRate{ID: 7, Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Timestamp: now, CreatedAt: now.Add(time.Second), Version: 3}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockRateAdminService)
+		mockService.On("GetRate", mock.Anything, int64(7)).Return(rate, nil)
+
+		controller := NewRateAdminController(mockService, zap.NewNop())
+		resp, err := controller.GetRate(context.Background(), &usdt_proto.GetRateRequest{Id: 7})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), resp.Rate.Id)
+		assert.Equal(t, int64(3), resp.Rate.Version)
+		assert.Equal(t, "2024-10-27T12:00:00Z", resp.Rate.Timestamp)
+		assert.Equal(t, "2024-10-27T12:00:01Z", resp.Rate.CreatedAt)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockService := new(MockRateAdminService)
+		mockService.On("GetRate", mock.Anything, int64(7)).Return(models.CurrencyRate{}, fmt.Errorf("Service.GetRate: %w", models.ErrRateNotFound))
+
+		controller := NewRateAdminController(mockService, zap.NewNop())
+		_, err := controller.GetRate(context.Background(), &usdt_proto.GetRateRequest{Id: 7})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestRateAdminController_ListRates(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	from := now.Add(-time.Hour)
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockRateAdminService)
+		mockService.On("ListRates", mock.Anything, "USDT/RUB", from, now).Return([]models.CurrencyRate{{ID: 1}, {ID: 2}}, nil)
+
+		controller := NewRateAdminController(mockService, zap.NewNop())
+		resp, err := controller.ListRates(context.Background(), &usdt_proto.ListRatesRequest{
+			TargetCurrency: "rub",
+			From:           from.Format(time.RFC3339),
+			To:             now.Format(time.RFC3339),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Rates, 2)
+	})
+
+	t.Run("InvalidPeriod", func(t *testing.T) {
+		controller := NewRateAdminController(new(MockRateAdminService), zap.NewNop())
+		_, err := controller.ListRates(context.Background(), &usdt_proto.ListRatesRequest{TargetCurrency: "RUB", From: "вчера"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestRateAdminController_UpdateRate(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	req := &usdt_proto.UpdateRateRequest{Id: 7, Version: 1, AskPrice: 91, BidPrice: 90, Timestamp: now.Format(time.RFC3339)}
+	rate := models.CurrencyRate{ID: 7, AskPrice: 91, BidPrice: 90, Timestamp: now, Version: 1}
+
+	t.Run("Success", func(t *testing.T) {
+		updated := rate
+		updated.Version = 2
+		mockService := new(MockRateAdminService)
+		mockService.On("UpdateRate", mock.Anything, rate).Return(updated, nil)
+
+		controller := NewRateAdminController(mockService, zap.NewNop())
+		resp, err := controller.UpdateRate(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Rate.Version)
+	})
+
+	errorCases := map[error]codes.Code{
+		models.ErrRateVersionConflict: codes.Aborted,
+		models.ErrRateNotFound:        codes.NotFound,
+		models.ErrInvalidRate:         codes.InvalidArgument,
+		models.ErrPermissionDenied:    codes.PermissionDenied,
+		models.ErrRateDuplicate:       codes.AlreadyExists,
+		fmt.Errorf("db error"):        codes.Internal,
+	}
+	for serviceErr, code := range errorCases {
+		t.Run(code.String(), func(t *testing.T) {
+			mockService := new(MockRateAdminService)
+			mockService.On("UpdateRate", mock.Anything, rate).Return(models.CurrencyRate{}, fmt.Errorf("Service.UpdateRate: %w", serviceErr))
+
+			controller := NewRateAdminController(mockService, zap.NewNop())
+			_, err := controller.UpdateRate(context.Background(), req)
+			assert.Equal(t, code, status.Code(err))
+		})
+	}
+
+	t.Run("InvalidTimestamp", func(t *testing.T) {
+		controller := NewRateAdminController(new(MockRateAdminService), zap.NewNop())
+		_, err := controller.UpdateRate(context.Background(), &usdt_proto.UpdateRateRequest{Id: 7, Version: 1, Timestamp: "сейчас"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestRateAdminController_DeleteRate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockRateAdminService)
+		mockService.On("DeleteRate", mock.Anything, int64(7), int64(2)).Return(nil)
+
+		controller := NewRateAdminController(mockService, zap.NewNop())
+		_, err := controller.DeleteRate(context.Background(), &usdt_proto.DeleteRateRequest{Id: 7, Version: 2})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mockService := new(MockRateAdminService)
+		mockService.On("DeleteRate", mock.Anything, int64(7), int64(1)).Return(fmt.Errorf("Service.DeleteRate: %w", models.ErrRateVersionConflict))
+
+		controller := NewRateAdminController(mockService, zap.NewNop())
+		_, err := controller.DeleteRate(context.Background(), &usdt_proto.DeleteRateRequest{Id: 7, Version: 1})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+}
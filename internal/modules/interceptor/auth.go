@@ -0,0 +1,205 @@
+// Package interceptor - перехватчики gRPC сервера: аутентификация и
+// проверка прав клиентов.
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	proto "usdt/internal/proto/usdt_proto"
+)
+
+const (
+	// APIKeyHeader - метаданные с API ключом.
+	APIKeyHeader = "x-api-key"
+	// AuthorizationHeader - метаданные с JWT в виде "Bearer <token>".
+	AuthorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+	reflectionPrefix    = "/grpc.reflection."
+)
+
+// methodScopes - право, нужное для каждого метода. Пустое право - метод
+// доступен без аутентификации. Методы вне таблицы запрещены всем.
+var methodScopes = map[string]string{
+	proto.AuthService_GetRates_FullMethodName:             models.ScopeRatesRead,
+	proto.AuthService_HealthCheck_FullMethodName:          "",
+	proto.QuoteService_CreateQuote_FullMethodName:         models.ScopeQuotes,
+	proto.QuoteService_RedeemQuote_FullMethodName:         models.ScopeQuotes,
+	proto.AlertService_CreateAlertRule_FullMethodName:     models.ScopeAlerts,
+	proto.AlertService_GetAlertRule_FullMethodName:        models.ScopeAlerts,
+	proto.AlertService_ListAlertRules_FullMethodName:      models.ScopeAlerts,
+	proto.AlertService_UpdateAlertRule_FullMethodName:     models.ScopeAlerts,
+	proto.AlertService_DeleteAlertRule_FullMethodName:     models.ScopeAlerts,
+	proto.AlertService_ListAlertDeliveries_FullMethodName: models.ScopeAlerts,
+	proto.MarketService_GetMarketStats_FullMethodName:     models.ScopeRatesRead,
+	proto.ArbitrageService_StreamArbitrage_FullMethodName: models.ScopeRatesRead,
+	proto.RateAdminService_GetRate_FullMethodName:         models.ScopeRatesRead,
+	proto.RateAdminService_ListRates_FullMethodName:       models.ScopeRatesRead,
+	proto.RateAdminService_UpdateRate_FullMethodName:      models.ScopeRatesWrite,
+	proto.RateAdminService_DeleteRate_FullMethodName:      models.ScopeRatesWrite,
+	proto.ExportService_ExportRates_FullMethodName:        models.ScopeRatesRead,
+	proto.KeyService_CreateAPIKey_FullMethodName:          models.ScopeAdmin,
+	proto.KeyService_ListAPIKeys_FullMethodName:           models.ScopeAdmin,
+	proto.KeyService_RevokeAPIKey_FullMethodName:          models.ScopeAdmin,
+}
+
+// Authenticator находит клиента по API ключу или JWT.
+type Authenticator interface {
+	Authenticate(ctx context.Context, key string) (models.Principal, error)
+	AuthenticateToken(token string) (models.Principal, error)
+}
+
+// publicMethod сообщает, доступен ли метод без аутентификации.
+func publicMethod(method string) bool {
+	scope, known := methodScopes[method]
+	return (known && scope == "") || strings.HasPrefix(method, reflectionPrefix)
+}
+
+// PrincipalFromContext возвращает клиента, аутентифицированного перехватчиком Auth.
+func PrincipalFromContext(ctx context.Context) (models.Principal, bool) {
+	return models.PrincipalFromContext(ctx)
+}
+
+// Auth аутентифицирует клиента по метаданным x-api-key или authorization и
+// проверяет право на метод и пары запроса. Пары проверяются у запросов с
+// target_currency или target_currencies; пару записи, к которой обращаются
+// по id, проверяют сервисы по клиенту из контекста.
+type Auth struct {
+	authn  Authenticator
+	logger *zap.Logger
+}
+
+func NewAuth(authn Authenticator, logger *zap.Logger) *Auth {
+	return &Auth{authn: authn, logger: logger}
+}
+
+func (a *Auth) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, principal, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			if err := a.checkPairs(*principal, info.FullMethod, req); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Auth) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, principal, err := a.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx, auth: a, principal: principal, method: info.FullMethod})
+	}
+}
+
+// authorize возвращает контекст с клиентом; для открытых методов клиент nil.
+func (a *Auth) authorize(ctx context.Context, method string) (context.Context, *models.Principal, error) {
+	if publicMethod(method) {
+		return ctx, nil, nil
+	}
+	scope, known := methodScopes[method]
+	if !known {
+		return nil, nil, status.Error(codes.PermissionDenied, models.ErrPermissionDenied.Error())
+	}
+
+	principal, err := a.authenticate(ctx)
+	if err != nil {
+		if errors.Is(err, models.ErrUnauthenticated) {
+			a.logger.Warn("Отказ в аутентификации", zap.String("method", method), zap.Error(err))
+			return nil, nil, status.Error(codes.Unauthenticated, models.ErrUnauthenticated.Error())
+		}
+		a.logger.Error("Ошибка аутентификации:", zap.String("method", method), zap.Error(err))
+		return nil, nil, status.Error(codes.Internal, "внутренняя ошибка")
+	}
+	if !principal.HasScope(scope) {
+		a.logger.Warn("Недостаточно прав", zap.String("method", method), zap.String("client", principal.Name), zap.String("scope", scope))
+		return nil, nil, status.Errorf(codes.PermissionDenied, "%s: нужно право %s", models.ErrPermissionDenied, scope)
+	}
+	return models.ContextWithPrincipal(ctx, principal), &principal, nil
+}
+
+func (a *Auth) authenticate(ctx context.Context) (models.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(APIKeyHeader); len(keys) > 0 {
+		return a.authn.Authenticate(ctx, keys[0])
+	}
+	if values := md.Get(AuthorizationHeader); len(values) > 0 {
+		if len(values[0]) <= len(bearerPrefix) || !strings.EqualFold(values[0][:len(bearerPrefix)], bearerPrefix) {
+			return models.Principal{}, models.ErrUnauthenticated
+		}
+		return a.authn.AuthenticateToken(values[0][len(bearerPrefix):])
+	}
+	return models.Principal{}, models.ErrUnauthenticated
+}
+
+// checkPairs проверяет, что клиенту доступны все пары запроса.
+func (a *Auth) checkPairs(principal models.Principal, method string, req any) error {
+	var currencies []string
+	switch r := req.(type) {
+	case interface{ GetTargetCurrency() string }:
+		currencies = []string{r.GetTargetCurrency()}
+	case interface{ GetTargetCurrencies() []string }:
+		currencies = r.GetTargetCurrencies()
+		if len(currencies) == 0 {
+			// Пустой список означает все пары.
+			currencies = []string{""}
+		}
+	default:
+		return nil
+	}
+	for _, currency := range currencies {
+		pair := ""
+		if currency != "" {
+			pair = models.CurrencyPair(currency)
+		}
+		if !principal.AllowsPair(pair) {
+			a.logger.Warn("Пара недоступна клиенту", zap.String("method", method), zap.String("client", principal.Name), zap.String("pair", pair))
+			return status.Errorf(codes.PermissionDenied, "%s: пара %s недоступна", models.ErrPermissionDenied, pairName(pair))
+		}
+	}
+	return nil
+}
+
+func pairName(pair string) string {
+	if pair == "" {
+		return "<все пары>"
+	}
+	return pair
+}
+
+// authorizedStream подставляет контекст с клиентом и проверяет пары
+// каждого принятого сообщения потока.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	auth      *Auth
+	principal *models.Principal
+	method    string
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.principal == nil {
+		return nil
+	}
+	return s.auth.checkPairs(*s.principal, s.method, m)
+}
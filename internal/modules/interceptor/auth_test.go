@@ -0,0 +1,147 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"usdt/internal/models"
+	proto "usdt/internal/proto/usdt_proto"
+)
+
+// fakeAuthenticator знает ключи и токены из своих таблиц.
+type fakeAuthenticator struct {
+	keys   map[string]models.Principal
+	tokens map[string]models.Principal
+	err    error
+}
+
+func (f fakeAuthenticator) Authenticate(_ context.Context, key string) (models.Principal, error) {
+	if f.err != nil {
+		return models.Principal{}, f.err
+	}
+	if principal, ok := f.keys[key]; ok {
+		return principal, nil
+	}
+	return models.Principal{}, models.ErrUnauthenticated
+}
+
+func (f fakeAuthenticator) AuthenticateToken(token string) (models.Principal, error) {
+	if principal, ok := f.tokens[token]; ok {
+		return principal, nil
+	}
+	return models.Principal{}, models.ErrUnauthenticated
+}
+
+var testAuthenticator = fakeAuthenticator{
+	keys: map[string]models.Principal{
+		"reader": {Name: "reader", Scopes: []string{models.ScopeRatesRead}},
+		"rub":    {Name: "rub", Scopes: []string{models.ScopeRatesRead}, Pairs: []string{"USDT/RUB"}},
+		"admin":  {Name: "admin", Scopes: []string{models.ScopeAdmin}},
+	},
+	tokens: map[string]models.Principal{
+		"jwt": {Name: "partner", Scopes: []string{models.ScopeQuotes}},
+	},
+}
+
+func incoming(kv ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+}
+
+func callUnary(t *testing.T, auth *Auth, ctx context.Context, method string, req any) (models.Principal, error) {
+	t.Helper()
+	var got models.Principal
+	_, err := auth.Unary()(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ any) (any, error) {
+		got, _ = PrincipalFromContext(ctx)
+		return nil, nil
+	})
+	return got, err
+}
+
+func TestAuth_Unary(t *testing.T) {
+	auth := NewAuth(testAuthenticator, zap.NewNop())
+	getRates := proto.AuthService_GetRates_FullMethodName
+	rub := &proto.GetRatesRequest{TargetCurrency: "RUB"}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		method    string
+		req       any
+		code      codes.Code
+		principal string
+	}{
+		{"APIKey", incoming(APIKeyHeader, "reader"), getRates, rub, codes.OK, "reader"},
+		{"JWT", incoming(AuthorizationHeader, "Bearer jwt"), proto.QuoteService_CreateQuote_FullMethodName, &proto.CreateQuoteRequest{TargetCurrency: "RUB"}, codes.OK, "partner"},
+		{"NoCredentials", context.Background(), getRates, rub, codes.Unauthenticated, ""},
+		{"UnknownKey", incoming(APIKeyHeader, "stolen"), getRates, rub, codes.Unauthenticated, ""},
+		{"NotBearer", incoming(AuthorizationHeader, "Basic jwt"), getRates, rub, codes.Unauthenticated, ""},
+		{"MissingScope", incoming(APIKeyHeader, "reader"), proto.RateAdminService_UpdateRate_FullMethodName, &proto.UpdateRateRequest{}, codes.PermissionDenied, ""},
+		{"AdminHasAllScopes", incoming(APIKeyHeader, "admin"), proto.RateAdminService_UpdateRate_FullMethodName, &proto.UpdateRateRequest{}, codes.OK, "admin"},
+		{"KeyServiceNeedsAdmin", incoming(APIKeyHeader, "reader"), proto.KeyService_ListAPIKeys_FullMethodName, &proto.ListAPIKeysRequest{}, codes.PermissionDenied, ""},
+		{"PublicHealthCheck", context.Background(), proto.AuthService_HealthCheck_FullMethodName, &proto.HealthCheckRequest{}, codes.OK, ""},
+		{"UnknownMethod", incoming(APIKeyHeader, "admin"), "/usdt.Unknown/Do", nil, codes.PermissionDenied, ""},
+		{"AllowedPair", incoming(APIKeyHeader, "rub"), getRates, &proto.GetRatesRequest{TargetCurrency: "rub"}, codes.OK, "rub"},
+		{"ForbiddenPair", incoming(APIKeyHeader, "rub"), getRates, &proto.GetRatesRequest{TargetCurrency: "USD"}, codes.PermissionDenied, ""},
+		{"AllPairsForRestrictedKey", incoming(APIKeyHeader, "rub"), proto.ExportService_ExportRates_FullMethodName, &proto.ExportRatesRequest{}, codes.PermissionDenied, ""},
+		{"ForbiddenPairInList", incoming(APIKeyHeader, "rub"), proto.ExportService_ExportRates_FullMethodName, &proto.ExportRatesRequest{TargetCurrencies: []string{"RUB", "USD"}}, codes.PermissionDenied, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := callUnary(t, auth, tt.ctx, tt.method, tt.req)
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.principal, principal.Name)
+		})
+	}
+
+	t.Run("StorageError", func(t *testing.T) {
+		broken := NewAuth(fakeAuthenticator{err: errors.New("база недоступна")}, zap.NewNop())
+		_, err := callUnary(t, broken, incoming(APIKeyHeader, "reader"), getRates, rub)
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+// fakeServerStream отдаёт одно сообщение запроса.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	req *proto.StreamArbitrageRequest
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) RecvMsg(m any) error {
+	m.(*proto.StreamArbitrageRequest).TargetCurrency = f.req.TargetCurrency
+	return nil
+}
+
+func TestAuth_Stream(t *testing.T) {
+	auth := NewAuth(testAuthenticator, zap.NewNop())
+	info := &grpc.StreamServerInfo{FullMethod: proto.ArbitrageService_StreamArbitrage_FullMethodName, IsServerStream: true}
+	handler := func(_ any, stream grpc.ServerStream) error {
+		principal, ok := PrincipalFromContext(stream.Context())
+		require.True(t, ok)
+		assert.Equal(t, "rub", principal.Name)
+		return stream.RecvMsg(&proto.StreamArbitrageRequest{})
+	}
+
+	stream := &fakeServerStream{ctx: incoming(APIKeyHeader, "rub"), req: &proto.StreamArbitrageRequest{TargetCurrency: "RUB"}}
+	assert.NoError(t, auth.Stream()(nil, stream, info, handler))
+
+	stream.req.TargetCurrency = ""
+	err := auth.Stream()(nil, stream, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "поток по всем парам недоступен ключу одной пары")
+
+	stream.ctx = context.Background()
+	err = auth.Stream()(nil, stream, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
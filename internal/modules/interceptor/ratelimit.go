@@ -0,0 +1,227 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"usdt/config"
+	"usdt/internal/infrastructure/tlsconfig"
+	"usdt/internal/models"
+)
+
+const (
+	// RetryAfterHeader - метаданные ответа с числом секунд до повтора запроса.
+	RetryAfterHeader = "retry-after"
+	// forwardedForHeader - адрес клиента, который REST шлюз передаёт в метаданных.
+	forwardedForHeader = "x-forwarded-for"
+	// GatewaySecretHeader - метаданные с секретом REST шлюза: только с ним
+	// принимается адрес клиента из X-Forwarded-For.
+	GatewaySecretHeader = "x-gateway-secret"
+	// sweepInterval - как часто удаляются вёдра клиентов, которые давно не обращались.
+	sweepInterval = time.Minute
+)
+
+// QuotaConsumer учитывает запрос клиента в суточной квоте.
+type QuotaConsumer interface {
+	Consume(ctx context.Context, client string) (time.Duration, error)
+}
+
+// RateLimit ограничивает частоту запросов каждого клиента алгоритмом token
+// bucket: у клиента своё ведро на каждый метод из conf.Methods и общее на
+// остальные методы. Сверх лимита и квоты запрос отклоняется с кодом
+// RESOURCE_EXHAUSTED, временем до повтора в метаданных retry-after и в
+// RetryInfo. Открытые методы (HealthCheck, reflection) не ограничиваются.
+// Квота расходуется только пропущенными запросами.
+type RateLimit struct {
+	conf          config.RateLimit
+	quota         QuotaConsumer
+	gatewaySecret string
+	logger        *zap.Logger
+	now           func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// refill - за сколько пустое ведро наполняется целиком.
+	refill time.Duration
+}
+
+// NewRateLimit создаёт ограничитель; при quota == nil суточной квоты нет.
+// gatewaySecret - секрет REST шлюза, см. ClientID.
+func NewRateLimit(conf config.RateLimit, quota QuotaConsumer, gatewaySecret string, logger *zap.Logger) *RateLimit {
+	return &RateLimit{
+		conf:          conf,
+		quota:         quota,
+		gatewaySecret: gatewaySecret,
+		logger:        logger,
+		now:           time.Now,
+		buckets:       make(map[string]*bucket),
+	}
+}
+
+func (l *RateLimit) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (l *RateLimit) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func (l *RateLimit) check(ctx context.Context, method string) error {
+	if publicMethod(method) {
+		return nil
+	}
+	client := ClientID(ctx, l.gatewaySecret)
+	name := method[strings.LastIndex(method, "/")+1:]
+	limit, own := l.conf.Methods[name]
+	if !own {
+		limit, name = l.conf.Default, "*"
+	}
+	if limit.RPS > 0 {
+		if wait, ok := l.take(client+" "+name, limit); !ok {
+			return l.reject(ctx, method, client, models.ErrRateLimited, wait)
+		}
+	}
+	if l.quota == nil {
+		return nil
+	}
+	wait, err := l.quota.Consume(ctx, client)
+	if errors.Is(err, models.ErrQuotaExceeded) {
+		return l.reject(ctx, method, client, err, wait)
+	}
+	if err != nil {
+		l.logger.Error("Ошибка учёта квоты:", zap.String("client", client), zap.Error(err))
+		if !l.conf.QuotaFailOpen {
+			return status.Error(codes.Unavailable, "не удалось проверить квоту, повторите запрос позже")
+		}
+	}
+	return nil
+}
+
+// take берёт токен из ведра key и при пустом ведре возвращает время до
+// появления следующего токена.
+func (l *RateLimit) take(key string, limit config.Limit) (time.Duration, bool) {
+	burst := float64(max(limit.Burst, 1))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now, refill: time.Duration(burst / limit.RPS * float64(time.Second))}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.RPS)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / limit.RPS * float64(time.Second)), false
+}
+
+// sweep удаляет вёдра, успевшие наполниться: новое ведро ведёт себя так же.
+func (l *RateLimit) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *RateLimit) reject(ctx context.Context, method, client string, reason error, wait time.Duration) error {
+	l.logger.Warn("Запрос отклонён ограничением", zap.String("method", method), zap.String("client", client),
+		zap.Error(reason), zap.Duration("retry_after", wait))
+	seconds := int64(math.Ceil(wait.Seconds()))
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.FormatInt(seconds, 10))); err != nil {
+		l.logger.Debug("Не удалось передать retry-after", zap.Error(err))
+	}
+	st, err := status.New(codes.ResourceExhausted, fmt.Sprintf("%s, повторите через %d с", reason, seconds)).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, reason.Error())
+	}
+	return st.Err()
+}
+
+// ClientID возвращает, кому засчитываются запросы: API ключу или субъекту
+// JWT из перехватчика Auth, клиенту REST шлюза, владельцу проверенного
+// клиентского сертификата, иначе IP адресу. Клиент шлюза - адрес из
+// X-Forwarded-For запроса, подписанного gatewaySecret в x-gateway-secret;
+// без секрета заголовок мог подставить сам клиент. Шлюз проверяется раньше
+// сертификата: при mTLS шлюз подключается со своим сертификатом, и иначе все
+// клиенты REST делили бы один лимит.
+func ClientID(ctx context.Context, gatewaySecret string) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.ID()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if forwarded := md.Get(forwardedForHeader); len(forwarded) > 0 && fromGateway(md, gatewaySecret) {
+		// Шлюз дописывает адрес своего клиента в конец списка; начало мог подставить сам клиент.
+		hops := strings.Split(forwarded[len(forwarded)-1], ",")
+		if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+			return "ip:" + last
+		}
+	}
+	if identity, ok := tlsconfig.Identity(ctx); ok {
+		return "cert:" + identity
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+// fromGateway сообщает, подписан ли запрос секретом REST шлюза.
+func fromGateway(md metadata.MD, secret string) bool {
+	if secret == "" {
+		return false
+	}
+	for _, value := range md.Get(GatewaySecretHeader) {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(secret)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"usdt/config"
+	"usdt/internal/models"
+	proto "usdt/internal/proto/usdt_proto"
+)
+
+// fakeQuota разрешает limit запросов каждому клиенту.
+type fakeQuota struct {
+	limit int
+	used  map[string]int
+	err   error
+}
+
+func (f *fakeQuota) Consume(_ context.Context, client string) (time.Duration, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.used[client]++
+	if f.used[client] > f.limit {
+		return time.Hour, models.ErrQuotaExceeded
+	}
+	return 0, nil
+}
+
+func fromPeer(addr string, kv ...string) context.Context {
+	tcp, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		panic(err)
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tcp})
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(kv...))
+}
+
+// fromCertPeer возвращает контекст запроса, пришедшего с проверенным
+// клиентским сертификатом commonName.
+func fromCertPeer(commonName string, kv ...string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	info := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}, AuthInfo: info})
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(kv...))
+}
+
+func TestRateLimit_Buckets(t *testing.T) {
+	conf := config.RateLimit{
+		Default: config.Limit{RPS: 1, Burst: 2},
+		Methods: map[string]config.Limit{"ExportRates": {RPS: 0.5, Burst: 1}},
+	}
+	limiter := NewRateLimit(conf, nil, "", zap.NewNop())
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	alice := fromPeer("10.0.0.1:5000")
+	bob := fromPeer("10.0.0.2:5000")
+	getRates := proto.AuthService_GetRates_FullMethodName
+	export := proto.ExportService_ExportRates_FullMethodName
+
+	assert.NoError(t, limiter.check(alice, getRates))
+	assert.NoError(t, limiter.check(alice, proto.MarketService_GetMarketStats_FullMethodName), "общее ведро на методы без своего лимита")
+	err := limiter.check(alice, getRates)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NoError(t, limiter.check(bob, getRates), "у каждого клиента своё ведро")
+	assert.NoError(t, limiter.check(alice, export), "у метода со своим лимитом отдельное ведро")
+	assert.Error(t, limiter.check(alice, export))
+
+	st, _ := status.FromError(err)
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, time.Second, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	now = now.Add(time.Second)
+	assert.NoError(t, limiter.check(alice, getRates), "токен восстанавливается за 1/RPS")
+	assert.Error(t, limiter.check(alice, getRates))
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, limiter.check(alice, proto.AuthService_HealthCheck_FullMethodName), "открытые методы не ограничиваются")
+	}
+
+	now = now.Add(time.Hour)
+	assert.NoError(t, limiter.check(bob, getRates))
+	assert.Len(t, limiter.buckets, 1, "наполнившиеся вёдра удаляются")
+}
+
+func TestRateLimit_Quota(t *testing.T) {
+	quota := &fakeQuota{limit: 2, used: map[string]int{}}
+	limiter := NewRateLimit(config.RateLimit{QuotaFailOpen: true}, quota, "", zap.NewNop())
+	ctx := fromPeer("10.0.0.1:5000")
+	method := proto.AuthService_GetRates_FullMethodName
+
+	assert.NoError(t, limiter.check(ctx, method))
+	assert.NoError(t, limiter.check(ctx, method))
+	err := limiter.check(ctx, method)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "3600 с")
+
+	quota.err = errors.New("база недоступна")
+	assert.NoError(t, limiter.check(ctx, method), "без базы запросы не блокируются")
+
+	limiter = NewRateLimit(config.RateLimit{QuotaFailOpen: false}, quota, "", zap.NewNop())
+	assert.Equal(t, codes.Unavailable, status.Code(limiter.check(ctx, method)), "без базы запросы отклоняются")
+}
+
+func TestClientID(t *testing.T) {
+	key := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "partner", KeyID: 7})
+	const secret = "gateway-secret"
+	assert.Equal(t, "key:7", ClientID(key, secret))
+	token := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "partner"})
+	assert.Equal(t, "jwt:partner", ClientID(token, secret))
+	assert.Equal(t, "ip:10.0.0.1", ClientID(fromPeer("10.0.0.1:5000"), secret))
+	assert.Equal(t, "ip:203.0.113.5", ClientID(fromPeer("127.0.0.1:5000", forwardedForHeader, "1.1.1.1, 203.0.113.5", GatewaySecretHeader, secret), secret))
+	assert.Equal(t, "ip:127.0.0.1", ClientID(fromPeer("127.0.0.1:5000", forwardedForHeader, "1.1.1.1"), secret), "без секрета X-Forwarded-For не учитывается даже с локального адреса")
+	assert.Equal(t, "ip:127.0.0.1", ClientID(fromPeer("127.0.0.1:5000", forwardedForHeader, "1.1.1.1", GatewaySecretHeader, "forged"), secret))
+	assert.Equal(t, "ip:127.0.0.1", ClientID(fromPeer("127.0.0.1:5000", forwardedForHeader, "1.1.1.1", GatewaySecretHeader, ""), ""), "пустой секрет не принимается")
+	assert.Equal(t, "ip:::1", ClientID(fromPeer("[::1]:5000"), secret))
+	assert.Equal(t, "cert:partner", ClientID(fromCertPeer("partner"), secret))
+	assert.Equal(t, "ip:203.0.113.5", ClientID(fromCertPeer("gateway", forwardedForHeader, "203.0.113.5", GatewaySecretHeader, secret), secret),
+		"при mTLS клиент шлюза определяется по X-Forwarded-For, а не по сертификату шлюза")
+	assert.Equal(t, "cert:gateway", ClientID(fromCertPeer("gateway", forwardedForHeader, "203.0.113.5"), secret))
+	assert.Equal(t, "ip:unknown", ClientID(context.Background(), secret))
+}
+
+type healthServer struct {
+	proto.UnimplementedAuthServiceServer
+}
+
+func (healthServer) GetRates(context.Context, *proto.GetRatesRequest) (*proto.GetRatesResponse, error) {
+	return &proto.GetRatesResponse{}, nil
+}
+
+func TestRateLimit_RetryAfterHeader(t *testing.T) {
+	limiter := NewRateLimit(config.RateLimit{Default: config.Limit{RPS: 0.25, Burst: 1}}, nil, "", zap.NewNop())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(limiter.Unary()))
+	proto.RegisterAuthServiceServer(server, healthServer{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := proto.NewAuthServiceClient(conn)
+	ctx := context.Background()
+
+	_, err = client.GetRates(ctx, &proto.GetRatesRequest{TargetCurrency: "RUB"})
+	require.NoError(t, err)
+	var header metadata.MD
+	_, err = client.GetRates(ctx, &proto.GetRatesRequest{TargetCurrency: "RUB"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"4"}, header.Get(RetryAfterHeader))
+}
@@ -0,0 +1,124 @@
+package poller
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Poller периодически запрашивает курсы по списку валют и передаёт каждый
+// новый снимок обработчикам. Если курсы пишутся пакетами, после каждого
+// прохода буфер сбрасывается через flusher, чтобы ошибка записи дошла до опроса.
+type Poller struct {
+	source     RateSource
+	flusher    Flusher
+	currencies []string
+	interval   time.Duration
+	logger     *zap.Logger
+	handlers   []SnapshotHandler
+}
+
+// NewPoller создаёт опрос; flusher может быть nil, если курсы пишутся сразу.
+func NewPoller(source RateSource, flusher Flusher, currencies []string, interval time.Duration, logger *zap.Logger, handlers ...SnapshotHandler) *Poller {
+	return &Poller{
+		source:     source,
+		flusher:    flusher,
+		currencies: currencies,
+		interval:   interval,
+		logger:     logger,
+		handlers:   handlers,
+	}
+}
+
+// Run опрашивает источник до отмены ctx. Нулевой интервал отключает опрос.
+func (p *Poller) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		p.logger.Info("Опрос курсов отключён")
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll выполняет один проход по всем валютам.
+func (p *Poller) Poll(ctx context.Context) {
+	for _, currency := range p.currencies {
+		if ctx.Err() != nil {
+			return
+		}
+		p.poll(ctx, currency)
+	}
+	p.flush(ctx)
+}
+
+// Follow запрашивает курс валюты по уведомлениям updates до отмены ctx; так
+// курсы потокового источника сохраняются при изменении стакана. Курс одной
+// валюты запрашивается не чаще раза в minInterval: изменения между
+// запросами объединяются, и по последнему из них курс запрашивается, когда
+// интервал истечёт. Буфер сбрасывается, когда уведомления заканчиваются.
+func (p *Poller) Follow(ctx context.Context, updates <-chan string, minInterval time.Duration) {
+	last := make(map[string]time.Time)
+	pending := make(map[string]bool)
+	var wake <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case currency := <-updates:
+			pending[currency] = true
+		case <-wake:
+			wake = nil
+		}
+
+		polled := false
+		next := time.Duration(-1)
+		for currency := range pending {
+			if wait := time.Until(last[currency].Add(minInterval)); wait > 0 {
+				if next < 0 || wait < next {
+					next = wait
+				}
+				continue
+			}
+			delete(pending, currency)
+			last[currency] = time.Now()
+			p.poll(ctx, currency)
+			polled = true
+		}
+		if polled && len(updates) == 0 {
+			p.flush(ctx)
+		}
+		if next >= 0 && wake == nil {
+			wake = time.After(next)
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context, currency string) {
+	rate, err := p.source.GetRates(ctx, currency)
+	if err != nil {
+		p.logger.Error("Poller.Poll error:", zap.String("currency", currency), zap.Error(err))
+		return
+	}
+	for _, handler := range p.handlers {
+		if err := handler.HandleSnapshot(ctx, rate); err != nil {
+			p.logger.Error("Poller.HandleSnapshot error:", zap.String("pair", rate.Pair), zap.Error(err))
+		}
+	}
+}
+
+func (p *Poller) flush(ctx context.Context) {
+	if p.flusher != nil && ctx.Err() == nil {
+		if err := p.flusher.Flush(ctx); err != nil {
+			p.logger.Error("Poller.Flush error:", zap.Error(err))
+		}
+	}
+}
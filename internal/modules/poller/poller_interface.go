@@ -0,0 +1,20 @@
+package poller
+
+import (
+	"context"
+	"usdt/internal/models"
+)
+
+type RateSource interface {
+	GetRates(ctx context.Context, pair string) (models.CurrencyRate, error)
+}
+
+// SnapshotHandler получает каждый новый сохранённый снимок курса.
+type SnapshotHandler interface {
+	HandleSnapshot(ctx context.Context, rate models.CurrencyRate) error
+}
+
+// Flusher дописывает в базу курсы, накопленные за проход опроса.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
@@ -0,0 +1,138 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"usdt/internal/models"
+)
+
+type MockRateSource struct {
+	mock.Mock
+}
+
+func (m *MockRateSource) GetRates(ctx context.Context, pair string) (models.CurrencyRate, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).(models.CurrencyRate), args.Error(1)
+}
+
+type MockSnapshotHandler struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotHandler) HandleSnapshot(ctx context.Context, rate models.CurrencyRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func TestPoller_Poll(t *testing.T) {
+	rub := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: time.Now()}
+	usd := models.CurrencyRate{Pair: "USDT/USD", AskPrice: 1.01, BidPrice: 0.99, Timestamp: time.Now()}
+
+	mockSource := new(MockRateSource)
+	mockSource.On("GetRates", mock.Anything, "RUB").Return(rub, nil)
+	mockSource.On("GetRates", mock.Anything, "EUR").Return(models.CurrencyRate{}, errors.New("API error"))
+	mockSource.On("GetRates", mock.Anything, "USD").Return(usd, nil)
+	mockHandler := new(MockSnapshotHandler)
+	mockHandler.On("HandleSnapshot", mock.Anything, rub).Return(errors.New("handler error"))
+	mockHandler.On("HandleSnapshot", mock.Anything, usd).Return(nil)
+
+	p := NewPoller(mockSource, nil, []string{"RUB", "EUR", "USD"}, time.Second, zap.NewNop(), mockHandler)
+	p.Poll(context.Background())
+
+	mockSource.AssertNumberOfCalls(t, "GetRates", 3)
+	mockHandler.AssertNumberOfCalls(t, "HandleSnapshot", 2)
+}
+
+type MockFlusher struct {
+	mock.Mock
+}
+
+func (m *MockFlusher) Flush(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func TestPoller_PollFlushes(t *testing.T) {
+	mockSource := new(MockRateSource)
+	mockSource.On("GetRates", mock.Anything, mock.Anything).Return(models.CurrencyRate{Pair: "USDT/RUB"}, nil)
+	mockFlusher := new(MockFlusher)
+	mockFlusher.On("Flush", mock.Anything).Return(errors.New("db error")).Once()
+
+	p := NewPoller(mockSource, mockFlusher, []string{"RUB", "USD"}, time.Second, zap.NewNop())
+	p.Poll(context.Background())
+
+	mockSource.AssertNumberOfCalls(t, "GetRates", 2)
+	mockFlusher.AssertNumberOfCalls(t, "Flush", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Poll(ctx)
+	mockFlusher.AssertNumberOfCalls(t, "Flush", 1)
+}
+
+func TestPoller_RunDisabled(t *testing.T) {
+	mockSource := new(MockRateSource)
+	p := NewPoller(mockSource, nil, []string{"RUB"}, 0, zap.NewNop())
+	p.Run(context.Background())
+	mockSource.AssertNotCalled(t, "GetRates", mock.Anything, mock.Anything)
+}
+
+func TestPoller_RunStopsOnCancel(t *testing.T) {
+	mockSource := new(MockRateSource)
+	mockSource.On("GetRates", mock.Anything, "RUB").Return(models.CurrencyRate{Pair: "USDT/RUB"}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p := NewPoller(mockSource, nil, []string{"RUB"}, time.Millisecond, zap.NewNop())
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+	assert.NotZero(t, len(mockSource.Calls))
+}
+
+func TestPoller_Follow(t *testing.T) {
+	var rub, usd atomic.Int32
+	mockSource := new(MockRateSource)
+	mockSource.On("GetRates", mock.Anything, "RUB").Return(models.CurrencyRate{Pair: "USDT/RUB"}, nil).
+		Run(func(mock.Arguments) { rub.Add(1) })
+	mockSource.On("GetRates", mock.Anything, "USD").Return(models.CurrencyRate{Pair: "USDT/USD"}, nil).
+		Run(func(mock.Arguments) { usd.Add(1) })
+	mockFlusher := new(MockFlusher)
+	mockFlusher.On("Flush", mock.Anything).Return(nil)
+
+	updates := make(chan string, 4)
+	updates <- "RUB"
+	updates <- "USD"
+	updates <- "RUB"
+	updates <- "RUB"
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPoller(mockSource, mockFlusher, nil, 0, zap.NewNop())
+	done := make(chan struct{})
+	go func() {
+		p.Follow(ctx, updates, 100*time.Millisecond)
+		close(done)
+	}()
+
+	// Повторные изменения RUB внутри интервала дают один отложенный запрос.
+	assert.Eventually(t, func() bool { return rub.Load() == 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(2), rub.Load())
+	assert.Equal(t, int32(1), usd.Load())
+	mockFlusher.AssertCalled(t, "Flush", mock.Anything)
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"usdt/internal/infrastructure/metrics"
+	"usdt/internal/infrastructure/webhook"
+	"usdt/internal/models"
+)
+
+const (
+	maxAlertBackoff = time.Minute
+	// alertWorkers ограничивает число одновременных доставок: медленный
+	// получатель занимает воркер, а не порождает новые горутины.
+	alertWorkers   = 8
+	alertQueueSize = 100
+)
+
+type alertDelivery struct {
+	rule    models.AlertRule
+	payload []byte
+}
+
+// AlertPayload - тело вебхука о срабатывании правила.
+type AlertPayload struct {
+	RuleID    int64     `json:"rule_id"`
+	Pair      string    `json:"pair"`
+	Condition string    `json:"condition"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	AskPrice  float64   `json:"ask_price"`
+	BidPrice  float64   `json:"bid_price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type AlertService struct {
+	storage     AlertServicer
+	history     HistorySource
+	sender      WebhookSender
+	logger      *zap.Logger
+	maxAttempts int
+	backoff     time.Duration
+	queue       chan alertDelivery
+
+	mu     sync.Mutex
+	active map[int64]bool
+}
+
+func NewAlertService(storage AlertServicer, history HistorySource, sender WebhookSender, logger *zap.Logger, maxAttempts int, backoff time.Duration) *AlertService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &AlertService{
+		storage:     storage,
+		history:     history,
+		sender:      sender,
+		logger:      logger,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		queue:       make(chan alertDelivery, alertQueueSize),
+		active:      make(map[int64]bool),
+	}
+}
+
+func (a *AlertService) CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	if err := validateAlertRule(rule); err != nil {
+		return models.AlertRule{}, fmt.Errorf("Service.CreateRule: %w", err)
+	}
+	if rule.Secret == "" {
+		secret, err := newAlertSecret()
+		if err != nil {
+			return models.AlertRule{}, fmt.Errorf("Service.CreateRule: %w", err)
+		}
+		rule.Secret = secret
+	}
+	rule.Owner = ""
+	if principal, ok := models.PrincipalFromContext(ctx); ok {
+		rule.Owner = principal.ID()
+	}
+	created, err := a.storage.CreateRule(ctx, rule)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("Service.CreateRule: %w", err)
+	}
+	return created, nil
+}
+
+func (a *AlertService) GetRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	rule, err := a.ownRule(ctx, id)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("Service.GetRule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListRules возвращает правила пары, доступные клиенту запроса.
+func (a *AlertService) ListRules(ctx context.Context, pair string) ([]models.AlertRule, error) {
+	rules, err := a.storage.ListRules(ctx, pair)
+	if err != nil {
+		return nil, fmt.Errorf("Service.ListRules: %w", err)
+	}
+	owned := make([]models.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		if ownsRecord(ctx, rule.Owner) {
+			owned = append(owned, rule)
+		}
+	}
+	return owned, nil
+}
+
+// UpdateRule сохраняет правило; пустой секрет означает "оставить прежний".
+func (a *AlertService) UpdateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	if err := validateAlertRule(rule); err != nil {
+		return models.AlertRule{}, fmt.Errorf("Service.UpdateRule: %w", err)
+	}
+	current, err := a.ownRule(ctx, rule.ID)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("Service.UpdateRule: %w", err)
+	}
+	if rule.Secret == "" {
+		rule.Secret = current.Secret
+	}
+	rule.Owner = current.Owner
+	rule.CreatedAt = current.CreatedAt
+	if err := a.storage.UpdateRule(ctx, rule); err != nil {
+		return models.AlertRule{}, fmt.Errorf("Service.UpdateRule: %w", err)
+	}
+	a.setActive(rule.ID, false)
+	return rule, nil
+}
+
+func (a *AlertService) DeleteRule(ctx context.Context, id int64) error {
+	if _, err := a.ownRule(ctx, id); err != nil {
+		return fmt.Errorf("Service.DeleteRule: %w", err)
+	}
+	if err := a.storage.DeleteRule(ctx, id); err != nil {
+		return fmt.Errorf("Service.DeleteRule: %w", err)
+	}
+	a.setActive(id, false)
+	return nil
+}
+
+func (a *AlertService) ListDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error) {
+	if _, err := a.ownRule(ctx, ruleID); err != nil {
+		return nil, fmt.Errorf("Service.ListDeliveries: %w", err)
+	}
+	deliveries, err := a.storage.ListDeliveries(ctx, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Service.ListDeliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ownRule читает правило клиента запроса. Чужое правило для клиента не
+// существует, поэтому вместо отказа в доступе возвращается
+// models.ErrAlertRuleNotFound.
+func (a *AlertService) ownRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	rule, err := a.storage.GetRule(ctx, id)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	if !ownsRecord(ctx, rule.Owner) {
+		return models.AlertRule{}, models.ErrAlertRuleNotFound
+	}
+	return rule, nil
+}
+
+// HandleSnapshot проверяет правила пары на новом курсе. Вебхук ставится в
+// очередь только при переходе условия из ложного в истинное, чтобы правило
+// не срабатывало на каждом снимке, пока условие держится. Ошибка одного
+// правила не мешает проверить остальные: ошибки объединяются. Если очередь
+// переполнена, оповещение отбрасывается, попадает в метрику
+// usdt_alerts_dropped_total, а правило снова срабатывает на следующем снимке.
+func (a *AlertService) HandleSnapshot(ctx context.Context, rate models.CurrencyRate) error {
+	rules, err := a.storage.ListRules(ctx, rate.Pair)
+	if err != nil {
+		return fmt.Errorf("Service.HandleSnapshot: %w", err)
+	}
+	var errs []error
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		fired, value, err := a.check(ctx, rule, rate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("правило %d: %w", rule.ID, err))
+			continue
+		}
+		if !a.setActive(rule.ID, fired) || !fired {
+			continue
+		}
+		payload, err := json.Marshal(AlertPayload{
+			RuleID:    rule.ID,
+			Pair:      rate.Pair,
+			Condition: rule.Condition,
+			Threshold: rule.Threshold,
+			Value:     value,
+			AskPrice:  rate.AskPrice,
+			BidPrice:  rate.BidPrice,
+			Timestamp: rate.Timestamp,
+		})
+		if err != nil {
+			a.setActive(rule.ID, false)
+			errs = append(errs, fmt.Errorf("правило %d: %w", rule.ID, err))
+			continue
+		}
+		select {
+		case a.queue <- alertDelivery{rule: rule, payload: payload}:
+		default:
+			a.setActive(rule.ID, false)
+			metrics.AlertsDropped.Inc()
+			a.logger.Warn("Очередь вебхуков переполнена, оповещение пропущено", zap.Int64("rule_id", rule.ID))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("Service.HandleSnapshot: %w", err)
+	}
+	return nil
+}
+
+// Run доставляет вебхуки из очереди фиксированным пулом воркеров до отмены ctx.
+// Когда все воркеры заняты и очередь заполнена, HandleSnapshot отбрасывает
+// оповещения и учитывает их в метрике AlertsDropped.
+func (a *AlertService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < alertWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-a.queue:
+					a.deliver(ctx, d)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (a *AlertService) deliver(ctx context.Context, d alertDelivery) {
+	for attempt := 1; attempt <= a.maxAttempts; attempt++ {
+		code, err := a.sender.Send(ctx, d.rule.WebhookURL, d.rule.Secret, d.payload)
+		record := models.AlertDelivery{
+			RuleID:     d.rule.ID,
+			Attempt:    attempt,
+			StatusCode: code,
+			Delivered:  err == nil,
+			Payload:    string(d.payload),
+			CreatedAt:  time.Now(),
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if logErr := a.storage.CreateDelivery(context.WithoutCancel(ctx), record); logErr != nil {
+			a.logger.Error("Service.deliver error:", zap.Error(logErr))
+		}
+		if err == nil {
+			return
+		}
+		a.logger.Warn("Не удалось доставить вебхук", zap.Int64("rule_id", d.rule.ID), zap.Int("attempt", attempt), zap.Error(err))
+		if attempt == a.maxAttempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.retryDelay(attempt)):
+		}
+	}
+}
+
+func (a *AlertService) retryDelay(attempt int) time.Duration {
+	delay := a.backoff << (attempt - 1)
+	if delay <= 0 || delay > maxAlertBackoff {
+		return maxAlertBackoff
+	}
+	return delay
+}
+
+// setActive запоминает состояние правила и сообщает, изменилось ли оно.
+func (a *AlertService) setActive(id int64, active bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	changed := a.active[id] != active
+	if active {
+		a.active[id] = true
+	} else {
+		delete(a.active, id)
+	}
+	return changed
+}
+
+func (a *AlertService) check(ctx context.Context, rule models.AlertRule, rate models.CurrencyRate) (bool, float64, error) {
+	switch rule.Condition {
+	case models.AlertBidAbove:
+		return rate.BidPrice > rule.Threshold, rate.BidPrice, nil
+	case models.AlertBidBelow:
+		return rate.BidPrice < rule.Threshold, rate.BidPrice, nil
+	case models.AlertAskAbove:
+		return rate.AskPrice > rule.Threshold, rate.AskPrice, nil
+	case models.AlertAskBelow:
+		return rate.AskPrice < rule.Threshold, rate.AskPrice, nil
+	case models.AlertSpreadAbove:
+		spread := spreadPercent(rate)
+		return spread > rule.Threshold, spread, nil
+	case models.AlertChangeAbove:
+		history, err := a.history.GetHistory(ctx, rule.Pair, rate.Timestamp.Add(-rule.Window()), rate.Timestamp)
+		if err != nil {
+			return false, 0, err
+		}
+		if len(history) == 0 {
+			return false, 0, nil
+		}
+		base := midPrice(history[0])
+		if base == 0 {
+			return false, 0, nil
+		}
+		change := math.Abs(midPrice(rate)-base) / base * 100
+		return change > rule.Threshold, change, nil
+	}
+	return false, 0, nil
+}
+
+func midPrice(rate models.CurrencyRate) float64 {
+	return (rate.AskPrice + rate.BidPrice) / 2
+}
+
+func spreadPercent(rate models.CurrencyRate) float64 {
+	mid := midPrice(rate)
+	if mid == 0 {
+		return 0
+	}
+	return (rate.AskPrice - rate.BidPrice) / mid * 100
+}
+
+func validateAlertRule(rule models.AlertRule) error {
+	switch rule.Condition {
+	case models.AlertBidAbove, models.AlertBidBelow, models.AlertAskAbove, models.AlertAskBelow, models.AlertSpreadAbove:
+	case models.AlertChangeAbove:
+		if rule.WindowSeconds <= 0 {
+			return fmt.Errorf("%w: для %s нужно окно window_seconds", models.ErrInvalidAlertRule, rule.Condition)
+		}
+	default:
+		return fmt.Errorf("%w: неизвестное условие %q", models.ErrInvalidAlertRule, rule.Condition)
+	}
+	if rule.Pair == "" {
+		return fmt.Errorf("%w: не указана валютная пара", models.ErrInvalidAlertRule)
+	}
+	if rule.Threshold <= 0 {
+		return fmt.Errorf("%w: порог должен быть положительным", models.ErrInvalidAlertRule)
+	}
+	u, err := url.Parse(rule.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: некорректный webhook_url", models.ErrInvalidAlertRule)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: webhook_url указывает на внутренний адрес", models.ErrInvalidAlertRule)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !webhook.IsPublic(addr) {
+		return fmt.Errorf("%w: webhook_url указывает на внутренний адрес", models.ErrInvalidAlertRule)
+	}
+	return nil
+}
+
+func newAlertSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать секрет вебхука: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"usdt/internal/infrastructure/metrics"
+	"usdt/internal/infrastructure/webhook"
+	"usdt/internal/models"
+)
+
+// MockAlertStorage - mock для интерфейса AlertServicer
+type MockAlertStorage struct {
+	mock.Mock
+	mu         sync.Mutex
+	deliveries []models.AlertDelivery
+}
+
+func (m *MockAlertStorage) CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	args := m.Called(ctx, rule)
+	return args.Get(0).(models.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertStorage) GetRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertStorage) ListRules(ctx context.Context, pair string) ([]models.AlertRule, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).([]models.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertStorage) UpdateRule(ctx context.Context, rule models.AlertRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockAlertStorage) DeleteRule(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAlertStorage) CreateDelivery(ctx context.Context, delivery models.AlertDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *MockAlertStorage) ListDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error) {
+	args := m.Called(ctx, ruleID, limit)
+	return args.Get(0).([]models.AlertDelivery), args.Error(1)
+}
+
+// MockHistorySource - mock для интерфейса HistorySource
+type MockHistorySource struct {
+	mock.Mock
+}
+
+func (m *MockHistorySource) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	args := m.Called(ctx, pair, from, to)
+	return args.Get(0).([]models.CurrencyRate), args.Error(1)
+}
+
+func TestAlertService_CreateRule(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rule := models.AlertRule{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 100, WebhookURL: "http://example.com/hook", Enabled: true}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("CreateRule", mock.Anything, mock.MatchedBy(func(r models.AlertRule) bool {
+			return len(r.Secret) == 64
+		})).Return(models.AlertRule{ID: 1}, nil)
+
+		service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		created, err := service.CreateRule(context.Background(), rule)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), created.ID)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		rules := []models.AlertRule{
+			{Pair: "USDT/RUB", Condition: "unknown", Threshold: 1, WebhookURL: "http://example.com"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 0, WebhookURL: "http://example.com"},
+			{Pair: "USDT/RUB", Condition: models.AlertChangeAbove, Threshold: 1, WebhookURL: "http://example.com"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "ftp://example.com"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "http://localhost:8080/hook"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "http://127.0.0.1/hook"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "http://10.0.0.5/hook"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "http://169.254.169.254/latest/meta-data"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "http://[::1]/hook"},
+			{Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, WebhookURL: "http://[::ffff:192.168.1.1]/hook"},
+		}
+		service := NewAlertService(new(MockAlertStorage), new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		for _, rule := range rules {
+			_, err := service.CreateRule(context.Background(), rule)
+			assert.ErrorIs(t, err, models.ErrInvalidAlertRule)
+		}
+	})
+}
+
+func TestAlertService_Owner(t *testing.T) {
+	owner := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "partner", KeyID: 1, Scopes: []string{models.ScopeAlerts}})
+	other := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "other", KeyID: 2, Scopes: []string{models.ScopeAlerts}})
+	admin := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "admin", KeyID: 3, Scopes: []string{models.ScopeAdmin}})
+	own := models.AlertRule{ID: 1, Owner: "key:1", Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 100, WebhookURL: "http://example.com/hook", Enabled: true}
+	foreign := models.AlertRule{ID: 2, Owner: "key:2", Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 100, WebhookURL: "http://example.com/hook", Enabled: true}
+	legacy := models.AlertRule{ID: 3, Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 100, WebhookURL: "http://example.com/hook", Enabled: true}
+
+	mockStorage := new(MockAlertStorage)
+	mockStorage.On("CreateRule", mock.Anything, mock.MatchedBy(func(r models.AlertRule) bool {
+		return r.Owner == "key:1"
+	})).Return(own, nil)
+	mockStorage.On("GetRule", mock.Anything, int64(1)).Return(own, nil)
+	mockStorage.On("GetRule", mock.Anything, int64(2)).Return(foreign, nil)
+	mockStorage.On("ListRules", mock.Anything, "").Return([]models.AlertRule{own, foreign, legacy}, nil)
+	service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+
+	rule := own
+	rule.ID, rule.Owner = 0, "key:2"
+	_, err := service.CreateRule(owner, rule)
+	require.NoError(t, err, "владелец берётся из клиента, а не из запроса")
+
+	rules, err := service.ListRules(owner, "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.AlertRule{own}, rules)
+	rules, err = service.ListRules(admin, "")
+	require.NoError(t, err)
+	assert.Len(t, rules, 3)
+
+	_, err = service.GetRule(other, 1)
+	assert.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	_, err = service.UpdateRule(other, own)
+	assert.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	assert.ErrorIs(t, service.DeleteRule(other, 1), models.ErrAlertRuleNotFound)
+	_, err = service.ListDeliveries(other, 1, 10)
+	assert.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	mockStorage.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "DeleteRule", mock.Anything, mock.Anything)
+
+	got, err := service.GetRule(admin, 2)
+	require.NoError(t, err)
+	assert.Equal(t, foreign, got)
+}
+
+func TestAlertService_HandleSnapshot(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	t.Run("EdgeTriggered", func(t *testing.T) {
+		rule := models.AlertRule{ID: 1, Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 100, Enabled: true}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{rule}, nil)
+
+		service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		for _, bid := range []float64{101, 102, 99, 103} {
+			err := service.HandleSnapshot(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: bid + 1, BidPrice: bid, Timestamp: now})
+			require.NoError(t, err)
+		}
+		assert.Len(t, service.queue, 2)
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		rule := models.AlertRule{ID: 2, Pair: "USDT/RUB", Condition: models.AlertSpreadAbove, Threshold: 2, Enabled: true}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{rule}, nil)
+
+		service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		err := service.HandleSnapshot(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 103, BidPrice: 100, Timestamp: now})
+		require.NoError(t, err)
+		require.Len(t, service.queue, 1)
+
+		var payload AlertPayload
+		require.NoError(t, json.Unmarshal((<-service.queue).payload, &payload))
+		assert.InDelta(t, 2.955, payload.Value, 0.001)
+	})
+
+	t.Run("ChangeInWindow", func(t *testing.T) {
+		rule := models.AlertRule{ID: 3, Pair: "USDT/RUB", Condition: models.AlertChangeAbove, Threshold: 1, WindowSeconds: 300, Enabled: true}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{rule}, nil)
+		mockHistory := new(MockHistorySource)
+		mockHistory.On("GetHistory", mock.Anything, "USDT/RUB", now.Add(-5*time.Minute), now).Return([]models.CurrencyRate{
+			{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 99, Timestamp: now.Add(-4 * time.Minute)},
+		}, nil)
+
+		service := NewAlertService(mockStorage, mockHistory, webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		err := service.HandleSnapshot(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 102.5, BidPrice: 100.5, Timestamp: now})
+		require.NoError(t, err)
+		assert.Len(t, service.queue, 1)
+	})
+
+	t.Run("DisabledRule", func(t *testing.T) {
+		rule := models.AlertRule{ID: 4, Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 1, Enabled: false}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{rule}, nil)
+
+		service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		err := service.HandleSnapshot(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now})
+		require.NoError(t, err)
+		assert.Len(t, service.queue, 0)
+	})
+
+	t.Run("ErrorDoesNotStopOtherRules", func(t *testing.T) {
+		broken := models.AlertRule{ID: 5, Pair: "USDT/RUB", Condition: models.AlertChangeAbove, Threshold: 1, WindowSeconds: 60, Enabled: true}
+		rule := models.AlertRule{ID: 6, Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 50, Enabled: true}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{broken, rule}, nil)
+		mockHistory := new(MockHistorySource)
+		mockHistory.On("GetHistory", mock.Anything, "USDT/RUB", mock.Anything, mock.Anything).Return([]models.CurrencyRate(nil), errors.New("history error"))
+
+		service := NewAlertService(mockStorage, mockHistory, webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		err := service.HandleSnapshot(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now})
+		assert.ErrorContains(t, err, "правило 5")
+		assert.Len(t, service.queue, 1, "исправное правило проверено")
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		rule := models.AlertRule{ID: 7, Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 50, Enabled: true}
+		mockStorage := new(MockAlertStorage)
+		mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{rule}, nil)
+
+		service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, false), zap.NewNop(), 1, 0)
+		service.queue = make(chan alertDelivery, 1)
+		service.queue <- alertDelivery{}
+		dropped := testutil.ToFloat64(metrics.AlertsDropped)
+		snapshot := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now}
+		require.NoError(t, service.HandleSnapshot(context.Background(), snapshot))
+		assert.Equal(t, dropped+1, testutil.ToFloat64(metrics.AlertsDropped))
+
+		// Отброшенное оповещение отправляется на следующем снимке.
+		<-service.queue
+		require.NoError(t, service.HandleSnapshot(context.Background(), snapshot))
+		assert.Len(t, service.queue, 1)
+	})
+}
+
+func TestAlertService_Deliver(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify("secret", r.Header.Get(webhook.TimestampHeader), body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	rule := models.AlertRule{ID: 1, Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 100, WebhookURL: server.URL, Secret: "secret", Enabled: true}
+	mockStorage := new(MockAlertStorage)
+	mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return([]models.AlertRule{rule}, nil)
+
+	service := NewAlertService(mockStorage, new(MockHistorySource), webhook.NewSender(time.Second, true), zap.NewNop(), 5, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx)
+		close(done)
+	}()
+
+	err := service.HandleSnapshot(ctx, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 102, BidPrice: 101, Timestamp: time.Now()})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mockStorage.mu.Lock()
+		defer mockStorage.mu.Unlock()
+		return len(mockStorage.deliveries) == 3
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.False(t, mockStorage.deliveries[0].Delivered)
+	assert.Equal(t, http.StatusServiceUnavailable, mockStorage.deliveries[0].StatusCode)
+	assert.True(t, mockStorage.deliveries[2].Delivered)
+	assert.Equal(t, 3, mockStorage.deliveries[2].Attempt)
+}
+
+// blockingSender держит доставку, пока не закроют release, и запоминает
+// наибольшее число одновременных отправок.
+type blockingSender struct {
+	release  chan struct{}
+	inFlight int32
+	peak     int32
+}
+
+func (s *blockingSender) Send(ctx context.Context, url, secret string, payload []byte) (int, error) {
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&s.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, n) {
+			break
+		}
+	}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+	}
+	return http.StatusOK, nil
+}
+
+func TestAlertService_RunWorkerPool(t *testing.T) {
+	var rules []models.AlertRule
+	for i := 1; i <= alertWorkers*3; i++ {
+		rules = append(rules, models.AlertRule{ID: int64(i), Pair: "USDT/RUB", Condition: models.AlertBidAbove, Threshold: 50, WebhookURL: "http://example.com/hook", Enabled: true})
+	}
+	mockStorage := new(MockAlertStorage)
+	mockStorage.On("ListRules", mock.Anything, "USDT/RUB").Return(rules, nil)
+	sender := &blockingSender{release: make(chan struct{})}
+
+	service := NewAlertService(mockStorage, new(MockHistorySource), sender, zap.NewNop(), 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx)
+		close(done)
+	}()
+
+	require.NoError(t, service.HandleSnapshot(ctx, models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: time.Now()}))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&sender.inFlight) == alertWorkers
+	}, time.Second, 5*time.Millisecond)
+	// Остальные доставки ждут в очереди, а не в отдельных горутинах.
+	assert.Len(t, service.queue, len(rules)-alertWorkers)
+
+	close(sender.release)
+	assert.Eventually(t, func() bool {
+		mockStorage.mu.Lock()
+		defer mockStorage.mu.Unlock()
+		return len(mockStorage.deliveries) == len(rules)
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, int32(alertWorkers), atomic.LoadInt32(&sender.peak))
+}
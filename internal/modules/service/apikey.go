@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"usdt/internal/infrastructure/jwt"
+	"usdt/internal/models"
+)
+
+const (
+	// apiKeyMarker начинает каждый ключ, чтобы его было легко узнать в
+	// конфигурации и найти утёкшим в логах.
+	apiKeyMarker = "usdt_"
+	// apiKeyPrefixLen - сколько первых символов ключа хранится открыто для списка ключей.
+	apiKeyPrefixLen = len(apiKeyMarker) + 6
+)
+
+// APIKeyService выпускает API ключи и аутентифицирует клиентов по ключу или
+// JWT. Ключи случайны и длинны, поэтому хранится их SHA-256 без соли: ключ
+// находится по хешу одним запросом, а подобрать его по хешу нельзя.
+type APIKeyService struct {
+	storage   APIKeyServicer
+	jwtSecret []byte
+	now       func() time.Time
+}
+
+// NewAPIKeyService создаёт сервис; при пустом jwtSecret JWT не принимаются.
+func NewAPIKeyService(storage APIKeyServicer, jwtSecret string) *APIKeyService {
+	return &APIKeyService{
+		storage:   storage,
+		jwtSecret: []byte(jwtSecret),
+		now:       time.Now,
+	}
+}
+
+// Issue выпускает ключ с правами и парами key и сроком действия ttl (0 -
+// бессрочно). Возвращает сохранённый ключ и сам ключ, который больше нигде
+// не хранится.
+func (s *APIKeyService) Issue(ctx context.Context, key models.APIKey, ttl time.Duration) (models.APIKey, string, error) {
+	if err := validateAPIKey(key, ttl); err != nil {
+		return models.APIKey{}, "", fmt.Errorf("Service.IssueAPIKey: %w", err)
+	}
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return models.APIKey{}, "", fmt.Errorf("Service.IssueAPIKey: %w", err)
+	}
+	now := s.now()
+	key.Prefix = secret[:apiKeyPrefixLen]
+	key.Hash = hashAPIKey(secret)
+	key.CreatedAt = now
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		key.ExpiresAt = &expires
+	}
+	created, err := s.storage.Create(ctx, key)
+	if err != nil {
+		return models.APIKey{}, "", fmt.Errorf("Service.IssueAPIKey: %w", err)
+	}
+	return created, secret, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.storage.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Service.ListAPIKeys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) error {
+	if err := s.storage.Revoke(ctx, id, s.now()); err != nil {
+		return fmt.Errorf("Service.RevokeAPIKey: %w", err)
+	}
+	return nil
+}
+
+// Authenticate находит клиента по API ключу. Неизвестный, отозванный и
+// просроченный ключи дают models.ErrUnauthenticated.
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (models.Principal, error) {
+	if !strings.HasPrefix(secret, apiKeyMarker) {
+		return models.Principal{}, fmt.Errorf("%w: %w", models.ErrUnauthenticated, models.ErrInvalidAPIKey)
+	}
+	key, err := s.storage.GetByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		return models.Principal{}, fmt.Errorf("%w: %w", models.ErrUnauthenticated, models.ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("Service.Authenticate: %w", err)
+	}
+	if !key.Active(s.now()) {
+		return models.Principal{}, fmt.Errorf("%w: ключ %s отозван или просрочен", models.ErrUnauthenticated, key.Prefix)
+	}
+	return models.Principal{Name: key.Name, KeyID: key.ID, Scopes: key.Scopes, Pairs: key.Pairs}, nil
+}
+
+// AuthenticateToken проверяет JWT, подписанный общим секретом: права
+// берутся из поля scope, пары - из pairs.
+func (s *APIKeyService) AuthenticateToken(token string) (models.Principal, error) {
+	if len(s.jwtSecret) == 0 {
+		return models.Principal{}, fmt.Errorf("%w: JWT не принимаются", models.ErrUnauthenticated)
+	}
+	claims, err := jwt.Verify(token, s.jwtSecret, s.now())
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: %w", models.ErrUnauthenticated, err)
+	}
+	return models.Principal{Name: claims.Subject, Scopes: strings.Fields(claims.Scope), Pairs: claims.Pairs}, nil
+}
+
+// checkPairAccess проверяет, что клиенту запроса доступна пара записи.
+func checkPairAccess(ctx context.Context, pair string) error {
+	if principal, ok := models.PrincipalFromContext(ctx); ok && !principal.AllowsPair(pair) {
+		return fmt.Errorf("%w: пара %s недоступна", models.ErrPermissionDenied, pair)
+	}
+	return nil
+}
+
+// pairRestricted сообщает, ограничен ли клиент запроса парами: только
+// таким клиентам нужно читать пару записи перед её изменением.
+func pairRestricted(ctx context.Context) bool {
+	principal, ok := models.PrincipalFromContext(ctx)
+	return ok && len(principal.Pairs) > 0
+}
+
+// ownsRecord сообщает, доступна ли клиенту запроса запись владельца owner.
+func ownsRecord(ctx context.Context, owner string) bool {
+	principal, ok := models.PrincipalFromContext(ctx)
+	return !ok || principal.Owns(owner)
+}
+
+func validateAPIKey(key models.APIKey, ttl time.Duration) error {
+	if key.Name == "" {
+		return fmt.Errorf("%w: нужно имя ключа", models.ErrInvalidAPIKey)
+	}
+	if len(key.Scopes) == 0 {
+		return fmt.Errorf("%w: нужно хотя бы одно право", models.ErrInvalidAPIKey)
+	}
+	for _, scope := range key.Scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			return fmt.Errorf("%w: неизвестное право %q", models.ErrInvalidAPIKey, scope)
+		}
+	}
+	if ttl < 0 {
+		return fmt.Errorf("%w: отрицательный срок действия", models.ErrInvalidAPIKey)
+	}
+	return nil
+}
+
+func newAPIKeySecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать API ключ: %w", err)
+	}
+	return apiKeyMarker + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
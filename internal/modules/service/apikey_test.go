@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/infrastructure/jwt"
+	"usdt/internal/models"
+)
+
+// fakeAPIKeyStorage хранит ключи в памяти.
+type fakeAPIKeyStorage struct {
+	keys []models.APIKey
+}
+
+func (f *fakeAPIKeyStorage) Create(_ context.Context, key models.APIKey) (models.APIKey, error) {
+	key.ID = int64(len(f.keys) + 1)
+	f.keys = append(f.keys, key)
+	return key, nil
+}
+
+func (f *fakeAPIKeyStorage) GetByHash(_ context.Context, hash string) (models.APIKey, error) {
+	for _, key := range f.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, models.ErrAPIKeyNotFound
+}
+
+func (f *fakeAPIKeyStorage) List(context.Context) ([]models.APIKey, error) {
+	return f.keys, nil
+}
+
+func (f *fakeAPIKeyStorage) Revoke(_ context.Context, id int64, at time.Time) error {
+	for i := range f.keys {
+		if f.keys[i].ID == id {
+			f.keys[i].RevokedAt = &at
+			return nil
+		}
+	}
+	return models.ErrAPIKeyNotFound
+}
+
+func TestAPIKeyService_Issue(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	storage := &fakeAPIKeyStorage{}
+	svc := NewAPIKeyService(storage, "")
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	key, secret, err := svc.Issue(ctx, models.APIKey{Name: "partner", Scopes: []string{models.ScopeRatesRead}, Pairs: []string{"USDT/RUB"}}, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, apiKeyMarker))
+	assert.Equal(t, secret[:apiKeyPrefixLen], key.Prefix)
+	assert.NotContains(t, key.Hash, secret, "в хранилище попадает только хеш ключа")
+	assert.Len(t, key.Hash, 64)
+	require.NotNil(t, key.ExpiresAt)
+	assert.Equal(t, now.Add(time.Hour), *key.ExpiresAt)
+
+	_, other, err := svc.Issue(ctx, models.APIKey{Name: "partner", Scopes: []string{models.ScopeRatesRead}}, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+	assert.Nil(t, storage.keys[1].ExpiresAt, "нулевой срок - бессрочный ключ")
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, _, err := svc.Issue(ctx, models.APIKey{Scopes: []string{models.ScopeRatesRead}}, 0)
+		assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+		_, _, err = svc.Issue(ctx, models.APIKey{Name: "partner"}, 0)
+		assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+		_, _, err = svc.Issue(ctx, models.APIKey{Name: "partner", Scopes: []string{"everything"}}, 0)
+		assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+		_, _, err = svc.Issue(ctx, models.APIKey{Name: "partner", Scopes: []string{models.ScopeRatesRead}}, -time.Hour)
+		assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+	})
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	storage := &fakeAPIKeyStorage{}
+	svc := NewAPIKeyService(storage, "")
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+	key, secret, err := svc.Issue(ctx, models.APIKey{Name: "partner", Scopes: []string{models.ScopeRatesRead}, Pairs: []string{"USDT/RUB"}}, time.Hour)
+	require.NoError(t, err)
+
+	t.Run("Valid", func(t *testing.T) {
+		principal, err := svc.Authenticate(ctx, secret)
+		require.NoError(t, err)
+		assert.Equal(t, models.Principal{Name: "partner", KeyID: key.ID, Scopes: []string{models.ScopeRatesRead}, Pairs: []string{"USDT/RUB"}}, principal)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := svc.Authenticate(ctx, apiKeyMarker+"unknown")
+		assert.ErrorIs(t, err, models.ErrUnauthenticated)
+		_, err = svc.Authenticate(ctx, "garbage")
+		assert.ErrorIs(t, err, models.ErrUnauthenticated)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		svc.now = func() time.Time { return now.Add(time.Hour) }
+		defer func() { svc.now = func() time.Time { return now } }()
+		_, err := svc.Authenticate(ctx, secret)
+		assert.ErrorIs(t, err, models.ErrUnauthenticated)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		require.NoError(t, svc.Revoke(ctx, key.ID))
+		_, err := svc.Authenticate(ctx, secret)
+		assert.ErrorIs(t, err, models.ErrUnauthenticated)
+		assert.ErrorIs(t, svc.Revoke(ctx, 42), models.ErrAPIKeyNotFound)
+	})
+}
+
+func TestAPIKeyService_AuthenticateToken(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	token, err := jwt.Sign(jwt.Claims{Subject: "partner", Scope: "rates:read quotes", Pairs: []string{"USDT/RUB"}, ExpiresAt: now.Add(time.Hour).Unix()}, []byte("secret"))
+	require.NoError(t, err)
+
+	svc := NewAPIKeyService(&fakeAPIKeyStorage{}, "secret")
+	svc.now = func() time.Time { return now }
+	principal, err := svc.AuthenticateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, models.Principal{Name: "partner", Scopes: []string{models.ScopeRatesRead, models.ScopeQuotes}, Pairs: []string{"USDT/RUB"}}, principal)
+
+	_, err = NewAPIKeyService(&fakeAPIKeyStorage{}, "other").AuthenticateToken(token)
+	assert.ErrorIs(t, err, models.ErrUnauthenticated)
+
+	_, err = NewAPIKeyService(&fakeAPIKeyStorage{}, "").AuthenticateToken(token)
+	assert.ErrorIs(t, err, models.ErrUnauthenticated, "без секрета JWT не принимаются")
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"usdt/internal/models"
+)
+
+const arbitrageSubscriberBuffer = 16
+
+// Venue - биржа, с которой берутся стаканы для поиска арбитража.
+type Venue struct {
+	Name       string
+	API        RequestAPI
+	FeePercent float64
+}
+
+type arbitrageSubscriber struct {
+	pair string
+	ch   chan models.ArbitrageOpportunity
+}
+
+// ArbitrageService сравнивает стаканы одной пары на разных биржах и ищет
+// моменты, когда bid одной биржи выше ask другой с учётом комиссий обеих.
+type ArbitrageService struct {
+	storage    ArbitrageServicer
+	venues     []Venue
+	currencies []string
+	interval   time.Duration
+	logger     *zap.Logger
+	now        func() time.Time
+
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]arbitrageSubscriber
+	// active - последняя сохранённая возможность по паре и направлению между биржами.
+	active map[arbitrageKey]models.ArbitrageOpportunity
+}
+
+type arbitrageKey struct {
+	pair, buy, sell string
+}
+
+func NewArbitrageService(storage ArbitrageServicer, venues []Venue, currencies []string, interval time.Duration, logger *zap.Logger) *ArbitrageService {
+	return &ArbitrageService{
+		storage:     storage,
+		venues:      venues,
+		currencies:  currencies,
+		interval:    interval,
+		logger:      logger,
+		now:         time.Now,
+		subscribers: make(map[int]arbitrageSubscriber),
+		active:      make(map[arbitrageKey]models.ArbitrageOpportunity),
+	}
+}
+
+// Run периодически ищет арбитраж до отмены ctx.
+func (a *ArbitrageService) Run(ctx context.Context) {
+	if a.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		for _, currency := range a.currencies {
+			if _, err := a.Scan(ctx, currency); err != nil {
+				a.logger.Error("ArbitrageService.Scan error:", zap.String("currency", currency), zap.Error(err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan загружает стаканы валюты со всех бирж и возвращает возможности,
+// которые появились или изменились с прошлого поиска: только они сохраняются
+// и рассылаются подписчикам. Возможность, которая держится без изменений,
+// записывается один раз, а исчезнувшая снова сохранится, когда появится.
+func (a *ArbitrageService) Scan(ctx context.Context, currency string) ([]models.ArbitrageOpportunity, error) {
+	pair := "USDT/" + currency
+	books := make(map[string]models.OrderBook, len(a.venues))
+	for _, venue := range a.venues {
+		book, err := venue.API.GetOrderBook(ctx, currency)
+		if err != nil {
+			a.logger.Warn("Не удалось получить стакан биржи", zap.String("venue", venue.Name), zap.String("pair", pair), zap.Error(err))
+			continue
+		}
+		books[venue.Name] = book
+	}
+
+	var found []models.ArbitrageOpportunity
+	for _, buy := range a.venues {
+		for _, sell := range a.venues {
+			buyBook, okBuy := books[buy.Name]
+			sellBook, okSell := books[sell.Name]
+			if buy.Name == sell.Name || !okBuy || !okSell {
+				// Без стакана биржи неизвестно, закончилась ли возможность.
+				continue
+			}
+			key := arbitrageKey{pair: pair, buy: buy.Name, sell: sell.Name}
+			opportunity, ok := FindArbitrage(buyBook.Asks, buy.FeePercent, sellBook.Bids, sell.FeePercent)
+			if !ok {
+				a.setActive(key, models.ArbitrageOpportunity{}, false)
+				continue
+			}
+			opportunity.Pair = pair
+			opportunity.BuyVenue = buy.Name
+			opportunity.SellVenue = sell.Name
+			if !a.changed(key, opportunity) {
+				continue
+			}
+			opportunity.DetectedAt = a.now()
+			if err := a.storage.Create(ctx, opportunity); err != nil {
+				return found, fmt.Errorf("Service.ScanArbitrage: %w", err)
+			}
+			a.setActive(key, opportunity, true)
+			a.logger.Info("Найдена арбитражная возможность",
+				zap.String("pair", pair),
+				zap.String("buy", buy.Name),
+				zap.String("sell", sell.Name),
+				zap.Float64("volume", opportunity.Volume),
+				zap.Float64("profit_percent", opportunity.ProfitPercent))
+			a.publish(opportunity)
+			found = append(found, opportunity)
+		}
+	}
+	return found, nil
+}
+
+// changed сообщает, отличается ли возможность от последней сохранённой по тому же ключу.
+func (a *ArbitrageService) changed(key arbitrageKey, opportunity models.ArbitrageOpportunity) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	last, ok := a.active[key]
+	return !ok || last.BuyPrice != opportunity.BuyPrice || last.SellPrice != opportunity.SellPrice || last.Volume != opportunity.Volume
+}
+
+func (a *ArbitrageService) setActive(key arbitrageKey, opportunity models.ArbitrageOpportunity, active bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if active {
+		a.active[key] = opportunity
+	} else {
+		delete(a.active, key)
+	}
+}
+
+// Subscribe возвращает канал возможностей по паре (пустая пара - все пары)
+// и функцию отписки. Медленный подписчик теряет сообщения, а не тормозит поиск.
+func (a *ArbitrageService) Subscribe(pair string) (<-chan models.ArbitrageOpportunity, func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	id := a.nextID
+	a.nextID++
+	ch := make(chan models.ArbitrageOpportunity, arbitrageSubscriberBuffer)
+	a.subscribers[id] = arbitrageSubscriber{pair: pair, ch: ch}
+	return ch, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		delete(a.subscribers, id)
+	}
+}
+
+func (a *ArbitrageService) publish(opportunity models.ArbitrageOpportunity) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, sub := range a.subscribers {
+		if sub.pair != "" && sub.pair != opportunity.Pair {
+			continue
+		}
+		select {
+		case sub.ch <- opportunity:
+		default:
+		}
+	}
+}
+
+// FindArbitrage сводит asks биржи покупки с bids биржи продажи, пока продажа
+// после комиссии выгоднее покупки с комиссией. Уровни должны идти от лучшей
+// цены к худшей.
+func FindArbitrage(asks []models.BookLevel, buyFeePercent float64, bids []models.BookLevel, sellFeePercent float64) (models.ArbitrageOpportunity, bool) {
+	var (
+		opportunity models.ArbitrageOpportunity
+		spent       float64
+		received    float64
+		cost        float64
+		i, j        int
+		askLeft     float64
+		bidLeft     float64
+	)
+	for i < len(asks) && j < len(bids) {
+		ask, bid := asks[i], bids[j]
+		if ask.Price <= 0 || ask.Volume <= 0 {
+			i++
+			continue
+		}
+		if bid.Price <= 0 || bid.Volume <= 0 {
+			j++
+			continue
+		}
+		if askLeft == 0 {
+			askLeft = ask.Volume
+		}
+		if bidLeft == 0 {
+			bidLeft = bid.Volume
+		}
+		buyCost := ask.Price * (1 + buyFeePercent/100)
+		sellProceeds := bid.Price * (1 - sellFeePercent/100)
+		if sellProceeds <= buyCost {
+			break
+		}
+		qty := math.Min(askLeft, bidLeft)
+		opportunity.Volume += qty
+		opportunity.Profit += qty * (sellProceeds - buyCost)
+		spent += qty * ask.Price
+		received += qty * bid.Price
+		cost += qty * buyCost
+		askLeft -= qty
+		bidLeft -= qty
+		if askLeft == 0 {
+			i++
+		}
+		if bidLeft == 0 {
+			j++
+		}
+	}
+	if opportunity.Volume == 0 {
+		return models.ArbitrageOpportunity{}, false
+	}
+	opportunity.BuyPrice = spent / opportunity.Volume
+	opportunity.SellPrice = received / opportunity.Volume
+	opportunity.ProfitPercent = opportunity.Profit / cost * 100
+	return opportunity, true
+}
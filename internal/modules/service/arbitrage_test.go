@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"usdt/internal/models"
+)
+
+// fakeExchange - биржа с заранее заданным стаканом
+type fakeExchange struct {
+	book models.OrderBook
+	err  error
+}
+
+func (f *fakeExchange) GetOrderBook(_ context.Context, market string) (models.OrderBook, error) {
+	return f.book, f.err
+}
+
+// MockArbitrageStorage - mock для интерфейса ArbitrageServicer
+type MockArbitrageStorage struct {
+	mock.Mock
+}
+
+func (m *MockArbitrageStorage) Create(ctx context.Context, opportunity models.ArbitrageOpportunity) error {
+	args := m.Called(ctx, opportunity)
+	return args.Error(0)
+}
+
+func newArbitrageExchanges() (*fakeExchange, *fakeExchange) {
+	cheap := &fakeExchange{book: models.OrderBook{
+		Asks: []models.BookLevel{{Price: 100, Volume: 5}, {Price: 100.5, Volume: 5}},
+		Bids: []models.BookLevel{{Price: 99, Volume: 5}},
+	}}
+	expensive := &fakeExchange{book: models.OrderBook{
+		Asks: []models.BookLevel{{Price: 102, Volume: 5}},
+		Bids: []models.BookLevel{{Price: 101, Volume: 3}, {Price: 100.8, Volume: 4}, {Price: 100, Volume: 10}},
+	}}
+	return cheap, expensive
+}
+
+func TestFindArbitrage(t *testing.T) {
+	cheap, expensive := newArbitrageExchanges()
+
+	t.Run("LimitedByDepth", func(t *testing.T) {
+		opportunity, ok := FindArbitrage(cheap.book.Asks, 0.1, expensive.book.Bids, 0.1)
+		require.True(t, ok)
+		assert.InDelta(t, 7, opportunity.Volume, 1e-9)
+		assert.InDelta(t, 3.7928, opportunity.Profit, 1e-9)
+		assert.InDelta(t, (5*100+2*100.5)/7.0, opportunity.BuyPrice, 1e-9)
+		assert.InDelta(t, (3*101+4*100.8)/7.0, opportunity.SellPrice, 1e-9)
+		assert.Greater(t, opportunity.ProfitPercent, 0.0)
+	})
+
+	t.Run("FeesEatEdge", func(t *testing.T) {
+		_, ok := FindArbitrage(cheap.book.Asks, 0.5, expensive.book.Bids, 0.5)
+		assert.False(t, ok)
+	})
+
+	t.Run("NoCross", func(t *testing.T) {
+		_, ok := FindArbitrage(expensive.book.Asks, 0, cheap.book.Bids, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestArbitrageService_Scan(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	t.Run("StoresAndStreams", func(t *testing.T) {
+		cheap, expensive := newArbitrageExchanges()
+		mockStorage := new(MockArbitrageStorage)
+		mockStorage.On("Create", mock.Anything, mock.Anything).Return(nil)
+		venues := []Venue{
+			{Name: "cheap", API: cheap, FeePercent: 0.1},
+			{Name: "expensive", API: expensive, FeePercent: 0.1},
+		}
+
+		service := NewArbitrageService(mockStorage, venues, []string{"RUB"}, time.Second, zap.NewNop())
+		service.now = func() time.Time { return now }
+		all, cancelAll := service.Subscribe("")
+		defer cancelAll()
+		other, cancelOther := service.Subscribe("USDT/EUR")
+		defer cancelOther()
+
+		found, err := service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "USDT/RUB", found[0].Pair)
+		assert.Equal(t, "cheap", found[0].BuyVenue)
+		assert.Equal(t, "expensive", found[0].SellVenue)
+		assert.Equal(t, now, found[0].DetectedAt)
+		mockStorage.AssertCalled(t, "Create", mock.Anything, found[0])
+
+		select {
+		case opportunity := <-all:
+			assert.Equal(t, found[0], opportunity)
+		default:
+			t.Fatal("подписчик не получил возможность")
+		}
+		assert.Len(t, other, 0)
+	})
+
+	t.Run("StoresOnlyChanges", func(t *testing.T) {
+		cheap, expensive := newArbitrageExchanges()
+		mockStorage := new(MockArbitrageStorage)
+		mockStorage.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewArbitrageService(mockStorage, []Venue{{Name: "cheap", API: cheap}, {Name: "expensive", API: expensive}}, []string{"RUB"}, time.Second, zap.NewNop())
+		ch, cancel := service.Subscribe("")
+		defer cancel()
+
+		found, err := service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		<-ch
+
+		// Та же возможность на следующем интервале не сохраняется и не рассылается.
+		found, err = service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		assert.Empty(t, found)
+		assert.Len(t, ch, 0)
+		mockStorage.AssertNumberOfCalls(t, "Create", 1)
+
+		// Изменился объём - возможность сохраняется заново.
+		expensive.book.Bids = expensive.book.Bids[:1]
+		found, err = service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, 3.0, found[0].Volume)
+		mockStorage.AssertNumberOfCalls(t, "Create", 2)
+
+		// Ошибка биржи не завершает возможность.
+		cheap.err = errors.New("API error")
+		_, err = service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		cheap.err = nil
+		found, err = service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		// Возможность закончилась и появилась снова - это новая возможность.
+		saved := expensive.book.Bids
+		expensive.book.Bids = []models.BookLevel{{Price: 99, Volume: 1}}
+		found, err = service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		assert.Empty(t, found)
+		expensive.book.Bids = saved
+		found, err = service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		assert.Len(t, found, 1)
+		mockStorage.AssertNumberOfCalls(t, "Create", 3)
+	})
+
+	t.Run("VenueError", func(t *testing.T) {
+		cheap, _ := newArbitrageExchanges()
+		broken := &fakeExchange{err: errors.New("API error")}
+		mockStorage := new(MockArbitrageStorage)
+
+		service := NewArbitrageService(mockStorage, []Venue{{Name: "cheap", API: cheap}, {Name: "broken", API: broken}}, []string{"RUB"}, time.Second, zap.NewNop())
+		found, err := service.Scan(context.Background(), "RUB")
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+		mockStorage.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		cheap, expensive := newArbitrageExchanges()
+		mockStorage := new(MockArbitrageStorage)
+		mockStorage.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewArbitrageService(mockStorage, []Venue{{Name: "cheap", API: cheap}, {Name: "expensive", API: expensive}}, []string{"RUB"}, time.Second, zap.NewNop())
+		ch, cancel := service.Subscribe("")
+		cancel()
+		_, err := service.Scan(context.Background(), "RUB")
+		require.NoError(t, err)
+		assert.Len(t, ch, 0)
+	})
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// BatchWriter копит курсы в буфере и пишет их в базу одним многострочным
+// INSERT, когда буфер достигает Size строк или раз в Interval. Остальные
+// методы хранилища вызываются напрямую.
+//
+// Строки неудачного пакета остаются в буфере и уходят со следующим сбросом;
+// при переполнении MaxBuffered самые старые строки отбрасываются.
+//
+// Статистика стакана (DeferStats) и обработчики OnCommit получают курс только
+// после записи его пакета: курс из буфера ещё может быть отброшен.
+type BatchWriter struct {
+	RateBatchServicer
+	policy config.Batch
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	buffer []models.CurrencyRate
+	// flushMu упорядочивает сбросы, чтобы строки попадали в базу в порядке записи.
+	flushMu sync.Mutex
+
+	stats        MarketStatsServicer
+	pendingStats map[statsKey]models.MarketStats
+	onCommit     []func(ctx context.Context, rate models.CurrencyRate) error
+}
+
+type statsKey struct {
+	pair      string
+	timestamp int64
+}
+
+func rateStatsKey(rate models.CurrencyRate) statsKey {
+	return statsKey{pair: rate.Pair, timestamp: rate.Timestamp.UnixNano()}
+}
+
+func NewBatchWriter(storage RateBatchServicer, policy config.Batch, logger *zap.Logger) *BatchWriter {
+	if policy.Size <= 0 {
+		policy.Size = 1
+	}
+	if policy.MaxBuffered < policy.Size {
+		policy.MaxBuffered = policy.Size
+	}
+	return &BatchWriter{
+		RateBatchServicer: storage,
+		policy:            policy,
+		logger:            logger,
+		pendingStats:      make(map[statsKey]models.MarketStats),
+	}
+}
+
+// OnCommit добавляет обработчик, который вызывается для каждого курса после
+// записи его пакета. Обработчики добавляются до начала записи.
+func (w *BatchWriter) OnCommit(handler func(ctx context.Context, rate models.CurrencyRate) error) {
+	w.onCommit = append(w.onCommit, handler)
+}
+
+// DeferStats возвращает хранилище статистики, которое придерживает статистику
+// курса из буфера до записи его пакета и не пишет её, если курс отброшен.
+// Вызывается до начала записи.
+func (w *BatchWriter) DeferStats(stats MarketStatsServicer) MarketStatsServicer {
+	w.stats = stats
+	return deferredStats{MarketStatsServicer: stats, writer: w}
+}
+
+type deferredStats struct {
+	MarketStatsServicer
+	writer *BatchWriter
+}
+
+// Create откладывает статистику курса, который ждёт записи в буфере; статистика
+// курса, пакет которого уже записан, пишется сразу.
+func (d deferredStats) Create(ctx context.Context, stats models.MarketStats) error {
+	w := d.writer
+	w.mu.Lock()
+	for _, buffered := range w.buffer {
+		if buffered.Pair == stats.Pair && buffered.Timestamp.Equal(stats.Timestamp) {
+			w.pendingStats[rateStatsKey(buffered)] = stats
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	w.mu.Unlock()
+	return d.MarketStatsServicer.Create(ctx, stats)
+}
+
+// Create добавляет курс в буфер. Если буфер заполнен, пакет сбрасывается
+// сразу и ошибка записи возвращается вызывающему. Снимок, который уже есть
+// в буфере или в базе, не добавляется и новым не считается. Курс из буфера
+// возвращается без ID и версии: они появятся только при записи пакета.
+func (w *BatchWriter) Create(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
+	if w.buffered(rate) {
+		return rate, false, nil
+	}
+	stored, ok, err := w.GetByKey(ctx, rate)
+	if err != nil {
+		return models.CurrencyRate{}, false, fmt.Errorf("Service.Create: %w", err)
+	}
+	if ok {
+		return stored, false, nil
+	}
+
+	w.mu.Lock()
+	if w.bufferedLocked(rate) {
+		w.mu.Unlock()
+		return rate, false, nil
+	}
+	w.buffer = append(w.buffer, rate)
+	full := len(w.buffer) >= w.policy.Size
+	w.mu.Unlock()
+	if !full {
+		return rate, true, nil
+	}
+	return rate, true, w.Flush(ctx)
+}
+
+func (w *BatchWriter) buffered(rate models.CurrencyRate) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.bufferedLocked(rate)
+}
+
+// bufferedLocked сообщает, есть ли снимок в буфере; вызывается под mu.
+func (w *BatchWriter) bufferedLocked(rate models.CurrencyRate) bool {
+	for _, buffered := range w.buffer {
+		if sameSnapshot(buffered, rate) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSnapshot(a, b models.CurrencyRate) bool {
+	return a.Pair == b.Pair && a.Source == b.Source && a.Timestamp.Equal(b.Timestamp)
+}
+
+// Flush записывает все накопленные курсы.
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.buffer
+	w.buffer = nil
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := w.CreateBatch(ctx, batch); err != nil {
+		w.requeue(batch)
+		return fmt.Errorf("Service.Flush: %w", err)
+	}
+	w.committed(ctx, batch)
+	return nil
+}
+
+// committed пишет отложенную статистику записанного пакета и вызывает
+// обработчики OnCommit. Их ошибки пишутся в лог: пакет уже в базе.
+func (w *BatchWriter) committed(ctx context.Context, batch []models.CurrencyRate) {
+	w.mu.Lock()
+	stats := make([]models.MarketStats, 0, len(batch))
+	for _, rate := range batch {
+		key := rateStatsKey(rate)
+		if s, ok := w.pendingStats[key]; ok {
+			stats = append(stats, s)
+			delete(w.pendingStats, key)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, s := range stats {
+		if err := w.stats.Create(ctx, s); err != nil {
+			w.logger.Error("Не удалось сохранить статистику стакана:", zap.String("pair", s.Pair), zap.Error(err))
+		}
+	}
+	for _, rate := range batch {
+		for _, handler := range w.onCommit {
+			if err := handler(ctx, rate); err != nil {
+				w.logger.Error("BatchWriter.OnCommit error:", zap.String("pair", rate.Pair), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Buffered возвращает число курсов, ожидающих записи.
+func (w *BatchWriter) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.buffer)
+}
+
+// requeue возвращает неудачный пакет в начало буфера.
+func (w *BatchWriter) requeue(batch []models.CurrencyRate) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer = append(batch, w.buffer...)
+	if dropped := len(w.buffer) - w.policy.MaxBuffered; dropped > 0 {
+		for _, rate := range w.buffer[:dropped] {
+			delete(w.pendingStats, rateStatsKey(rate))
+		}
+		w.buffer = w.buffer[dropped:]
+		w.logger.Warn("Буфер записи курсов переполнен, старые курсы отброшены", zap.Int("dropped", dropped))
+	}
+}
+
+// Run сбрасывает буфер каждые Interval до отмены ctx. Оставшиеся курсы
+// нужно записать вызовом Flush после остановки всех писателей.
+func (w *BatchWriter) Run(ctx context.Context) {
+	if w.policy.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(w.policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Flush(ctx); err != nil && ctx.Err() == nil {
+				w.logger.Error("BatchWriter.Flush error:", zap.Error(err))
+			}
+		}
+	}
+}
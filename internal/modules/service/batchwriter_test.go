@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// MockBatchStorage - mock для интерфейса RateBatchServicer
+type MockBatchStorage struct {
+	MockUsdtStorage
+}
+
+func (m *MockBatchStorage) CreateBatch(ctx context.Context, rates []models.CurrencyRate) error {
+	args := m.Called(ctx, rates)
+	return args.Error(0)
+}
+
+func (m *MockBatchStorage) GetByKey(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
+	args := m.Called(ctx, rate)
+	return args.Get(0).(models.CurrencyRate), args.Bool(1), args.Error(2)
+}
+
+// newMockBatchStorage возвращает mock, в базе которого нет ни одного снимка.
+func newMockBatchStorage() *MockBatchStorage {
+	m := new(MockBatchStorage)
+	m.On("GetByKey", mock.Anything, mock.Anything).Return(models.CurrencyRate{}, false, nil).Maybe()
+	return m
+}
+
+// newRate возвращает курс, время биржи которого зависит от bid, чтобы курсы с
+// разными ценами были разными снимками.
+func newRate(pair string, bid float64) models.CurrencyRate {
+	return models.CurrencyRate{Pair: pair, AskPrice: bid + 1, BidPrice: bid,
+		Timestamp: time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC).Add(time.Duration(bid) * time.Second)}
+}
+
+func TestBatchWriter_Create(t *testing.T) {
+	t.Run("FlushesOnSize", func(t *testing.T) {
+		mockStorage := newMockBatchStorage()
+		batch := []models.CurrencyRate{newRate("USDT/RUB", 1), newRate("USDT/USD", 2), newRate("USDT/EUR", 3)}
+		mockStorage.On("CreateBatch", mock.Anything, batch).Return(nil).Once()
+
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 3}, zap.NewNop())
+		for _, rate := range batch {
+			_, created, err := writer.Create(context.Background(), rate)
+			assert.NoError(t, err)
+			assert.True(t, created)
+		}
+		assert.Zero(t, writer.Buffered())
+		mockStorage.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ErrorKeepsRowsForRetry", func(t *testing.T) {
+		mockStorage := newMockBatchStorage()
+		first := []models.CurrencyRate{newRate("USDT/RUB", 1), newRate("USDT/USD", 2)}
+		mockStorage.On("CreateBatch", mock.Anything, first).Return(errors.New("db error")).Once()
+		retried := append(append([]models.CurrencyRate{}, first...), newRate("USDT/EUR", 3))
+		mockStorage.On("CreateBatch", mock.Anything, retried).Return(nil).Once()
+
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 2, MaxBuffered: 10}, zap.NewNop())
+		_, _, err := writer.Create(context.Background(), first[0])
+		assert.NoError(t, err)
+		_, _, err = writer.Create(context.Background(), first[1])
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Service.Flush")
+		assert.Equal(t, 2, writer.Buffered())
+
+		_, _, err = writer.Create(context.Background(), newRate("USDT/EUR", 3))
+		assert.NoError(t, err)
+		assert.Zero(t, writer.Buffered())
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("DropsOldestOnOverflow", func(t *testing.T) {
+		mockStorage := newMockBatchStorage()
+		mockStorage.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 2, MaxBuffered: 3}, zap.NewNop())
+		for i := 0; i < 5; i++ {
+			writer.Create(context.Background(), newRate("USDT/RUB", float64(i)))
+		}
+		assert.Equal(t, 3, writer.Buffered())
+
+		// После восстановления базы уходят три самых свежих курса.
+		mockStorage.ExpectedCalls = nil
+		mockStorage.On("CreateBatch", mock.Anything, []models.CurrencyRate{
+			newRate("USDT/RUB", 2), newRate("USDT/RUB", 3), newRate("USDT/RUB", 4),
+		}).Return(nil).Once()
+		assert.NoError(t, writer.Flush(context.Background()))
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("SkipsBufferedSnapshot", func(t *testing.T) {
+		mockStorage := newMockBatchStorage()
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 10}, zap.NewNop())
+		_, created, err := writer.Create(context.Background(), newRate("USDT/RUB", 1))
+		assert.NoError(t, err)
+		assert.True(t, created)
+
+		repeat := newRate("USDT/RUB", 1)
+		repeat.AskPrice = 5
+		_, created, err = writer.Create(context.Background(), repeat)
+		assert.NoError(t, err)
+		assert.False(t, created)
+
+		other := newRate("USDT/RUB", 1)
+		other.Source = "other"
+		_, created, err = writer.Create(context.Background(), other)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, 2, writer.Buffered())
+	})
+
+	t.Run("SkipsStoredSnapshot", func(t *testing.T) {
+		mockStorage := new(MockBatchStorage)
+		stored := newRate("USDT/RUB", 1)
+		mockStorage.On("GetByKey", mock.Anything, stored).Return(stored, true, nil).Once()
+		mockStorage.On("GetByKey", mock.Anything, newRate("USDT/RUB", 2)).Return(models.CurrencyRate{}, false, errors.New("db error")).Once()
+
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 10}, zap.NewNop())
+		got, created, err := writer.Create(context.Background(), stored)
+		assert.NoError(t, err)
+		assert.False(t, created, "снимок уже сохранён в базе")
+		assert.Equal(t, stored, got)
+
+		_, _, err = writer.Create(context.Background(), newRate("USDT/RUB", 2))
+		assert.ErrorContains(t, err, "Service.Create")
+		assert.Zero(t, writer.Buffered())
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestBatchWriter_Commit(t *testing.T) {
+	statsOf := func(rate models.CurrencyRate) models.MarketStats {
+		return models.MarketStats{Pair: rate.Pair, Timestamp: rate.Timestamp, AskPrice: rate.AskPrice, BidPrice: rate.BidPrice}
+	}
+
+	t.Run("AfterFlush", func(t *testing.T) {
+		mockStorage := newMockBatchStorage()
+		mockStats := new(MockMarketStatsStorage)
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 10}, zap.NewNop())
+		stats := writer.DeferStats(mockStats)
+		var committed []models.CurrencyRate
+		writer.OnCommit(func(_ context.Context, rate models.CurrencyRate) error {
+			committed = append(committed, rate)
+			return nil
+		})
+
+		rate := newRate("USDT/RUB", 1)
+		_, _, err := writer.Create(context.Background(), rate)
+		assert.NoError(t, err)
+		assert.NoError(t, stats.Create(context.Background(), statsOf(rate)))
+		mockStats.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		assert.Empty(t, committed, "курс ещё не записан")
+
+		mockStorage.On("CreateBatch", mock.Anything, []models.CurrencyRate{rate}).Return(errors.New("db error")).Once()
+		assert.Error(t, writer.Flush(context.Background()))
+		mockStats.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		assert.Empty(t, committed, "пакет не записан")
+
+		mockStorage.On("CreateBatch", mock.Anything, []models.CurrencyRate{rate}).Return(nil).Once()
+		mockStats.On("Create", mock.Anything, statsOf(rate)).Return(nil).Once()
+		assert.NoError(t, writer.Flush(context.Background()))
+		assert.Equal(t, []models.CurrencyRate{rate}, committed)
+		mockStats.AssertExpectations(t)
+
+		// Статистика курса, пакет которого уже записан, пишется сразу.
+		mockStats.On("Create", mock.Anything, statsOf(rate)).Return(nil).Once()
+		assert.NoError(t, stats.Create(context.Background(), statsOf(rate)))
+		mockStats.AssertExpectations(t)
+	})
+
+	t.Run("DroppedRate", func(t *testing.T) {
+		mockStorage := newMockBatchStorage()
+		mockStorage.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("db error"))
+		mockStats := new(MockMarketStatsStorage)
+		writer := NewBatchWriter(mockStorage, config.Batch{Size: 2, MaxBuffered: 2}, zap.NewNop())
+		stats := writer.DeferStats(mockStats)
+		var committed []models.CurrencyRate
+		writer.OnCommit(func(_ context.Context, rate models.CurrencyRate) error {
+			committed = append(committed, rate)
+			return nil
+		})
+
+		for i := 0; i < 4; i++ {
+			rate := newRate("USDT/RUB", float64(i))
+			writer.Create(context.Background(), rate)
+			assert.NoError(t, stats.Create(context.Background(), statsOf(rate)))
+		}
+
+		// Отброшенные курсы 0 и 1 не попадают ни в статистику, ни к обработчикам.
+		mockStorage.ExpectedCalls = nil
+		kept := []models.CurrencyRate{newRate("USDT/RUB", 2), newRate("USDT/RUB", 3)}
+		mockStorage.On("CreateBatch", mock.Anything, kept).Return(nil).Once()
+		for _, rate := range kept {
+			mockStats.On("Create", mock.Anything, statsOf(rate)).Return(nil).Once()
+		}
+		assert.NoError(t, writer.Flush(context.Background()))
+		assert.Equal(t, kept, committed)
+		mockStats.AssertExpectations(t)
+		assert.Empty(t, writer.pendingStats)
+	})
+}
+
+func TestBatchWriter_Run(t *testing.T) {
+	mockStorage := newMockBatchStorage()
+	flushed := make(chan struct{})
+	mockStorage.On("CreateBatch", mock.Anything, []models.CurrencyRate{newRate("USDT/RUB", 1)}).
+		Return(nil).Once().Run(func(mock.Arguments) { close(flushed) })
+
+	writer := NewBatchWriter(mockStorage, config.Batch{Size: 100, Interval: time.Millisecond}, zap.NewNop())
+	_, _, err := writer.Create(context.Background(), newRate("USDT/RUB", 1))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		writer.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("буфер не сброшен по интервалу")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+
+	// Курсы, записанные после остановки Run, дописывает финальный Flush.
+	mockStorage.On("CreateBatch", mock.Anything, []models.CurrencyRate{newRate("USDT/USD", 2)}).Return(nil).Once()
+	_, _, err = writer.Create(context.Background(), newRate("USDT/USD", 2))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Flush(context.Background()))
+	assert.Zero(t, writer.Buffered())
+	mockStorage.AssertExpectations(t)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"usdt/internal/models"
+)
+
+// ExportService выгружает историю курсов страницами по pageSize строк, так
+// что в памяти одновременно находится не больше одной страницы.
+type ExportService struct {
+	storage  RatePageSource
+	pageSize int
+}
+
+func NewExportService(storage RatePageSource, pageSize int) *ExportService {
+	if pageSize <= 0 {
+		pageSize = 1
+	}
+	return &ExportService{
+		storage:  storage,
+		pageSize: pageSize,
+	}
+}
+
+// Export передаёт write курсы пар pairs за [from, to]: пары по порядку,
+// внутри пары - по времени биржи. Ошибка write прерывает выгрузку.
+func (e *ExportService) Export(ctx context.Context, pairs []string, from, to time.Time, write func(models.CurrencyRate) error) error {
+	for _, pair := range pairs {
+		var after models.RateCursor
+		for {
+			page, err := e.storage.GetPage(ctx, pair, from, to, after, e.pageSize)
+			if err != nil {
+				return fmt.Errorf("Service.Export: %w", err)
+			}
+			for _, rate := range page {
+				if err := write(rate); err != nil {
+					return fmt.Errorf("Service.Export: %w", err)
+				}
+			}
+			if len(page) < e.pageSize {
+				break
+			}
+			after = models.CursorAfter(page[len(page)-1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"usdt/internal/models"
+)
+
+// MockRatePageSource - mock для интерфейса RatePageSource
+type MockRatePageSource struct {
+	mock.Mock
+}
+
+func (m *MockRatePageSource) GetPage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error) {
+	args := m.Called(ctx, pair, from, to, after, limit)
+	return args.Get(0).([]models.CurrencyRate), args.Error(1)
+}
+
+func TestExportService_Export(t *testing.T) {
+	to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-time.Hour)
+	rate := func(id int64, pair string) models.CurrencyRate {
+		return models.CurrencyRate{ID: id, Pair: pair, Timestamp: from.Add(time.Duration(id) * time.Minute)}
+	}
+
+	t.Run("Pages", func(t *testing.T) {
+		mockStorage := new(MockRatePageSource)
+		mockStorage.On("GetPage", mock.Anything, "USDT/RUB", from, to, models.RateCursor{}, 2).
+			Return([]models.CurrencyRate{rate(1, "USDT/RUB"), rate(2, "USDT/RUB")}, nil).Once()
+		mockStorage.On("GetPage", mock.Anything, "USDT/RUB", from, to, models.CursorAfter(rate(2, "USDT/RUB")), 2).
+			Return([]models.CurrencyRate{rate(3, "USDT/RUB")}, nil).Once()
+		mockStorage.On("GetPage", mock.Anything, "USDT/USD", from, to, models.RateCursor{}, 2).
+			Return([]models.CurrencyRate{rate(4, "USDT/USD"), rate(5, "USDT/USD")}, nil).Once()
+		mockStorage.On("GetPage", mock.Anything, "USDT/USD", from, to, models.CursorAfter(rate(5, "USDT/USD")), 2).
+			Return([]models.CurrencyRate{}, nil).Once()
+
+		var ids []int64
+		err := NewExportService(mockStorage, 2).Export(context.Background(), []string{"USDT/RUB", "USDT/USD"}, from, to,
+			func(rate models.CurrencyRate) error {
+				ids = append(ids, rate.ID)
+				return nil
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("WriteError", func(t *testing.T) {
+		mockStorage := new(MockRatePageSource)
+		mockStorage.On("GetPage", mock.Anything, "USDT/RUB", from, to, models.RateCursor{}, 2).
+			Return([]models.CurrencyRate{rate(1, "USDT/RUB"), rate(2, "USDT/RUB")}, nil).Once()
+
+		writeErr := errors.New("клиент отключился")
+		calls := 0
+		err := NewExportService(mockStorage, 2).Export(context.Background(), []string{"USDT/RUB"}, from, to,
+			func(models.CurrencyRate) error {
+				calls++
+				return writeErr
+			})
+		assert.ErrorIs(t, err, writeErr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("StorageError", func(t *testing.T) {
+		mockStorage := new(MockRatePageSource)
+		mockStorage.On("GetPage", mock.Anything, "USDT/RUB", from, to, models.RateCursor{}, 2).
+			Return([]models.CurrencyRate{}, errors.New("db error"))
+
+		err := NewExportService(mockStorage, 2).Export(context.Background(), []string{"USDT/RUB"}, from, to,
+			func(models.CurrencyRate) error { return nil })
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// RateImporter загружает историю курсов из файла. Строки проверяются теми же
+// правилами, что и снимки биржи, кроме возраста снимка: история по
+// определению старая. Курсы одной пары должны идти в файле по времени, а
+// скачок цены считается от предыдущего курса пары - из файла или уже
+// сохранённого, смотря какой из них позже. Более старые курсы, чем последний
+// сохранённый, принимаются: так заполняются пропуски после сбоев. Отклонённые
+// строки, как и снимки биржи, откладываются в карантин.
+// Курсы без поставщика сохраняются с models.SourceImport; снимки, уже
+// сохранённые с теми же парой, временем биржи и поставщиком, пропускаются как
+// дубли.
+type RateImporter struct {
+	storage    RateImportServicer
+	quarantine QuarantineServicer
+	conf       config.Validation
+	batchSize  int
+	now        func() time.Time
+}
+
+func NewRateImporter(storage RateImportServicer, quarantine QuarantineServicer, conf config.Validation, batchSize int) *RateImporter {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	return &RateImporter{
+		storage:    storage,
+		quarantine: quarantine,
+		conf:       conf,
+		batchSize:  batchSize,
+		now:        time.Now,
+	}
+}
+
+// Import читает src до конца и пишет курсы пакетами по batchSize строк. После
+// каждого записанного пакета ход сохраняется в progress, и повторный запуск
+// продолжает с первой необработанной строки. Если импорт прервался между
+// записью пакета и сохранением хода, пакет прочитается заново и уйдёт в дубли.
+func (im *RateImporter) Import(ctx context.Context, src RateReader, progress ImportProgressStore) (models.ImportProgress, error) {
+	state, err := progress.Load()
+	if err != nil {
+		return state, fmt.Errorf("Service.Import: %w", err)
+	}
+	if state.Rejected == nil {
+		state.Rejected = make(map[string]int64)
+	}
+
+	// Пропущенные строки всё равно проверяются, чтобы восстановить последние
+	// курсы пар для проверки порядка и скачков цены.
+	last := make(map[string]models.CurrencyRate)
+	for i := int64(0); i < state.Processed; i++ {
+		rate, err := src.Read()
+		if errors.Is(err, io.EOF) {
+			return state, fmt.Errorf("Service.Import: в файле %d строк, а обработано уже %d", i, state.Processed)
+		}
+		if errors.Is(err, models.ErrInvalidRecord) {
+			continue
+		}
+		if err != nil {
+			return state, fmt.Errorf("Service.Import: %w", err)
+		}
+		im.accept(normalizeImported(rate), last, nil)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return state, fmt.Errorf("Service.Import: %w", err)
+		}
+		rows := make([]models.CurrencyRate, 0, im.batchSize)
+		rejected := make(map[string]int64)
+		var read int64
+		eof := false
+		for read < int64(im.batchSize) {
+			rate, err := src.Read()
+			if errors.Is(err, io.EOF) {
+				eof = true
+				break
+			}
+			read++
+			if errors.Is(err, models.ErrInvalidRecord) {
+				rejected[models.RejectInvalidRecord]++
+				continue
+			}
+			if err != nil {
+				return state, fmt.Errorf("Service.Import: %w", err)
+			}
+			rows = append(rows, normalizeImported(rate))
+		}
+
+		stored, err := im.stored(ctx, rows)
+		if err != nil {
+			return state, fmt.Errorf("Service.Import: %w", err)
+		}
+		batch := make([]models.CurrencyRate, 0, len(rows))
+		for _, rate := range rows {
+			reason, details := im.accept(rate, last, stored[rate.Pair])
+			if reason == "" {
+				batch = append(batch, rate)
+				continue
+			}
+			if err := quarantineRate(ctx, im.quarantine, rate, reason, details, im.now()); err != nil {
+				return state, fmt.Errorf("Service.Import: %w", err)
+			}
+			rejected[reason]++
+		}
+		fresh := dedupe(batch, stored)
+		if len(fresh) > 0 {
+			if err := im.storage.CreateBatch(ctx, fresh); err != nil {
+				return state, fmt.Errorf("Service.Import: %w", err)
+			}
+		}
+		if read > 0 {
+			state.Processed += read
+			state.Imported += int64(len(fresh))
+			state.Duplicates += int64(len(batch) - len(fresh))
+			for reason, n := range rejected {
+				state.Rejected[reason] += n
+			}
+			if err := progress.Save(state); err != nil {
+				return state, fmt.Errorf("Service.Import: %w", err)
+			}
+		}
+		if eof {
+			return state, nil
+		}
+	}
+}
+
+// accept проверяет курс и, если он прошёл проверку, запоминает его как
+// последний курс пары. Порядок проверяется по предыдущему курсу файла, а
+// скачок цены - по более позднему из предыдущего курса файла и последнего
+// сохранённого курса пары не позже проверяемого. Возвращает причину
+// отклонения и подробности для карантина.
+func (im *RateImporter) accept(rate models.CurrencyRate, last map[string]models.CurrencyRate, stored []models.CurrencyRate) (string, string) {
+	if reason, details := checkPrices(rate); reason != "" {
+		return reason, details
+	}
+	if reason, details := checkFuture(rate, im.now(), im.conf); reason != "" {
+		return reason, details
+	}
+	prev, ok := last[rate.Pair]
+	// Сохранённый курс позже предыдущего курса файла, но не позже проверяемого,
+	// поэтому с ним порядок заведомо соблюдён.
+	if before, found := latestNotAfter(stored, rate.Timestamp); found && (!ok || before.Timestamp.After(prev.Timestamp)) {
+		prev, ok = before, true
+	}
+	if ok {
+		if reason, details := checkAgainstLast(rate, prev, im.conf); reason != "" {
+			return reason, details
+		}
+	}
+	last[rate.Pair] = rate
+	return "", ""
+}
+
+// latestNotAfter возвращает самый поздний курс не позже ts.
+func latestNotAfter(rates []models.CurrencyRate, ts time.Time) (models.CurrencyRate, bool) {
+	var (
+		latest models.CurrencyRate
+		found  bool
+	)
+	for _, rate := range rates {
+		if !rate.Timestamp.After(ts) && (!found || rate.Timestamp.After(latest.Timestamp)) {
+			latest, found = rate, true
+		}
+	}
+	return latest, found
+}
+
+// stored загружает сохранённые курсы пар пакета за его период и окно скачка
+// цены перед ним: по ним проверяются скачки и находятся дубли.
+func (im *RateImporter) stored(ctx context.Context, rows []models.CurrencyRate) (map[string][]models.CurrencyRate, error) {
+	type span struct{ from, to time.Time }
+	spans := make(map[string]span)
+	for _, rate := range rows {
+		s, ok := spans[rate.Pair]
+		if !ok || rate.Timestamp.Before(s.from) {
+			s.from = rate.Timestamp
+		}
+		if !ok || rate.Timestamp.After(s.to) {
+			s.to = rate.Timestamp
+		}
+		spans[rate.Pair] = s
+	}
+
+	stored := make(map[string][]models.CurrencyRate, len(spans))
+	for pair, s := range spans {
+		from := s.from
+		if im.conf.MaxJumpPercent > 0 && im.conf.JumpWindow > 0 {
+			from = from.Add(-im.conf.JumpWindow)
+		}
+		rates, err := im.storage.GetHistory(ctx, pair, from, s.to)
+		if err != nil {
+			return nil, err
+		}
+		stored[pair] = rates
+	}
+	return stored, nil
+}
+
+// dedupe убирает из пакета снимки, которые уже есть в хранилище или раньше в
+// этом же пакете.
+func dedupe(batch []models.CurrencyRate, stored map[string][]models.CurrencyRate) []models.CurrencyRate {
+	type key struct {
+		pair   string
+		source string
+		ts     int64
+	}
+	seen := make(map[key]struct{})
+	for pair, rates := range stored {
+		for _, rate := range rates {
+			seen[key{pair, rate.Source, rate.Timestamp.UnixMicro()}] = struct{}{}
+		}
+	}
+
+	fresh := make([]models.CurrencyRate, 0, len(batch))
+	for _, rate := range batch {
+		k := key{rate.Pair, rate.Source, rate.Timestamp.UnixMicro()}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		fresh = append(fresh, rate)
+	}
+	return fresh
+}
+
+// normalizeImported отбрасывает доли микросекунды: база хранит время с такой
+// точностью, и иначе повторный импорт не узнал бы сохранённые курсы.
+func normalizeImported(rate models.CurrencyRate) models.CurrencyRate {
+	rate.Timestamp = rate.Timestamp.UTC().Truncate(time.Microsecond)
+	if rate.Source == "" {
+		rate.Source = models.SourceImport
+	}
+	return rate
+}
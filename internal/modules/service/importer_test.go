@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// fakeImportStorage хранит курсы в срезе и может отказать в записи.
+type fakeImportStorage struct {
+	rates   []models.CurrencyRate
+	batches int
+	failAt  int
+}
+
+func (f *fakeImportStorage) CreateBatch(ctx context.Context, rates []models.CurrencyRate) error {
+	f.batches++
+	if f.failAt > 0 && f.batches == f.failAt {
+		return errors.New("db error")
+	}
+	f.rates = append(f.rates, rates...)
+	return nil
+}
+
+func (f *fakeImportStorage) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	var rates []models.CurrencyRate
+	for _, rate := range f.rates {
+		if rate.Pair == pair && !rate.Timestamp.Before(from) && !rate.Timestamp.After(to) {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// fakeRateReader отдаёт заранее заданные строки; nil-курс - некорректная строка.
+type fakeRateReader struct {
+	rows []*models.CurrencyRate
+	pos  int
+}
+
+func (f *fakeRateReader) Read() (models.CurrencyRate, error) {
+	if f.pos >= len(f.rows) {
+		return models.CurrencyRate{}, io.EOF
+	}
+	row := f.rows[f.pos]
+	f.pos++
+	if row == nil {
+		return models.CurrencyRate{}, fmt.Errorf("%w: строка %d", models.ErrInvalidRecord, f.pos)
+	}
+	return *row, nil
+}
+
+// fakeQuarantine запоминает отложенные в карантин курсы.
+type fakeQuarantine struct {
+	rates []models.QuarantinedRate
+}
+
+func (f *fakeQuarantine) Create(ctx context.Context, rate models.QuarantinedRate) error {
+	f.rates = append(f.rates, rate)
+	return nil
+}
+
+type memoryProgress struct {
+	state models.ImportProgress
+	saves []int64
+}
+
+func (m *memoryProgress) Load() (models.ImportProgress, error) { return m.state, nil }
+
+func (m *memoryProgress) Save(progress models.ImportProgress) error {
+	m.state = progress
+	m.saves = append(m.saves, progress.Processed)
+	return nil
+}
+
+func TestRateImporter_Import(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	conf := config.Validation{MaxAge: 5 * time.Minute, MaxJumpPercent: 20, JumpWindow: time.Hour}
+	row := func(pair string, ask, bid float64, minute int) *models.CurrencyRate {
+		return &models.CurrencyRate{Pair: pair, AskPrice: ask, BidPrice: bid, Timestamp: now.Add(time.Duration(minute-60) * time.Minute)}
+	}
+	newImporter := func(storage RateImportServicer, batch int) *RateImporter {
+		im := NewRateImporter(storage, &fakeQuarantine{}, conf, batch)
+		im.now = func() time.Time { return now }
+		return im
+	}
+	rows := func() []*models.CurrencyRate {
+		return []*models.CurrencyRate{
+			row("USDT/RUB", 91, 90, 1),
+			row("USDT/RUB", 0, 90, 2),    // zero_price
+			nil,                          // invalid_record
+			row("USDT/RUB", 91, 92, 3),   // crossed_book
+			row("USDT/RUB", 91.5, 90, 4), // дубль уже сохранённого
+			row("USDT/USD", 1.01, 0.99, 4),
+			row("USDT/RUB", 91, 90, 0),   // out_of_order_timestamp
+			row("USDT/RUB", 200, 199, 5), // price_jump
+			row("USDT/RUB", 91, 90, 6),
+			row("USDT/RUB", 91, 90, 6),     // дубль внутри файла
+			row("USDT/RUB", 91, 90, 24*60), // future_timestamp
+		}
+	}
+
+	t.Run("ValidatesAndDeduplicates", func(t *testing.T) {
+		stored := *row("USDT/RUB", 91.5, 90, 4)
+		stored.Source = models.SourceImport
+		storage := &fakeImportStorage{rates: []models.CurrencyRate{stored}}
+		progress := &memoryProgress{}
+
+		im := newImporter(storage, 4)
+		report, err := im.Import(context.Background(), &fakeRateReader{rows: rows()}, progress)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), report.Processed)
+		assert.Equal(t, int64(3), report.Imported)
+		assert.Equal(t, int64(2), report.Duplicates)
+		assert.Equal(t, map[string]int64{
+			models.RejectZeroPrice:     1,
+			models.RejectInvalidRecord: 1,
+			models.RejectCrossedBook:   1,
+			models.RejectOutOfOrder:    1,
+			models.RejectPriceJump:     1,
+			models.RejectFuture:        1,
+		}, report.Rejected)
+		assert.Len(t, storage.rates, 4)
+		assert.Equal(t, []int64{4, 8, 11}, progress.saves)
+
+		// Отклонённые строки, кроме неразобранной, попадают в карантин.
+		var reasons []string
+		for _, rate := range im.quarantine.(*fakeQuarantine).rates {
+			reasons = append(reasons, rate.Reason)
+			assert.Equal(t, now, rate.CreatedAt)
+		}
+		assert.Equal(t, []string{models.RejectZeroPrice, models.RejectCrossedBook, models.RejectOutOfOrder, models.RejectPriceJump, models.RejectFuture}, reasons)
+	})
+
+	t.Run("ChecksAgainstStored", func(t *testing.T) {
+		stored := *row("USDT/RUB", 91, 90, 10)
+		stored.Source = "garantex"
+		storage := &fakeImportStorage{rates: []models.CurrencyRate{stored}}
+		im := newImporter(storage, 10)
+
+		report, err := im.Import(context.Background(), &fakeRateReader{rows: []*models.CurrencyRate{
+			row("USDT/RUB", 91.2, 90.2, 5),  // старше сохранённого курса - заполняет пропуск
+			row("USDT/RUB", 200, 199, 11),   // скачок относительно сохранённого курса
+			row("USDT/RUB", 91.3, 90.3, 12), // в пределах порога от сохранённого курса
+		}}, &memoryProgress{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), report.Imported)
+		assert.Equal(t, map[string]int64{models.RejectPriceJump: 1}, report.Rejected)
+		quarantined := im.quarantine.(*fakeQuarantine).rates
+		require.Len(t, quarantined, 1)
+		assert.Equal(t, 200.0, quarantined[0].AskPrice)
+		assert.Contains(t, quarantined[0].Details, "относительно 90.5")
+	})
+
+	t.Run("ResumesAfterFailure", func(t *testing.T) {
+		storage := &fakeImportStorage{failAt: 2}
+		progress := &memoryProgress{}
+
+		_, err := newImporter(storage, 4).Import(context.Background(), &fakeRateReader{rows: rows()}, progress)
+		require.Error(t, err)
+		assert.Equal(t, int64(4), progress.state.Processed)
+		assert.Len(t, storage.rates, 1)
+
+		storage.failAt = 0
+		report, err := newImporter(storage, 4).Import(context.Background(), &fakeRateReader{rows: rows()}, progress)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), report.Processed)
+		assert.Equal(t, int64(4), report.Imported)
+		// Порядок и скачок цены проверяются и после продолжения.
+		assert.Equal(t, int64(1), report.Rejected[models.RejectOutOfOrder])
+		assert.Equal(t, int64(1), report.Rejected[models.RejectPriceJump])
+		assert.Len(t, storage.rates, 4)
+	})
+
+	t.Run("RepeatedImportIsIdempotent", func(t *testing.T) {
+		storage := &fakeImportStorage{}
+		_, err := newImporter(storage, 3).Import(context.Background(), &fakeRateReader{rows: rows()}, &memoryProgress{})
+		require.NoError(t, err)
+
+		report, err := newImporter(storage, 3).Import(context.Background(), &fakeRateReader{rows: rows()}, &memoryProgress{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), report.Imported)
+		assert.Equal(t, int64(5), report.Duplicates)
+		assert.Len(t, storage.rates, 4)
+	})
+
+	t.Run("FileShorterThanProgress", func(t *testing.T) {
+		progress := &memoryProgress{state: models.ImportProgress{Processed: 20}}
+		_, err := newImporter(&fakeImportStorage{}, 4).Import(context.Background(), &fakeRateReader{rows: rows()}, progress)
+		assert.Error(t, err)
+	})
+}
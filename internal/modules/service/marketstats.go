@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"usdt/internal/models"
+)
+
+type MarketStatsService struct {
+	storage MarketStatsServicer
+}
+
+func NewMarketStatsService(storage MarketStatsServicer) *MarketStatsService {
+	return &MarketStatsService{storage: storage}
+}
+
+func (m *MarketStatsService) GetCurrent(ctx context.Context, pair string) (models.MarketStats, error) {
+	stats, err := m.storage.GetLatest(ctx, pair)
+	if err != nil {
+		return models.MarketStats{}, fmt.Errorf("Service.GetMarketStats: %w", err)
+	}
+	return stats, nil
+}
+
+func (m *MarketStatsService) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error) {
+	stats, err := m.storage.GetHistory(ctx, pair, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Service.GetMarketStatsHistory: %w", err)
+	}
+	return stats, nil
+}
+
+// ComputeMarketStats считает метрики по стакану. Лучшими ценами считаются
+// первые уровни asks и bids, как и при получении курса.
+func ComputeMarketStats(pair string, book models.OrderBook) models.MarketStats {
+	stats := models.MarketStats{
+		Pair:      pair,
+		Timestamp: book.Timestamp,
+	}
+	if len(book.Asks) == 0 || len(book.Bids) == 0 {
+		return stats
+	}
+	stats.AskPrice = book.Asks[0].Price
+	stats.BidPrice = book.Bids[0].Price
+	stats.MidPrice = (stats.AskPrice + stats.BidPrice) / 2
+	stats.Spread = stats.AskPrice - stats.BidPrice
+	if stats.MidPrice == 0 {
+		return stats
+	}
+	stats.SpreadPercent = stats.Spread / stats.MidPrice * 100
+
+	stats.AskDepth05 = askDepth(book.Asks, stats.MidPrice*(1+0.005))
+	stats.BidDepth05 = bidDepth(book.Bids, stats.MidPrice*(1-0.005))
+	stats.AskDepth1 = askDepth(book.Asks, stats.MidPrice*(1+0.01))
+	stats.BidDepth1 = bidDepth(book.Bids, stats.MidPrice*(1-0.01))
+	stats.AskDepth2 = askDepth(book.Asks, stats.MidPrice*(1+0.02))
+	stats.BidDepth2 = bidDepth(book.Bids, stats.MidPrice*(1-0.02))
+	if total := stats.AskDepth1 + stats.BidDepth1; total > 0 {
+		stats.Imbalance = (stats.BidDepth1 - stats.AskDepth1) / total
+	}
+	return stats
+}
+
+func askDepth(levels []models.BookLevel, limit float64) float64 {
+	var depth float64
+	for _, level := range levels {
+		if level.Price > 0 && level.Price <= limit {
+			depth += level.Volume
+		}
+	}
+	return depth
+}
+
+func bidDepth(levels []models.BookLevel, limit float64) float64 {
+	var depth float64
+	for _, level := range levels {
+		if level.Price > 0 && level.Price >= limit {
+			depth += level.Volume
+		}
+	}
+	return depth
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"usdt/internal/models"
+)
+
+func TestComputeMarketStats(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Depth", func(t *testing.T) {
+		book := models.OrderBook{
+			Timestamp: now,
+			Asks: []models.BookLevel{
+				{Price: 100.2, Volume: 1},
+				{Price: 100.8, Volume: 2},
+				{Price: 101.5, Volume: 4},
+				{Price: 103, Volume: 8},
+			},
+			Bids: []models.BookLevel{
+				{Price: 99.8, Volume: 3},
+				{Price: 99.2, Volume: 5},
+				{Price: 98.5, Volume: 7},
+				{Price: 90, Volume: 11},
+			},
+		}
+		stats := ComputeMarketStats("USDT/RUB", book)
+		assert.Equal(t, "USDT/RUB", stats.Pair)
+		assert.Equal(t, now, stats.Timestamp)
+		assert.Equal(t, 100.2, stats.AskPrice)
+		assert.Equal(t, 99.8, stats.BidPrice)
+		assert.InDelta(t, 100.0, stats.MidPrice, 1e-9)
+		assert.InDelta(t, 0.4, stats.Spread, 1e-9)
+		assert.InDelta(t, 0.4, stats.SpreadPercent, 1e-9)
+		assert.Equal(t, 1.0, stats.AskDepth05)
+		assert.Equal(t, 3.0, stats.BidDepth05)
+		assert.Equal(t, 3.0, stats.AskDepth1)
+		assert.Equal(t, 8.0, stats.BidDepth1)
+		assert.Equal(t, 7.0, stats.AskDepth2)
+		assert.Equal(t, 15.0, stats.BidDepth2)
+		assert.InDelta(t, 5.0/11.0, stats.Imbalance, 1e-9)
+	})
+
+	t.Run("EmptyBook", func(t *testing.T) {
+		stats := ComputeMarketStats("USDT/RUB", models.OrderBook{Timestamp: now})
+		assert.Equal(t, models.MarketStats{Pair: "USDT/RUB", Timestamp: now}, stats)
+	})
+}
+
+func TestUsdtService_GetRatesStoresMarketStats(t *testing.T) {
+	now := time.Now()
+	mockAPI := new(MockRequestAPI)
+	mockAPI.On("GetOrderBook", "RUB").Return(newBook(101, 99, now), nil)
+	mockStorage := new(MockUsdtStorage)
+	mockStorage.On("Create", mock.Anything, mock.Anything).Return(models.CurrencyRate{Pair: "USDT/RUB"}, true, nil)
+	mockStats := newStatsStorage()
+
+	service := NewUsdtService(mockStorage, mockAPI, "garantex", newPassingValidator(), mockStats, zap.NewNop())
+	_, err := service.GetRates(context.Background(), "RUB")
+	assert.NoError(t, err)
+	mockStats.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(stats models.MarketStats) bool {
+		return stats.Pair == "USDT/RUB" && stats.MidPrice == 100 && stats.AskDepth1 == 10 && stats.BidDepth1 == 10
+	}))
+}
+
+func TestMarketStatsService(t *testing.T) {
+	now := time.Now()
+	current := models.MarketStats{Pair: "USDT/RUB", MidPrice: 100, Timestamp: now}
+	mockStats := new(MockMarketStatsStorage)
+	mockStats.On("GetLatest", mock.Anything, "USDT/RUB").Return(current, nil)
+	mockStats.On("GetHistory", mock.Anything, "USDT/RUB", now.Add(-time.Hour), now).Return([]models.MarketStats{current}, nil)
+
+	service := NewMarketStatsService(mockStats)
+	stats, err := service.GetCurrent(context.Background(), "USDT/RUB")
+	assert.NoError(t, err)
+	assert.Equal(t, current, stats)
+	history, err := service.GetHistory(context.Background(), "USDT/RUB", now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MarketStats{current}, history)
+}
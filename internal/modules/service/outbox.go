@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"usdt/config"
+)
+
+// maxOutboxBackoff ограничивает задержку повторной публикации события.
+const maxOutboxBackoff = 10 * time.Minute
+
+// OutboxReport - итог одного прохода релея.
+type OutboxReport struct {
+	Published int
+	Failed    int
+	Deleted   int64
+}
+
+// OutboxRelay публикует события из outbox в приёмник. Событие отмечается
+// опубликованным только после успешного Publish, поэтому при сбое между
+// публикацией и отметкой оно будет отправлено повторно: доставка "хотя бы
+// один раз". Неудачные события повторяются с растущей задержкой и не
+// задерживают остальные, так что порядок публикации не гарантируется.
+type OutboxRelay struct {
+	storage OutboxServicer
+	sink    OutboxSink
+	conf    config.Outbox
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewOutboxRelay(storage OutboxServicer, sink OutboxSink, conf config.Outbox, logger *zap.Logger) *OutboxRelay {
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 1
+	}
+	return &OutboxRelay{
+		storage: storage,
+		sink:    sink,
+		conf:    conf,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Run публикует события каждые Interval до отмены ctx. Если проход выбрал
+// полный пакет, следующий начинается сразу, чтобы быстрее разобрать очередь.
+func (o *OutboxRelay) Run(ctx context.Context) {
+	if o.conf.Interval <= 0 {
+		o.logger.Info("Публикация событий outbox отключена")
+		return
+	}
+	ticker := time.NewTicker(o.conf.Interval)
+	defer ticker.Stop()
+	for {
+		report, err := o.RunOnce(ctx)
+		if err != nil {
+			o.logger.Error("OutboxRelay.RunOnce error:", zap.Error(err))
+		}
+		if err == nil && report.Published+report.Failed >= o.conf.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *OutboxRelay) RunOnce(ctx context.Context) (OutboxReport, error) {
+	var report OutboxReport
+	now := o.now()
+	events, err := o.storage.ListPending(ctx, now, o.conf.BatchSize)
+	if err != nil {
+		return report, fmt.Errorf("Service.OutboxRelay: %w", err)
+	}
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return report, nil
+		}
+		if err := o.sink.Publish(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				// Остановка сервиса - не ошибка приёмника, попытку не считаем.
+				return report, nil
+			}
+			report.Failed++
+			attempt := event.Attempts + 1
+			o.logger.Warn("Не удалось опубликовать событие outbox", zap.Int64("event_id", event.ID),
+				zap.String("idempotency_key", event.IdempotencyKey), zap.Int("attempt", attempt), zap.Error(err))
+			if err := o.storage.MarkFailed(ctx, event.ID, err.Error(), o.now().Add(o.retryDelay(attempt))); err != nil {
+				return report, fmt.Errorf("Service.OutboxRelay: %w", err)
+			}
+			continue
+		}
+		if err := o.storage.MarkPublished(ctx, event.ID, o.now()); err != nil {
+			return report, fmt.Errorf("Service.OutboxRelay: %w", err)
+		}
+		report.Published++
+	}
+	if o.conf.Retention > 0 {
+		report.Deleted, err = o.storage.DeletePublished(ctx, now.Add(-o.conf.Retention))
+		if err != nil {
+			return report, fmt.Errorf("Service.OutboxRelay: %w", err)
+		}
+	}
+	return report, nil
+}
+
+func (o *OutboxRelay) retryDelay(attempt int) time.Duration {
+	if attempt > 32 {
+		return maxOutboxBackoff
+	}
+	delay := o.conf.RetryBackoff << (attempt - 1)
+	if delay <= 0 || delay > maxOutboxBackoff {
+		return maxOutboxBackoff
+	}
+	return delay
+}
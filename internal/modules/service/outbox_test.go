@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// MockOutboxStorage - mock для интерфейса OutboxServicer
+type MockOutboxStorage struct {
+	mock.Mock
+}
+
+func (m *MockOutboxStorage) ListPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxStorage) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStorage) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, reason, nextAttemptAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStorage) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockOutboxSink - mock для интерфейса OutboxSink
+type MockOutboxSink struct {
+	mock.Mock
+}
+
+func (m *MockOutboxSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func TestOutboxRelay_RunOnce(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	conf := config.Outbox{BatchSize: 10, RetryBackoff: time.Second, Retention: time.Hour}
+	first := models.OutboxEvent{ID: 1, IdempotencyKey: "rate.created:1:1"}
+	second := models.OutboxEvent{ID: 2, IdempotencyKey: "rate.created:2:1", Attempts: 2}
+	third := models.OutboxEvent{ID: 3, IdempotencyKey: "rate.created:3:1"}
+
+	t.Run("PublishesAndSchedulesRetries", func(t *testing.T) {
+		mockStorage := new(MockOutboxStorage)
+		mockSink := new(MockOutboxSink)
+		mockStorage.On("ListPending", mock.Anything, now, 10).Return([]models.OutboxEvent{first, second, third}, nil)
+		mockSink.On("Publish", mock.Anything, first).Return(nil)
+		mockSink.On("Publish", mock.Anything, second).Return(errors.New("503"))
+		mockSink.On("Publish", mock.Anything, third).Return(nil)
+		mockStorage.On("MarkPublished", mock.Anything, int64(1), now).Return(nil).Once()
+		// Третья попытка: задержка 1s << 2.
+		mockStorage.On("MarkFailed", mock.Anything, int64(2), "503", now.Add(4*time.Second)).Return(nil).Once()
+		mockStorage.On("MarkPublished", mock.Anything, int64(3), now).Return(nil).Once()
+		mockStorage.On("DeletePublished", mock.Anything, now.Add(-time.Hour)).Return(int64(5), nil)
+
+		relay := NewOutboxRelay(mockStorage, mockSink, conf, zap.NewNop())
+		relay.now = func() time.Time { return now }
+		report, err := relay.RunOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, OutboxReport{Published: 2, Failed: 1, Deleted: 5}, report)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("MarkPublishedError", func(t *testing.T) {
+		mockStorage := new(MockOutboxStorage)
+		mockSink := new(MockOutboxSink)
+		mockStorage.On("ListPending", mock.Anything, now, 10).Return([]models.OutboxEvent{first, third}, nil)
+		mockSink.On("Publish", mock.Anything, first).Return(nil)
+		mockStorage.On("MarkPublished", mock.Anything, int64(1), now).Return(errors.New("db error"))
+
+		relay := NewOutboxRelay(mockStorage, mockSink, conf, zap.NewNop())
+		relay.now = func() time.Time { return now }
+		_, err := relay.RunOnce(context.Background())
+		assert.Error(t, err)
+		// Событие останется неопубликованным и уйдёт повторно в следующий проход.
+		mockSink.AssertNotCalled(t, "Publish", mock.Anything, third)
+	})
+
+	t.Run("CancelledContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mockStorage := new(MockOutboxStorage)
+		mockSink := new(MockOutboxSink)
+		mockStorage.On("ListPending", mock.Anything, now, 10).Return([]models.OutboxEvent{first}, nil)
+		mockSink.On("Publish", mock.Anything, first).Run(func(mock.Arguments) { cancel() }).Return(context.Canceled)
+
+		relay := NewOutboxRelay(mockStorage, mockSink, conf, zap.NewNop())
+		relay.now = func() time.Time { return now }
+		report, err := relay.RunOnce(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, report.Failed)
+		mockStorage.AssertNotCalled(t, "MarkFailed", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOutboxRelay_RetryDelay(t *testing.T) {
+	relay := NewOutboxRelay(nil, nil, config.Outbox{RetryBackoff: time.Second}, zap.NewNop())
+	assert.Equal(t, time.Second, relay.retryDelay(1))
+	assert.Equal(t, 8*time.Second, relay.retryDelay(4))
+	assert.Equal(t, maxOutboxBackoff, relay.retryDelay(20))
+	assert.Equal(t, maxOutboxBackoff, relay.retryDelay(100))
+}
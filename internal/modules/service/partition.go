@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// PartitionReport - итог одного запуска обслуживания секций.
+type PartitionReport struct {
+	Created []string
+	Dropped []string
+}
+
+// PartitionService поддерживает месячные секции currency_rates: заранее
+// создаёт секции на ближайшие месяцы, чтобы новые курсы не попадали в секцию
+// по умолчанию, и удаляет секции, целиком вышедшие за срок хранения.
+type PartitionService struct {
+	storage PartitionServicer
+	policy  config.Partition
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewPartitionService(storage PartitionServicer, policy config.Partition, logger *zap.Logger) *PartitionService {
+	if policy.MonthsAhead < 0 {
+		policy.MonthsAhead = 0
+	}
+	return &PartitionService{
+		storage: storage,
+		policy:  policy,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Run обслуживает секции сразу при старте и затем каждые Interval до отмены
+// ctx. Нулевой интервал отключает обслуживание.
+func (p *PartitionService) Run(ctx context.Context) {
+	if p.policy.Interval <= 0 {
+		p.logger.Info("Обслуживание секций курсов отключено")
+		return
+	}
+	ticker := time.NewTicker(p.policy.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.RunOnce(ctx); err != nil {
+			p.logger.Error("PartitionService.RunOnce error:", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *PartitionService) RunOnce(ctx context.Context) (PartitionReport, error) {
+	var report PartitionReport
+	now := p.now().UTC()
+	partitions, err := p.storage.ListPartitions(ctx)
+	if err != nil {
+		return report, fmt.Errorf("Service.Partition: %w", err)
+	}
+	existing := make(map[int64]bool, len(partitions))
+	for _, partition := range partitions {
+		existing[partition.From.Unix()] = true
+	}
+
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= p.policy.MonthsAhead; i++ {
+		month := current.AddDate(0, i, 0)
+		if existing[month.Unix()] {
+			continue
+		}
+		partition, err := p.storage.CreatePartition(ctx, month)
+		if err != nil {
+			return report, fmt.Errorf("Service.Partition: %w", err)
+		}
+		report.Created = append(report.Created, partition.Name)
+	}
+
+	if p.policy.Retention > 0 {
+		cutoff := now.Add(-p.policy.Retention)
+		for _, partition := range expiredPartitions(partitions, cutoff) {
+			if err := p.storage.DropPartition(ctx, partition.Name); err != nil {
+				return report, fmt.Errorf("Service.Partition: %w", err)
+			}
+			report.Dropped = append(report.Dropped, partition.Name)
+		}
+	}
+
+	if len(report.Created) > 0 || len(report.Dropped) > 0 {
+		p.logger.Info("Секции курсов обновлены",
+			zap.Strings("created", report.Created),
+			zap.Strings("dropped", report.Dropped))
+	}
+	return report, nil
+}
+
+// expiredPartitions возвращает секции, все курсы которых старше cutoff.
+func expiredPartitions(partitions []models.Partition, cutoff time.Time) []models.Partition {
+	var expired []models.Partition
+	for _, partition := range partitions {
+		if !partition.To.After(cutoff) {
+			expired = append(expired, partition)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// MockPartitionStorage - mock для интерфейса PartitionServicer
+type MockPartitionStorage struct {
+	mock.Mock
+}
+
+func (m *MockPartitionStorage) ListPartitions(ctx context.Context) ([]models.Partition, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Partition), args.Error(1)
+}
+
+func (m *MockPartitionStorage) CreatePartition(ctx context.Context, month time.Time) (models.Partition, error) {
+	args := m.Called(ctx, month)
+	return args.Get(0).(models.Partition), args.Error(1)
+}
+
+func (m *MockPartitionStorage) DropPartition(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func monthPartition(year int, month time.Month) models.Partition {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return models.Partition{Name: from.Format("currency_rates_2006_01"), From: from, To: from.AddDate(0, 1, 0)}
+}
+
+func TestPartitionService_RunOnce(t *testing.T) {
+	now := time.Date(2024, 11, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("CreatesMissingAndDropsExpired", func(t *testing.T) {
+		mockStorage := new(MockPartitionStorage)
+		mockStorage.On("ListPartitions", mock.Anything).Return([]models.Partition{
+			monthPartition(2024, 8),
+			monthPartition(2024, 9),
+			monthPartition(2024, 10),
+			monthPartition(2024, 11),
+			monthPartition(2024, 12),
+		}, nil)
+		mockStorage.On("CreatePartition", mock.Anything, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).
+			Return(monthPartition(2025, 1), nil).Once()
+		mockStorage.On("CreatePartition", mock.Anything, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)).
+			Return(monthPartition(2025, 2), nil).Once()
+		mockStorage.On("DropPartition", mock.Anything, "currency_rates_2024_08").Return(nil).Once()
+
+		// 60 дней назад - 16 сентября: сентябрьская секция ещё содержит
+		// курсы в пределах срока хранения и должна остаться.
+		service := NewPartitionService(mockStorage, config.Partition{Interval: time.Hour, MonthsAhead: 3, Retention: 60 * 24 * time.Hour}, zap.NewNop())
+		service.now = func() time.Time { return now }
+		report, err := service.RunOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, PartitionReport{
+			Created: []string{"currency_rates_2025_01", "currency_rates_2025_02"},
+			Dropped: []string{"currency_rates_2024_08"},
+		}, report)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("RetentionDisabled", func(t *testing.T) {
+		mockStorage := new(MockPartitionStorage)
+		mockStorage.On("ListPartitions", mock.Anything).Return([]models.Partition{
+			monthPartition(2000, 1),
+			monthPartition(2024, 11),
+		}, nil)
+
+		service := NewPartitionService(mockStorage, config.Partition{Interval: time.Hour}, zap.NewNop())
+		service.now = func() time.Time { return now }
+		report, err := service.RunOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, report.Created)
+		assert.Empty(t, report.Dropped)
+		mockStorage.AssertNotCalled(t, "DropPartition", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateError", func(t *testing.T) {
+		mockStorage := new(MockPartitionStorage)
+		mockStorage.On("ListPartitions", mock.Anything).Return([]models.Partition{}, nil)
+		mockStorage.On("CreatePartition", mock.Anything, mock.Anything).Return(models.Partition{}, errors.New("db error"))
+
+		service := NewPartitionService(mockStorage, config.Partition{Interval: time.Hour, MonthsAhead: 1, Retention: time.Hour}, zap.NewNop())
+		service.now = func() time.Time { return now }
+		_, err := service.RunOnce(context.Background())
+		assert.Error(t, err)
+		mockStorage.AssertNumberOfCalls(t, "CreatePartition", 1)
+		mockStorage.AssertNotCalled(t, "DropPartition", mock.Anything, mock.Anything)
+	})
+}
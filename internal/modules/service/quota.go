@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"usdt/internal/models"
+)
+
+// QuotaService ведёт суточные квоты запросов клиентов. Счётчики хранятся в
+// базе, поэтому квота общая для всех экземпляров сервиса и переживает
+// перезапуск. Сутки считаются по UTC.
+type QuotaService struct {
+	storage QuotaServicer
+	limit   int64
+	now     func() time.Time
+}
+
+func NewQuotaService(storage QuotaServicer, limit int64) *QuotaService {
+	return &QuotaService{
+		storage: storage,
+		limit:   limit,
+		now:     time.Now,
+	}
+}
+
+// Consume учитывает запрос клиента. Сверх квоты запрос не учитывается, а
+// Consume возвращает models.ErrQuotaExceeded и время до начала следующих
+// суток.
+func (q *QuotaService) Consume(ctx context.Context, client string) (time.Duration, error) {
+	now := q.now().UTC()
+	admitted, err := q.storage.Increment(ctx, client, now, q.limit)
+	if err != nil {
+		return 0, fmt.Errorf("Service.ConsumeQuota: %w", err)
+	}
+	if admitted {
+		return 0, nil
+	}
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return tomorrow.Sub(now), models.ErrQuotaExceeded
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"usdt/internal/models"
+)
+
+// fakeQuotaStorage считает запросы клиентов по суткам.
+type fakeQuotaStorage struct {
+	used map[string]int64
+}
+
+func (f *fakeQuotaStorage) Increment(_ context.Context, client string, day time.Time, limit int64) (bool, error) {
+	key := client + " " + day.Format(time.DateOnly)
+	if f.used[key] >= limit {
+		return false, nil
+	}
+	f.used[key]++
+	return true, nil
+}
+
+func TestQuotaService_Consume(t *testing.T) {
+	now := time.Date(2024, 10, 27, 22, 30, 0, 0, time.UTC)
+	storage := &fakeQuotaStorage{used: map[string]int64{}}
+	quota := NewQuotaService(storage, 2)
+	quota.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := quota.Consume(ctx, "key:1")
+		require.NoError(t, err)
+	}
+	wait, err := quota.Consume(ctx, "key:1")
+	assert.ErrorIs(t, err, models.ErrQuotaExceeded)
+	assert.Equal(t, 90*time.Minute, wait, "квота сбрасывается в полночь UTC")
+	assert.Equal(t, int64(2), storage.used["key:1 2024-10-27"], "отклонённый запрос не учитывается")
+
+	_, err = quota.Consume(ctx, "key:2")
+	assert.NoError(t, err, "у каждого клиента своя квота")
+
+	now = now.Add(2 * time.Hour)
+	_, err = quota.Consume(ctx, "key:1")
+	assert.NoError(t, err, "в новые сутки счётчик начинается заново")
+}
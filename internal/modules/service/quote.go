@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"usdt/internal/models"
+)
+
+type QuoteService struct {
+	storage       QuoteServicer
+	rates         RateSource
+	ttl           time.Duration
+	markupPercent float64
+	now           func() time.Time
+}
+
+func NewQuoteService(storage QuoteServicer, rates RateSource, ttl time.Duration, markupPercent float64) *QuoteService {
+	return &QuoteService{
+		storage:       storage,
+		rates:         rates,
+		ttl:           ttl,
+		markupPercent: markupPercent,
+		now:           time.Now,
+	}
+}
+
+// CreateQuote фиксирует текущий курс с наценкой: ask увеличивается, bid
+// уменьшается на markupPercent процентов.
+func (q *QuoteService) CreateQuote(ctx context.Context, pair string) (models.Quote, error) {
+	if pair == "" {
+		return models.Quote{}, fmt.Errorf("Service.CreateQuote: %w: не указана валюта", models.ErrInvalidQuote)
+	}
+	rate, err := q.rates.GetRates(ctx, pair)
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("Service.CreateQuote: %w", err)
+	}
+	id, err := newQuoteID()
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("Service.CreateQuote: %w", err)
+	}
+
+	now := q.now()
+	markup := q.markupPercent / 100
+	quote := models.Quote{
+		ID:            id,
+		Pair:          rate.Pair,
+		AskPrice:      rate.AskPrice * (1 + markup),
+		BidPrice:      rate.BidPrice * (1 - markup),
+		MarkupPercent: q.markupPercent,
+		RateTimestamp: rate.Timestamp,
+		ExpiresAt:     now.Add(q.ttl),
+		CreatedAt:     now,
+	}
+	err = q.storage.Create(ctx, quote)
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("Service.CreateQuote: %w", err)
+	}
+	return quote, nil
+}
+
+// RedeemQuote погашает котировку. Клиенту, ограниченному парами, доступны
+// только котировки этих пар.
+func (q *QuoteService) RedeemQuote(ctx context.Context, id string) (models.Quote, error) {
+	if pairRestricted(ctx) {
+		quote, err := q.storage.GetById(ctx, id)
+		if err != nil {
+			return models.Quote{}, fmt.Errorf("Service.RedeemQuote: %w", err)
+		}
+		if err := checkPairAccess(ctx, quote.Pair); err != nil {
+			return models.Quote{}, fmt.Errorf("Service.RedeemQuote: %w", err)
+		}
+	}
+	quote, err := q.storage.Redeem(ctx, id, q.now())
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("Service.RedeemQuote: %w", err)
+	}
+	return quote, nil
+}
+
+func newQuoteID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать ID котировки: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"usdt/internal/models"
+)
+
+// MockQuoteStorage - mock для интерфейса QuoteServicer
+type MockQuoteStorage struct {
+	mock.Mock
+}
+
+func (m *MockQuoteStorage) Create(ctx context.Context, quote models.Quote) error {
+	args := m.Called(ctx, quote)
+	return args.Error(0)
+}
+
+func (m *MockQuoteStorage) GetById(ctx context.Context, id string) (models.Quote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+func (m *MockQuoteStorage) Redeem(ctx context.Context, id string, now time.Time) (models.Quote, error) {
+	args := m.Called(ctx, id, now)
+	return args.Get(0).(models.Quote), args.Error(1)
+}
+
+// MockRateSource - mock для интерфейса RateSource
+type MockRateSource struct {
+	mock.Mock
+}
+
+func (m *MockRateSource) GetRates(ctx context.Context, pair string) (models.CurrencyRate, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).(models.CurrencyRate), args.Error(1)
+}
+
+func TestQuoteService_CreateQuote(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		rate := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: now.Add(-time.Second)}
+		mockRates := new(MockRateSource)
+		mockStorage := new(MockQuoteStorage)
+		mockRates.On("GetRates", mock.Anything, "RUB").Return(rate, nil)
+		mockStorage.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewQuoteService(mockStorage, mockRates, 30*time.Second, 1)
+		service.now = func() time.Time { return now }
+		quote, err := service.CreateQuote(context.Background(), "RUB")
+		assert.NoError(t, err)
+		assert.Len(t, quote.ID, 32)
+		assert.Equal(t, "USDT/RUB", quote.Pair)
+		assert.InDelta(t, 101, quote.AskPrice, 1e-9)
+		assert.InDelta(t, 98.01, quote.BidPrice, 1e-9)
+		assert.Equal(t, rate.Timestamp, quote.RateTimestamp)
+		assert.Equal(t, now.Add(30*time.Second), quote.ExpiresAt)
+		mockStorage.AssertCalled(t, "Create", mock.Anything, quote)
+	})
+
+	t.Run("RateError", func(t *testing.T) {
+		expectedError := errors.New("API error")
+		mockRates := new(MockRateSource)
+		mockStorage := new(MockQuoteStorage)
+		mockRates.On("GetRates", mock.Anything, "EUR").Return(models.CurrencyRate{}, expectedError)
+
+		service := NewQuoteService(mockStorage, mockRates, 30*time.Second, 1)
+		_, err := service.CreateQuote(context.Background(), "EUR")
+		assert.ErrorIs(t, err, expectedError)
+		mockStorage.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("EmptyCurrency", func(t *testing.T) {
+		mockRates := new(MockRateSource)
+		service := NewQuoteService(new(MockQuoteStorage), mockRates, 30*time.Second, 1)
+		_, err := service.CreateQuote(context.Background(), "")
+		assert.ErrorIs(t, err, models.ErrInvalidQuote)
+		mockRates.AssertNotCalled(t, "GetRates", mock.Anything, mock.Anything)
+	})
+}
+
+func TestQuoteService_RedeemQuote(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		redeemed := models.Quote{ID: "q1", RedeemedAt: &now}
+		mockStorage := new(MockQuoteStorage)
+		mockStorage.On("Redeem", mock.Anything, "q1", now).Return(redeemed, nil)
+
+		service := NewQuoteService(mockStorage, new(MockRateSource), time.Minute, 0)
+		service.now = func() time.Time { return now }
+		quote, err := service.RedeemQuote(context.Background(), "q1")
+		assert.NoError(t, err)
+		assert.Equal(t, redeemed, quote)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		mockStorage := new(MockQuoteStorage)
+		mockStorage.On("Redeem", mock.Anything, "q1", mock.Anything).Return(models.Quote{}, models.ErrQuoteExpired)
+
+		service := NewQuoteService(mockStorage, new(MockRateSource), time.Minute, 0)
+		_, err := service.RedeemQuote(context.Background(), "q1")
+		assert.ErrorIs(t, err, models.ErrQuoteExpired)
+	})
+
+	t.Run("AlreadyRedeemed", func(t *testing.T) {
+		mockStorage := new(MockQuoteStorage)
+		mockStorage.On("Redeem", mock.Anything, "q1", mock.Anything).Return(models.Quote{}, models.ErrQuoteRedeemed)
+
+		service := NewQuoteService(mockStorage, new(MockRateSource), time.Minute, 0)
+		_, err := service.RedeemQuote(context.Background(), "q1")
+		assert.ErrorIs(t, err, models.ErrQuoteRedeemed)
+	})
+
+	t.Run("ForeignPair", func(t *testing.T) {
+		mockStorage := new(MockQuoteStorage)
+		mockStorage.On("GetById", mock.Anything, "q1").Return(models.Quote{ID: "q1", Pair: "USDT/USD"}, nil)
+
+		service := NewQuoteService(mockStorage, new(MockRateSource), time.Minute, 0)
+		ctx := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "partner", KeyID: 1, Pairs: []string{"USDT/RUB"}})
+		_, err := service.RedeemQuote(ctx, "q1")
+		assert.ErrorIs(t, err, models.ErrPermissionDenied)
+		mockStorage.AssertNotCalled(t, "Redeem", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"usdt/internal/models"
+)
+
+// RateAdminService - ручное исправление сохранённых курсов. Изменения
+// принимаются только с текущей версией записи, поэтому два администратора
+// не перезапишут правки друг друга незаметно. Клиенту, ограниченному
+// парами, доступны только курсы этих пар.
+type RateAdminService struct {
+	storage UsdtServicer
+	history HistorySource
+}
+
+func NewRateAdminService(storage UsdtServicer, history HistorySource) *RateAdminService {
+	return &RateAdminService{
+		storage: storage,
+		history: history,
+	}
+}
+
+func (r *RateAdminService) GetRate(ctx context.Context, id int64) (models.CurrencyRate, error) {
+	rate, err := r.storage.GetById(ctx, id)
+	if err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Service.GetRate: %w", err)
+	}
+	if rate.ID == 0 {
+		return models.CurrencyRate{}, fmt.Errorf("Service.GetRate: %w", models.ErrRateNotFound)
+	}
+	if err := checkPairAccess(ctx, rate.Pair); err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Service.GetRate: %w", err)
+	}
+	return rate, nil
+}
+
+func (r *RateAdminService) ListRates(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	rates, err := r.history.GetHistory(ctx, pair, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Service.ListRates: %w", err)
+	}
+	return rates, nil
+}
+
+// UpdateRate меняет цены и время биржи курса; пара записи не меняется.
+func (r *RateAdminService) UpdateRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, error) {
+	if err := validateRate(rate); err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Service.UpdateRate: %w", err)
+	}
+	if err := r.checkPair(ctx, rate.ID); err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Service.UpdateRate: %w", err)
+	}
+	updated, err := r.storage.Update(ctx, rate)
+	if err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Service.UpdateRate: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *RateAdminService) DeleteRate(ctx context.Context, id, version int64) error {
+	if version <= 0 {
+		return fmt.Errorf("Service.DeleteRate: %w: не указана версия", models.ErrInvalidRate)
+	}
+	if err := r.checkPair(ctx, id); err != nil {
+		return fmt.Errorf("Service.DeleteRate: %w", err)
+	}
+	if err := r.storage.Delete(ctx, id, version); err != nil {
+		return fmt.Errorf("Service.DeleteRate: %w", err)
+	}
+	return nil
+}
+
+// checkPair проверяет, что клиенту запроса доступна пара курса id.
+func (r *RateAdminService) checkPair(ctx context.Context, id int64) error {
+	if !pairRestricted(ctx) {
+		return nil
+	}
+	rate, err := r.storage.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rate.ID == 0 {
+		return models.ErrRateNotFound
+	}
+	return checkPairAccess(ctx, rate.Pair)
+}
+
+func validateRate(rate models.CurrencyRate) error {
+	switch {
+	case rate.Version <= 0:
+		return fmt.Errorf("%w: не указана версия", models.ErrInvalidRate)
+	case rate.AskPrice <= 0 || rate.BidPrice <= 0:
+		return fmt.Errorf("%w: цены должны быть положительными", models.ErrInvalidRate)
+	case rate.AskPrice < rate.BidPrice:
+		return fmt.Errorf("%w: ask меньше bid", models.ErrInvalidRate)
+	case rate.Timestamp.IsZero():
+		return fmt.Errorf("%w: не указано время биржи", models.ErrInvalidRate)
+	}
+	return nil
+}
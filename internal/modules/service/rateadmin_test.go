@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"usdt/internal/models"
+)
+
+func TestRateAdminService_GetRate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rate := models.CurrencyRate{ID: 7, Pair: "USDT/RUB", AskPrice: 91, BidPrice: 90, Version: 2}
+		mockStorage := new(MockUsdtStorage)
+		mockStorage.On("GetById", mock.Anything, int64(7)).Return(rate, nil)
+		service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+		got, err := service.GetRate(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.Equal(t, rate, got)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		mockStorage := new(MockUsdtStorage)
+		mockStorage.On("GetById", mock.Anything, int64(7)).Return(models.CurrencyRate{}, nil)
+		service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+		_, err := service.GetRate(context.Background(), 7)
+		assert.ErrorIs(t, err, models.ErrRateNotFound)
+	})
+}
+
+func TestRateAdminService_ListRates(t *testing.T) {
+	now := time.Now()
+	rates := []models.CurrencyRate{{ID: 1, Pair: "USDT/RUB", Timestamp: now}}
+	mockHistory := new(MockHistorySource)
+	mockHistory.On("GetHistory", mock.Anything, "USDT/RUB", now.Add(-time.Hour), now).Return(rates, nil)
+	service := NewRateAdminService(new(MockUsdtStorage), mockHistory)
+
+	got, err := service.ListRates(context.Background(), "USDT/RUB", now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.Equal(t, rates, got)
+}
+
+func TestRateAdminService_UpdateRate(t *testing.T) {
+	valid := models.CurrencyRate{ID: 7, AskPrice: 91, BidPrice: 90, Timestamp: time.Now(), Version: 1}
+
+	t.Run("Success", func(t *testing.T) {
+		updated := valid
+		updated.Version = 2
+		mockStorage := new(MockUsdtStorage)
+		mockStorage.On("Update", mock.Anything, valid).Return(updated, nil)
+		service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+		got, err := service.UpdateRate(context.Background(), valid)
+		assert.NoError(t, err)
+		assert.Equal(t, updated, got)
+	})
+	t.Run("Conflict", func(t *testing.T) {
+		mockStorage := new(MockUsdtStorage)
+		mockStorage.On("Update", mock.Anything, valid).Return(models.CurrencyRate{}, models.ErrRateVersionConflict)
+		service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+		_, err := service.UpdateRate(context.Background(), valid)
+		assert.ErrorIs(t, err, models.ErrRateVersionConflict)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		cases := map[string]func(r *models.CurrencyRate){
+			"NoVersion":   func(r *models.CurrencyRate) { r.Version = 0 },
+			"ZeroPrice":   func(r *models.CurrencyRate) { r.BidPrice = 0 },
+			"Crossed":     func(r *models.CurrencyRate) { r.AskPrice = 89 },
+			"NoTimestamp": func(r *models.CurrencyRate) { r.Timestamp = time.Time{} },
+		}
+		for name, mutate := range cases {
+			t.Run(name, func(t *testing.T) {
+				rate := valid
+				mutate(&rate)
+				mockStorage := new(MockUsdtStorage)
+				service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+				_, err := service.UpdateRate(context.Background(), rate)
+				assert.ErrorIs(t, err, models.ErrInvalidRate)
+				mockStorage.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestRateAdminService_DeleteRate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStorage := new(MockUsdtStorage)
+		mockStorage.On("Delete", mock.Anything, int64(7), int64(3)).Return(nil)
+		service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+		assert.NoError(t, service.DeleteRate(context.Background(), 7, 3))
+	})
+	t.Run("Error", func(t *testing.T) {
+		mockStorage := new(MockUsdtStorage)
+		mockStorage.On("Delete", mock.Anything, int64(7), int64(3)).Return(errors.New("db error"))
+		service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+		assert.Error(t, service.DeleteRate(context.Background(), 7, 3))
+	})
+	t.Run("NoVersion", func(t *testing.T) {
+		service := NewRateAdminService(new(MockUsdtStorage), new(MockHistorySource))
+		assert.ErrorIs(t, service.DeleteRate(context.Background(), 7, 0), models.ErrInvalidRate)
+	})
+}
+
+func TestRateAdminService_PairRestriction(t *testing.T) {
+	rub := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "partner", KeyID: 1, Pairs: []string{"USDT/RUB"}})
+	foreign := models.CurrencyRate{ID: 8, Pair: "USDT/USD", AskPrice: 1.01, BidPrice: 0.99, Timestamp: time.Now(), Version: 1}
+	mockStorage := new(MockUsdtStorage)
+	mockStorage.On("GetById", mock.Anything, int64(8)).Return(foreign, nil)
+	service := NewRateAdminService(mockStorage, new(MockHistorySource))
+
+	_, err := service.GetRate(rub, 8)
+	assert.ErrorIs(t, err, models.ErrPermissionDenied)
+	_, err = service.UpdateRate(rub, foreign)
+	assert.ErrorIs(t, err, models.ErrPermissionDenied)
+	assert.ErrorIs(t, service.DeleteRate(rub, 8, 1), models.ErrPermissionDenied)
+	mockStorage.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+
+	// Клиент без ограничения по парам работает с любым курсом.
+	mockStorage.On("Delete", mock.Anything, int64(8), int64(1)).Return(nil)
+	all := models.ContextWithPrincipal(context.Background(), models.Principal{Name: "admin", KeyID: 2})
+	assert.NoError(t, service.DeleteRate(all, 8, 1))
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/infrastructure/metrics"
+	"usdt/internal/models"
+)
+
+// RetentionReport - итог одного запуска очистки.
+type RetentionReport struct {
+	RawDeleted       int64
+	MinuteAggregates int64
+	HourAggregates   int64
+	MinuteDeleted    int64
+	HourDeleted      int64
+	Batches          int
+}
+
+// RetentionService переносит устаревшие сырые курсы в агрегаты и удаляет
+// устаревшие агрегаты. Все удаления идут пакетами не больше BatchSize строк,
+// чтобы не держать длинные блокировки на таблицах.
+type RetentionService struct {
+	storage RetentionServicer
+	policy  config.Retention
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewRetentionService(storage RetentionServicer, policy config.Retention, logger *zap.Logger) *RetentionService {
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = 1000
+	}
+	return &RetentionService{
+		storage: storage,
+		policy:  policy,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Run запускает очистку каждые Interval до отмены ctx. Нулевой интервал или
+// нулевой срок хранения сырых курсов отключают очистку.
+func (r *RetentionService) Run(ctx context.Context) {
+	if r.policy.Interval <= 0 || r.policy.Raw <= 0 {
+		r.logger.Info("Очистка истории курсов отключена")
+		return
+	}
+	ticker := time.NewTicker(r.policy.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RunOnce(ctx); err != nil {
+			r.logger.Error("RetentionService.RunOnce error:", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *RetentionService) RunOnce(ctx context.Context) (RetentionReport, error) {
+	started := r.now()
+	report, err := r.run(ctx, started)
+	metrics.RetentionDuration.Observe(r.now().Sub(started).Seconds())
+	if err != nil {
+		metrics.RetentionRuns.WithLabelValues("error").Inc()
+		return report, fmt.Errorf("Service.Retention: %w", err)
+	}
+	metrics.RetentionRuns.WithLabelValues("success").Inc()
+	metrics.RetentionLastSuccess.Set(float64(r.now().Unix()))
+	r.logger.Info("Очистка истории курсов завершена",
+		zap.Int64("raw_deleted", report.RawDeleted),
+		zap.Int64("minute_aggregates", report.MinuteAggregates),
+		zap.Int64("hour_aggregates", report.HourAggregates),
+		zap.Int64("minute_deleted", report.MinuteDeleted),
+		zap.Int64("hour_deleted", report.HourDeleted),
+		zap.Int("batches", report.Batches),
+		zap.Duration("duration", r.now().Sub(started)))
+	return report, nil
+}
+
+func (r *RetentionService) run(ctx context.Context, now time.Time) (RetentionReport, error) {
+	var report RetentionReport
+	before := now.Add(-r.policy.Raw)
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		result, err := r.storage.Downsample(ctx, before, r.policy.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		report.Batches++
+		report.RawDeleted += result.RawDeleted
+		report.MinuteAggregates += result.MinuteAggregates
+		report.HourAggregates += result.HourAggregates
+		metrics.RetentionRawDeleted.Add(float64(result.RawDeleted))
+		metrics.RetentionAggregatesUpserted.WithLabelValues(models.ResolutionMinute).Add(float64(result.MinuteAggregates))
+		metrics.RetentionAggregatesUpserted.WithLabelValues(models.ResolutionHour).Add(float64(result.HourAggregates))
+		if result.RawDeleted < int64(r.policy.BatchSize) {
+			break
+		}
+	}
+
+	var err error
+	if report.MinuteDeleted, err = r.deleteAggregates(ctx, models.ResolutionMinute, r.policy.Minute, now, &report); err != nil {
+		return report, err
+	}
+	if report.HourDeleted, err = r.deleteAggregates(ctx, models.ResolutionHour, r.policy.Hour, now, &report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func (r *RetentionService) deleteAggregates(ctx context.Context, resolution string, keep time.Duration, now time.Time, report *RetentionReport) (int64, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		deleted, err := r.storage.DeleteAggregates(ctx, resolution, now.Add(-keep), r.policy.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		report.Batches++
+		total += deleted
+		metrics.RetentionAggregatesDeleted.WithLabelValues(resolution).Add(float64(deleted))
+		if deleted < int64(r.policy.BatchSize) {
+			return total, nil
+		}
+	}
+}
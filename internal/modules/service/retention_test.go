@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"usdt/config"
+	"usdt/internal/infrastructure/metrics"
+	"usdt/internal/models"
+)
+
+// MockRetentionStorage - mock для интерфейса RetentionServicer
+type MockRetentionStorage struct {
+	mock.Mock
+}
+
+func (m *MockRetentionStorage) Downsample(ctx context.Context, before time.Time, limit int) (models.DownsampleResult, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(models.DownsampleResult), args.Error(1)
+}
+
+func (m *MockRetentionStorage) DeleteAggregates(ctx context.Context, resolution string, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, resolution, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestRetentionService_RunOnce(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	policy := config.Retention{Interval: time.Hour, Raw: 24 * time.Hour, Minute: 7 * 24 * time.Hour, BatchSize: 100}
+
+	t.Run("Batches", func(t *testing.T) {
+		mockStorage := new(MockRetentionStorage)
+		mockStorage.On("Downsample", mock.Anything, now.Add(-24*time.Hour), 100).
+			Return(models.DownsampleResult{RawDeleted: 100, MinuteAggregates: 10, HourAggregates: 1}, nil).Twice()
+		mockStorage.On("Downsample", mock.Anything, now.Add(-24*time.Hour), 100).
+			Return(models.DownsampleResult{RawDeleted: 30, MinuteAggregates: 3, HourAggregates: 1}, nil).Once()
+		mockStorage.On("DeleteAggregates", mock.Anything, models.ResolutionMinute, now.Add(-7*24*time.Hour), 100).
+			Return(int64(100), nil).Once()
+		mockStorage.On("DeleteAggregates", mock.Anything, models.ResolutionMinute, now.Add(-7*24*time.Hour), 100).
+			Return(int64(5), nil).Once()
+		rawBefore := testutil.ToFloat64(metrics.RetentionRawDeleted)
+		runsBefore := testutil.ToFloat64(metrics.RetentionRuns.WithLabelValues("success"))
+
+		service := NewRetentionService(mockStorage, policy, zap.NewNop())
+		service.now = func() time.Time { return now }
+		report, err := service.RunOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, RetentionReport{
+			RawDeleted:       230,
+			MinuteAggregates: 23,
+			HourAggregates:   3,
+			MinuteDeleted:    105,
+			Batches:          5,
+		}, report)
+		mockStorage.AssertNotCalled(t, "DeleteAggregates", mock.Anything, models.ResolutionHour, mock.Anything, mock.Anything)
+		assert.Equal(t, 230.0, testutil.ToFloat64(metrics.RetentionRawDeleted)-rawBefore)
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.RetentionRuns.WithLabelValues("success"))-runsBefore)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockStorage := new(MockRetentionStorage)
+		mockStorage.On("Downsample", mock.Anything, mock.Anything, 100).
+			Return(models.DownsampleResult{}, errors.New("db error"))
+		errorsBefore := testutil.ToFloat64(metrics.RetentionRuns.WithLabelValues("error"))
+
+		service := NewRetentionService(mockStorage, policy, zap.NewNop())
+		_, err := service.RunOnce(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.RetentionRuns.WithLabelValues("error"))-errorsBefore)
+		mockStorage.AssertNotCalled(t, "DeleteAggregates", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		service := NewRetentionService(new(MockRetentionStorage), policy, zap.NewNop())
+		_, err := service.RunOnce(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -4,36 +4,62 @@ import (
 	"context"
 	"fmt"
 
+	"go.uber.org/zap"
 	"usdt/internal/models"
 )
 
+// UsdtService получает курс у поставщика source и сохраняет снимок. Один и
+// тот же снимок, запрошенный несколько раз, сохраняется один раз. Статистика
+// стакана вторична: ошибка её записи пишется в лог, а курс всё равно
+// возвращается.
 type UsdtService struct {
-	storage UsdtServicer
-	api     RequestAPI
+	storage   UsdtServicer
+	api       RequestAPI
+	source    string
+	validator SnapshotValidator
+	stats     MarketStatsServicer
+	logger    *zap.Logger
 }
 
-func NewUsdtService(storage UsdtServicer, api RequestAPI) *UsdtService {
+func NewUsdtService(storage UsdtServicer, api RequestAPI, source string, validator SnapshotValidator, stats MarketStatsServicer, logger *zap.Logger) *UsdtService {
 	return &UsdtService{
-		storage: storage,
-		api:     api,
+		storage:   storage,
+		api:       api,
+		source:    source,
+		validator: validator,
+		stats:     stats,
+		logger:    logger,
 	}
 }
 
 func (u *UsdtService) GetRates(ctx context.Context, pair string) (models.CurrencyRate, error) {
 
-	asc, bid, time, err := u.api.GetRates(pair)
+	book, err := u.api.GetOrderBook(ctx, pair)
 	if err != nil {
 		return models.CurrencyRate{}, fmt.Errorf("Service.GetRates: %w", err)
 	}
+	stats := ComputeMarketStats("USDT/"+pair, book)
 	rates := models.CurrencyRate{
-		"USDT/" + pair,
-		asc,
-		bid,
-		time,
+		Pair:      stats.Pair,
+		AskPrice:  stats.AskPrice,
+		BidPrice:  stats.BidPrice,
+		Timestamp: stats.Timestamp,
+		Source:    u.source,
 	}
-	err = u.storage.Create(ctx, rates)
+	err = u.validator.Validate(ctx, rates)
 	if err != nil {
 		return models.CurrencyRate{}, fmt.Errorf("Service.GetRates: %w", err)
 	}
-	return rates, nil
+	stored, created, err := u.storage.Create(ctx, rates)
+	if err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Service.GetRates: %w", err)
+	}
+	if !created {
+		return stored, nil
+	}
+	err = u.stats.Create(ctx, stats)
+	if err != nil {
+		u.logger.Error("Не удалось сохранить статистику стакана:", zap.String("pair", stats.Pair), zap.Error(err))
+	}
+	return stored, nil
 }
@@ -7,13 +7,135 @@ import (
 )
 
 type UsdtServicer interface {
-	Create(ctx context.Context, rate models.CurrencyRate) error
-	Update(ctx context.Context, rate models.CurrencyRate) error
-	Delete(ctx context.Context, id int64) error
+	// Create сохраняет курс и возвращает сохранённую запись и признак того,
+	// был ли снимок новым.
+	Create(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error)
+	Update(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, error)
+	Delete(ctx context.Context, id, version int64) error
 	GetById(ctx context.Context, id int64) (models.CurrencyRate, error)
 	GetByPair(ctx context.Context, pair string) (models.CurrencyRate, error)
 	GetAll(ctx context.Context) ([]models.CurrencyRate, error)
 }
+
+// RateBatchServicer - хранилище курсов с пакетной записью.
+type RateBatchServicer interface {
+	UsdtServicer
+	CreateBatch(ctx context.Context, rates []models.CurrencyRate) error
+	GetByKey(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error)
+}
+
 type RequestAPI interface {
-	GetRates(market string) (askPrice, bidPrice float64, timestamp time.Time, err error)
+	GetOrderBook(ctx context.Context, market string) (models.OrderBook, error)
+}
+
+type QuoteServicer interface {
+	Create(ctx context.Context, quote models.Quote) error
+	GetById(ctx context.Context, id string) (models.Quote, error)
+	Redeem(ctx context.Context, id string, now time.Time) (models.Quote, error)
+}
+
+// RateSource - источник актуального курса для котировок.
+type RateSource interface {
+	GetRates(ctx context.Context, pair string) (models.CurrencyRate, error)
+}
+
+type AlertServicer interface {
+	CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error)
+	GetRule(ctx context.Context, id int64) (models.AlertRule, error)
+	ListRules(ctx context.Context, pair string) ([]models.AlertRule, error)
+	UpdateRule(ctx context.Context, rule models.AlertRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, delivery models.AlertDelivery) error
+	ListDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error)
+}
+
+// HistorySource - источник сохранённых курсов за период.
+type HistorySource interface {
+	GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error)
+}
+
+type WebhookSender interface {
+	Send(ctx context.Context, url, secret string, payload []byte) (int, error)
+}
+
+// SnapshotValidator проверяет снимок курса перед сохранением.
+type SnapshotValidator interface {
+	Validate(ctx context.Context, rate models.CurrencyRate) error
+}
+
+type LatestRateSource interface {
+	GetLatest(ctx context.Context, pair string) (models.CurrencyRate, bool, error)
+}
+
+type QuarantineServicer interface {
+	Create(ctx context.Context, rate models.QuarantinedRate) error
+}
+
+type MarketStatsServicer interface {
+	Create(ctx context.Context, stats models.MarketStats) error
+	GetLatest(ctx context.Context, pair string) (models.MarketStats, error)
+	GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error)
+}
+
+type ArbitrageServicer interface {
+	Create(ctx context.Context, opportunity models.ArbitrageOpportunity) error
+}
+
+type RetentionServicer interface {
+	Downsample(ctx context.Context, before time.Time, limit int) (models.DownsampleResult, error)
+	DeleteAggregates(ctx context.Context, resolution string, before time.Time, limit int) (int64, error)
+}
+
+type PartitionServicer interface {
+	ListPartitions(ctx context.Context) ([]models.Partition, error)
+	CreatePartition(ctx context.Context, month time.Time) (models.Partition, error)
+	DropPartition(ctx context.Context, name string) error
+}
+
+type OutboxServicer interface {
+	ListPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OutboxSink - приёмник событий outbox. Publish может получить одно событие
+// несколько раз; получатель отбрасывает дубли по IdempotencyKey.
+type OutboxSink interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+// RatePageSource - постраничное чтение истории курсов.
+type RatePageSource interface {
+	GetPage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error)
+}
+
+// RateImportServicer - хранилище, в которое импортируется история курсов.
+type RateImportServicer interface {
+	CreateBatch(ctx context.Context, rates []models.CurrencyRate) error
+	GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error)
+}
+
+// RateReader - источник строк файла импорта. Read возвращает io.EOF после
+// последней строки и ошибку с models.ErrInvalidRecord для неразобранной строки.
+type RateReader interface {
+	Read() (models.CurrencyRate, error)
+}
+
+// ImportProgressStore хранит ход импорта между запусками. Load без
+// сохранённого хода возвращает нулевой ImportProgress.
+type ImportProgressStore interface {
+	Load() (models.ImportProgress, error)
+	Save(progress models.ImportProgress) error
+}
+
+type APIKeyServicer interface {
+	Create(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int64, at time.Time) error
+}
+
+type QuotaServicer interface {
+	Increment(ctx context.Context, client string, day time.Time, limit int64) (bool, error)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 	"usdt/internal/models"
 )
 
@@ -17,18 +18,21 @@ type MockUsdtStorage struct {
 	mock.Mock
 }
 
-func (m *MockUsdtStorage) Create(ctx context.Context, rate models.CurrencyRate) error {
+func (m *MockUsdtStorage) Create(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
 	args := m.Called(ctx, rate)
-	return args.Error(0)
+	return args.Get(0).(models.CurrencyRate), args.Bool(1), args.Error(2)
 }
 
-func (m *MockUsdtStorage) Update(ctx context.Context, rate models.CurrencyRate) error {
+func (m *MockUsdtStorage) Update(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, error) {
 	args := m.Called(ctx, rate)
-	return args.Error(0)
+	if args.Error(1) != nil {
+		return models.CurrencyRate{}, args.Error(1)
+	}
+	return args.Get(0).(models.CurrencyRate), args.Error(1)
 }
 
-func (m *MockUsdtStorage) Delete(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
+func (m *MockUsdtStorage) Delete(ctx context.Context, id, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
@@ -60,9 +64,59 @@ type MockRequestAPI struct {
 	mock.Mock
 }
 
-func (m *MockRequestAPI) GetRates(market string) (askPrice, bidPrice float64, timestamp time.Time, err error) {
+func (m *MockRequestAPI) GetOrderBook(_ context.Context, market string) (models.OrderBook, error) {
 	args := m.Called(market)
-	return args.Get(0).(float64), args.Get(1).(float64), args.Get(2).(time.Time), args.Error(3)
+	return args.Get(0).(models.OrderBook), args.Error(1)
+}
+
+func newBook(ask, bid float64, timestamp time.Time) models.OrderBook {
+	return models.OrderBook{
+		Timestamp: timestamp,
+		Asks:      []models.BookLevel{{Price: ask, Volume: 10}},
+		Bids:      []models.BookLevel{{Price: bid, Volume: 10}},
+	}
+}
+
+// MockMarketStatsStorage - mock для интерфейса MarketStatsServicer
+type MockMarketStatsStorage struct {
+	mock.Mock
+}
+
+func (m *MockMarketStatsStorage) Create(ctx context.Context, stats models.MarketStats) error {
+	args := m.Called(ctx, stats)
+	return args.Error(0)
+}
+
+func (m *MockMarketStatsStorage) GetLatest(ctx context.Context, pair string) (models.MarketStats, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).(models.MarketStats), args.Error(1)
+}
+
+func (m *MockMarketStatsStorage) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error) {
+	args := m.Called(ctx, pair, from, to)
+	return args.Get(0).([]models.MarketStats), args.Error(1)
+}
+
+func newStatsStorage() *MockMarketStatsStorage {
+	stats := new(MockMarketStatsStorage)
+	stats.On("Create", mock.Anything, mock.Anything).Return(nil)
+	return stats
+}
+
+// MockSnapshotValidator - mock для интерфейса SnapshotValidator
+type MockSnapshotValidator struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotValidator) Validate(ctx context.Context, rate models.CurrencyRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func newPassingValidator() *MockSnapshotValidator {
+	validator := new(MockSnapshotValidator)
+	validator.On("Validate", mock.Anything, mock.Anything).Return(nil)
+	return validator
 }
 
 func TestUsdtService_GetRates(t *testing.T) {
@@ -75,18 +129,22 @@ func TestUsdtService_GetRates(t *testing.T) {
 		mockStorage := new(MockUsdtStorage)
 		mockAPI := new(MockRequestAPI)
 
-		mockAPI.On("GetRates", testMarket).Return(expectedAsk, expectedBid, timeNow, nil)
+		mockAPI.On("GetOrderBook", testMarket).Return(newBook(expectedAsk, expectedBid, timeNow), nil)
 		mockStorage.On("Create", mock.Anything, mock.MatchedBy(func(rate models.CurrencyRate) bool {
-			return rate.Pair == "USDT/"+testMarket && rate.AskPrice == expectedAsk && rate.BidPrice == expectedBid
-		})).Return(nil)
+			return rate.Pair == "USDT/"+testMarket && rate.AskPrice == expectedAsk && rate.BidPrice == expectedBid &&
+				rate.Source == "garantex"
+		})).Return(models.CurrencyRate{ID: 5, Pair: "USDT/" + testMarket, AskPrice: expectedAsk, BidPrice: expectedBid,
+			Timestamp: timeNow, CreatedAt: timeNow, Version: 1, Source: "garantex"}, true, nil)
 
-		service := NewUsdtService(mockStorage, mockAPI)
+		service := NewUsdtService(mockStorage, mockAPI, "garantex", newPassingValidator(), newStatsStorage(), zap.NewNop())
 		rate, err := service.GetRates(context.Background(), testMarket)
 		assert.NoError(t, err)
 		assert.Equal(t, "USDT/"+testMarket, rate.Pair)
 		assert.Equal(t, expectedAsk, rate.AskPrice)
 		assert.Equal(t, expectedBid, rate.BidPrice)
 		assert.Equal(t, timeNow.Format(time.RFC3339), rate.Timestamp.Format(time.RFC3339)) // Сравнение времени с учетом форматирования
+		assert.Equal(t, int64(5), rate.ID, "возвращается сохранённая запись")
+		assert.Equal(t, int64(1), rate.Version)
 
 	})
 
@@ -97,9 +155,9 @@ func TestUsdtService_GetRates(t *testing.T) {
 		mockStorage := new(MockUsdtStorage)
 		mockAPI := new(MockRequestAPI)
 
-		mockAPI.On("GetRates", testMarket).Return(0.0, 0.0, time.Time{}, expectedError)
+		mockAPI.On("GetOrderBook", testMarket).Return(models.OrderBook{}, expectedError)
 
-		service := NewUsdtService(mockStorage, mockAPI)
+		service := NewUsdtService(mockStorage, mockAPI, "garantex", newPassingValidator(), newStatsStorage(), zap.NewNop())
 		_, err := service.GetRates(context.Background(), testMarket)
 		assert.Error(t, err)
 		assert.Equal(t, fmt.Errorf("Service.GetRates: %w", expectedError), err)
@@ -115,12 +173,60 @@ func TestUsdtService_GetRates(t *testing.T) {
 		mockStorage := new(MockUsdtStorage)
 		mockAPI := new(MockRequestAPI)
 
-		mockAPI.On("GetRates", testMarket).Return(expectedAsk, expectedBid, time.Now(), nil)
-		mockStorage.On("Create", mock.Anything, mock.Anything).Return(expectedError)
+		mockAPI.On("GetOrderBook", testMarket).Return(newBook(expectedAsk, expectedBid, time.Now()), nil)
+		mockStorage.On("Create", mock.Anything, mock.Anything).Return(models.CurrencyRate{}, false, expectedError)
 
-		service := NewUsdtService(mockStorage, mockAPI)
+		service := NewUsdtService(mockStorage, mockAPI, "garantex", newPassingValidator(), newStatsStorage(), zap.NewNop())
 		_, err := service.GetRates(context.Background(), testMarket)
 		assert.Error(t, err)
 		assert.Equal(t, fmt.Errorf("Service.GetRates: %w", expectedError), err)
 	})
+	t.Run("Rejected", func(t *testing.T) {
+		testMarket := "RUB"
+		rejectedError := fmt.Errorf("%w: crossed_book", models.ErrSnapshotRejected)
+
+		mockStorage := new(MockUsdtStorage)
+		mockAPI := new(MockRequestAPI)
+		mockValidator := new(MockSnapshotValidator)
+
+		mockAPI.On("GetOrderBook", testMarket).Return(newBook(99, 100, time.Now()), nil)
+		mockValidator.On("Validate", mock.Anything, mock.Anything).Return(rejectedError)
+
+		service := NewUsdtService(mockStorage, mockAPI, "garantex", mockValidator, newStatsStorage(), zap.NewNop())
+		_, err := service.GetRates(context.Background(), testMarket)
+		assert.ErrorIs(t, err, models.ErrSnapshotRejected)
+		mockStorage.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+	t.Run("DuplicateSnapshot", func(t *testing.T) {
+		mockStorage := new(MockUsdtStorage)
+		mockAPI := new(MockRequestAPI)
+		mockStats := newStatsStorage()
+
+		mockAPI.On("GetOrderBook", "RUB").Return(newBook(101, 99, time.Now()), nil)
+		mockStorage.On("Create", mock.Anything, mock.Anything).
+			Return(models.CurrencyRate{ID: 4, Pair: "USDT/RUB", AskPrice: 101, BidPrice: 99, Version: 2}, false, nil)
+
+		service := NewUsdtService(mockStorage, mockAPI, "garantex", newPassingValidator(), mockStats, zap.NewNop())
+		rate, err := service.GetRates(context.Background(), "RUB")
+		assert.NoError(t, err)
+		assert.Equal(t, 101.0, rate.AskPrice)
+		assert.Equal(t, int64(4), rate.ID, "возвращается ранее сохранённая запись")
+		mockStats.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+	t.Run("StatsError", func(t *testing.T) {
+		mockStorage := new(MockUsdtStorage)
+		mockAPI := new(MockRequestAPI)
+		mockStats := new(MockMarketStatsStorage)
+
+		mockAPI.On("GetOrderBook", "RUB").Return(newBook(101, 99, time.Now()), nil)
+		mockStorage.On("Create", mock.Anything, mock.Anything).
+			Return(models.CurrencyRate{ID: 6, Pair: "USDT/RUB", AskPrice: 101, BidPrice: 99, Version: 1}, true, nil)
+		mockStats.On("Create", mock.Anything, mock.Anything).Return(errors.New("stats error"))
+
+		service := NewUsdtService(mockStorage, mockAPI, "garantex", newPassingValidator(), mockStats, zap.NewNop())
+		rate, err := service.GetRates(context.Background(), "RUB")
+		assert.NoError(t, err, "ошибка статистики не скрывает сохранённый курс")
+		assert.Equal(t, int64(6), rate.ID)
+		mockStats.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// RateValidator отсеивает заведомо некорректные снимки биржи и откладывает
+// их в карантин вместе с причиной. Нулевые значения порогов отключают
+// соответствующие проверки.
+type RateValidator struct {
+	latest     LatestRateSource
+	quarantine QuarantineServicer
+	conf       config.Validation
+	now        func() time.Time
+}
+
+func NewRateValidator(latest LatestRateSource, quarantine QuarantineServicer, conf config.Validation) *RateValidator {
+	return &RateValidator{
+		latest:     latest,
+		quarantine: quarantine,
+		conf:       conf,
+		now:        time.Now,
+	}
+}
+
+func (v *RateValidator) Validate(ctx context.Context, rate models.CurrencyRate) error {
+	reason, details, err := v.check(ctx, rate)
+	if err != nil {
+		return fmt.Errorf("Validator.Validate: %w", err)
+	}
+	if reason == "" {
+		return nil
+	}
+	if err := quarantineRate(ctx, v.quarantine, rate, reason, details, v.now()); err != nil {
+		return fmt.Errorf("Validator.Validate: %w", err)
+	}
+	return fmt.Errorf("%w: %s (%s)", models.ErrSnapshotRejected, reason, details)
+}
+
+// quarantineRate откладывает отклонённый курс в карантин; так же поступает
+// импорт с отклонёнными строками файла.
+func quarantineRate(ctx context.Context, quarantine QuarantineServicer, rate models.CurrencyRate, reason, details string, now time.Time) error {
+	return quarantine.Create(ctx, models.QuarantinedRate{
+		Pair:      rate.Pair,
+		AskPrice:  rate.AskPrice,
+		BidPrice:  rate.BidPrice,
+		Timestamp: rate.Timestamp,
+		Reason:    reason,
+		Details:   details,
+		CreatedAt: now,
+	})
+}
+
+func (v *RateValidator) check(ctx context.Context, rate models.CurrencyRate) (string, string, error) {
+	if reason, details := checkPrices(rate); reason != "" {
+		return reason, details, nil
+	}
+
+	now := v.now()
+	if v.conf.MaxAge > 0 {
+		if age := now.Sub(rate.Timestamp); age > v.conf.MaxAge {
+			return models.RejectStale, fmt.Sprintf("возраст снимка %s", age.Truncate(time.Second)), nil
+		}
+	}
+	if reason, details := checkFuture(rate, now, v.conf); reason != "" {
+		return reason, details, nil
+	}
+
+	last, ok, err := v.latest.GetLatest(ctx, rate.Pair)
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		return "", "", nil
+	}
+	reason, details := checkAgainstLast(rate, last, v.conf)
+	return reason, details, nil
+}
+
+// checkPrices отсеивает нулевые цены и перевёрнутый стакан.
+func checkPrices(rate models.CurrencyRate) (string, string) {
+	if rate.AskPrice <= 0 || rate.BidPrice <= 0 {
+		return models.RejectZeroPrice, fmt.Sprintf("ask=%v bid=%v", rate.AskPrice, rate.BidPrice)
+	}
+	if rate.AskPrice < rate.BidPrice {
+		return models.RejectCrossedBook, fmt.Sprintf("ask=%v < bid=%v", rate.AskPrice, rate.BidPrice)
+	}
+	return "", ""
+}
+
+// checkFuture отсеивает снимки, опережающие часы больше чем на MaxAge.
+func checkFuture(rate models.CurrencyRate, now time.Time, conf config.Validation) (string, string) {
+	if conf.MaxAge <= 0 {
+		return "", ""
+	}
+	if ahead := rate.Timestamp.Sub(now); ahead > conf.MaxAge {
+		return models.RejectFuture, fmt.Sprintf("снимок опережает часы на %s", ahead.Truncate(time.Second))
+	}
+	return "", ""
+}
+
+// checkAgainstLast сравнивает снимок с предыдущим курсом той же пары.
+func checkAgainstLast(rate, last models.CurrencyRate, conf config.Validation) (string, string) {
+	if rate.Timestamp.Before(last.Timestamp) {
+		return models.RejectOutOfOrder, fmt.Sprintf("последний сохранённый снимок от %s", last.Timestamp.Format(time.RFC3339))
+	}
+	base := midPrice(last)
+	if conf.MaxJumpPercent > 0 && base > 0 && rate.Timestamp.Sub(last.Timestamp) <= conf.JumpWindow {
+		jump := math.Abs(midPrice(rate)-base) / base * 100
+		if jump > conf.MaxJumpPercent {
+			return models.RejectPriceJump, fmt.Sprintf("изменение %.2f%% относительно %v", jump, base)
+		}
+	}
+	return "", ""
+}
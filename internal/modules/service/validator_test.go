@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"usdt/config"
+	"usdt/internal/models"
+)
+
+// MockLatestRateSource - mock для интерфейса LatestRateSource
+type MockLatestRateSource struct {
+	mock.Mock
+}
+
+func (m *MockLatestRateSource) GetLatest(ctx context.Context, pair string) (models.CurrencyRate, bool, error) {
+	args := m.Called(ctx, pair)
+	return args.Get(0).(models.CurrencyRate), args.Bool(1), args.Error(2)
+}
+
+// MockQuarantineStorage - mock для интерфейса QuarantineServicer
+type MockQuarantineStorage struct {
+	mock.Mock
+}
+
+func (m *MockQuarantineStorage) Create(ctx context.Context, rate models.QuarantinedRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func TestRateValidator_Validate(t *testing.T) {
+	now := time.Date(2024, 10, 27, 12, 0, 0, 0, time.UTC)
+	conf := config.Validation{MaxJumpPercent: 20, JumpWindow: time.Hour, MaxAge: 5 * time.Minute}
+	last := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: now.Add(-time.Minute)}
+
+	tests := []struct {
+		name         string
+		rate         models.CurrencyRate
+		expectReason string
+	}{
+		{
+			name: "Valid",
+			rate: models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now},
+		},
+		{
+			name:         "Zero price",
+			rate:         models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 0, BidPrice: 100, Timestamp: now},
+			expectReason: models.RejectZeroPrice,
+		},
+		{
+			name:         "Crossed book",
+			rate:         models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 99, BidPrice: 100, Timestamp: now},
+			expectReason: models.RejectCrossedBook,
+		},
+		{
+			name:         "Stale timestamp",
+			rate:         models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now.Add(-time.Hour)},
+			expectReason: models.RejectStale,
+		},
+		{
+			name:         "Future timestamp",
+			rate:         models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now.Add(time.Hour)},
+			expectReason: models.RejectFuture,
+		},
+		{
+			name:         "Out of order",
+			rate:         models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now.Add(-2 * time.Minute)},
+			expectReason: models.RejectOutOfOrder,
+		},
+		{
+			name:         "Price jump",
+			rate:         models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 1001, BidPrice: 1000, Timestamp: now},
+			expectReason: models.RejectPriceJump,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLatest := new(MockLatestRateSource)
+			mockLatest.On("GetLatest", mock.Anything, "USDT/RUB").Return(last, true, nil)
+			mockQuarantine := new(MockQuarantineStorage)
+			mockQuarantine.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+			validator := NewRateValidator(mockLatest, mockQuarantine, conf)
+			validator.now = func() time.Time { return now }
+			err := validator.Validate(context.Background(), tt.rate)
+
+			if tt.expectReason == "" {
+				assert.NoError(t, err)
+				mockQuarantine.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.ErrorIs(t, err, models.ErrSnapshotRejected)
+			mockQuarantine.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(q models.QuarantinedRate) bool {
+				return q.Reason == tt.expectReason && q.Pair == tt.rate.Pair && q.AskPrice == tt.rate.AskPrice
+			}))
+		})
+	}
+
+	t.Run("FirstSnapshot", func(t *testing.T) {
+		mockLatest := new(MockLatestRateSource)
+		mockLatest.On("GetLatest", mock.Anything, "USDT/RUB").Return(models.CurrencyRate{}, false, nil)
+
+		validator := NewRateValidator(mockLatest, new(MockQuarantineStorage), conf)
+		validator.now = func() time.Time { return now }
+		err := validator.Validate(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 1001, BidPrice: 1000, Timestamp: now})
+		assert.NoError(t, err)
+	})
+
+	t.Run("JumpAfterWindow", func(t *testing.T) {
+		old := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: now.Add(-2 * time.Hour)}
+		mockLatest := new(MockLatestRateSource)
+		mockLatest.On("GetLatest", mock.Anything, "USDT/RUB").Return(old, true, nil)
+
+		validator := NewRateValidator(mockLatest, new(MockQuarantineStorage), conf)
+		validator.now = func() time.Time { return now }
+		err := validator.Validate(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 1001, BidPrice: 1000, Timestamp: now})
+		assert.NoError(t, err)
+	})
+
+	t.Run("StorageError", func(t *testing.T) {
+		mockLatest := new(MockLatestRateSource)
+		mockLatest.On("GetLatest", mock.Anything, "USDT/RUB").Return(models.CurrencyRate{}, false, errors.New("db error"))
+
+		validator := NewRateValidator(mockLatest, new(MockQuarantineStorage), conf)
+		validator.now = func() time.Time { return now }
+		err := validator.Validate(context.Background(), models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: now})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, models.ErrSnapshotRejected)
+	})
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"usdt/internal/models"
+)
+
+type AlertStorage struct {
+	adapter AlertStorager
+}
+
+func NewAlertStorage(adapter AlertStorager) *AlertStorage {
+	return &AlertStorage{adapter: adapter}
+}
+
+func (a *AlertStorage) CreateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	err := a.adapter.CreateAlertRule(ctx, &rule)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("Storage.CreateRule.не удалось создать правило оповещения: %w", err)
+	}
+	return rule, nil
+}
+
+func (a *AlertStorage) GetRule(ctx context.Context, id int64) (models.AlertRule, error) {
+	rule, err := a.adapter.GetAlertRule(ctx, id)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("Storage.GetRule.не удалось получить правило оповещения: %w", err)
+	}
+	if rule == nil {
+		return models.AlertRule{}, models.ErrAlertRuleNotFound
+	}
+	return *rule, nil
+}
+
+func (a *AlertStorage) ListRules(ctx context.Context, pair string) ([]models.AlertRule, error) {
+	rules, err := a.adapter.ListAlertRules(ctx, pair)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.ListRules.не удалось получить правила оповещения: %w", err)
+	}
+	return rules, nil
+}
+
+func (a *AlertStorage) UpdateRule(ctx context.Context, rule models.AlertRule) error {
+	err := a.adapter.UpdateAlertRule(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("Storage.UpdateRule.не удалось обновить правило оповещения: %w", err)
+	}
+	return nil
+}
+
+func (a *AlertStorage) DeleteRule(ctx context.Context, id int64) error {
+	err := a.adapter.DeleteAlertRule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("Storage.DeleteRule.не удалось удалить правило оповещения: %w", err)
+	}
+	return nil
+}
+
+func (a *AlertStorage) CreateDelivery(ctx context.Context, delivery models.AlertDelivery) error {
+	err := a.adapter.CreateAlertDelivery(ctx, delivery)
+	if err != nil {
+		return fmt.Errorf("Storage.CreateDelivery.не удалось записать доставку вебхука: %w", err)
+	}
+	return nil
+}
+
+func (a *AlertStorage) ListDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error) {
+	deliveries, err := a.adapter.ListAlertDeliveries(ctx, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.ListDeliveries.не удалось получить журнал доставки: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+type APIKeyStorage struct {
+	adapter APIKeyStorager
+}
+
+func NewAPIKeyStorage(adapter APIKeyStorager) *APIKeyStorage {
+	return &APIKeyStorage{adapter: adapter}
+}
+
+func (a *APIKeyStorage) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	err := a.adapter.CreateAPIKey(ctx, &key)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("Storage.CreateAPIKey.не удалось создать API ключ: %w", err)
+	}
+	return key, nil
+}
+
+func (a *APIKeyStorage) GetByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	key, err := a.adapter.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("Storage.GetAPIKeyByHash.не удалось получить API ключ: %w", err)
+	}
+	if key == nil {
+		return models.APIKey{}, models.ErrAPIKeyNotFound
+	}
+	return *key, nil
+}
+
+func (a *APIKeyStorage) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := a.adapter.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.ListAPIKeys.не удалось получить API ключи: %w", err)
+	}
+	return keys, nil
+}
+
+func (a *APIKeyStorage) Revoke(ctx context.Context, id int64, at time.Time) error {
+	err := a.adapter.RevokeAPIKey(ctx, id, at)
+	if err != nil {
+		return fmt.Errorf("Storage.RevokeAPIKey.не удалось отозвать API ключ: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"usdt/internal/models"
+)
+
+type ArbitrageStorage struct {
+	adapter ArbitrageStorager
+}
+
+func NewArbitrageStorage(adapter ArbitrageStorager) *ArbitrageStorage {
+	return &ArbitrageStorage{adapter: adapter}
+}
+
+func (a *ArbitrageStorage) Create(ctx context.Context, opportunity models.ArbitrageOpportunity) error {
+	err := a.adapter.CreateArbitrageOpportunity(ctx, opportunity)
+	if err != nil {
+		return fmt.Errorf("Storage.CreateArbitrageOpportunity.не удалось сохранить арбитражную возможность: %w", err)
+	}
+	return nil
+}
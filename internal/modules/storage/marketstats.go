@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+type MarketStatsStorage struct {
+	adapter MarketStatsStorager
+}
+
+func NewMarketStatsStorage(adapter MarketStatsStorager) *MarketStatsStorage {
+	return &MarketStatsStorage{adapter: adapter}
+}
+
+func (m *MarketStatsStorage) Create(ctx context.Context, stats models.MarketStats) error {
+	err := m.adapter.CreateMarketStats(ctx, stats)
+	if err != nil {
+		return fmt.Errorf("Storage.CreateMarketStats.не удалось сохранить метрики рынка: %w", err)
+	}
+	return nil
+}
+
+func (m *MarketStatsStorage) GetLatest(ctx context.Context, pair string) (models.MarketStats, error) {
+	stats, err := m.adapter.GetLatestMarketStats(ctx, pair)
+	if err != nil {
+		return models.MarketStats{}, fmt.Errorf("Storage.GetLatestMarketStats.не удалось получить метрики рынка: %w", err)
+	}
+	if stats == nil {
+		return models.MarketStats{}, nil
+	}
+	return *stats, nil
+}
+
+func (m *MarketStatsStorage) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error) {
+	stats, err := m.adapter.GetMarketStatsHistory(ctx, pair, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.GetMarketStatsHistory.не удалось получить историю метрик рынка: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+type OutboxStorage struct {
+	adapter OutboxStorager
+}
+
+func NewOutboxStorage(adapter OutboxStorager) *OutboxStorage {
+	return &OutboxStorage{adapter: adapter}
+}
+
+func (o *OutboxStorage) ListPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	events, err := o.adapter.ListPendingOutboxEvents(ctx, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.ListPending.не удалось получить события outbox: %w", err)
+	}
+	return events, nil
+}
+
+func (o *OutboxStorage) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	if err := o.adapter.MarkOutboxEventPublished(ctx, id, at); err != nil {
+		return fmt.Errorf("Storage.MarkPublished.не удалось отметить публикацию события: %w", err)
+	}
+	return nil
+}
+
+func (o *OutboxStorage) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	if err := o.adapter.MarkOutboxEventFailed(ctx, id, reason, nextAttemptAt); err != nil {
+		return fmt.Errorf("Storage.MarkFailed.не удалось записать ошибку публикации события: %w", err)
+	}
+	return nil
+}
+
+func (o *OutboxStorage) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := o.adapter.DeletePublishedOutboxEvents(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("Storage.DeletePublished.не удалось удалить опубликованные события: %w", err)
+	}
+	return deleted, nil
+}
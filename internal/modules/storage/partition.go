@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+type PartitionStorage struct {
+	adapter PartitionStorager
+}
+
+func NewPartitionStorage(adapter PartitionStorager) *PartitionStorage {
+	return &PartitionStorage{adapter: adapter}
+}
+
+func (p *PartitionStorage) ListPartitions(ctx context.Context) ([]models.Partition, error) {
+	partitions, err := p.adapter.ListCurrencyRatePartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.ListPartitions.не удалось получить секции: %w", err)
+	}
+	return partitions, nil
+}
+
+func (p *PartitionStorage) CreatePartition(ctx context.Context, month time.Time) (models.Partition, error) {
+	partition, err := p.adapter.CreateCurrencyRatePartition(ctx, month)
+	if err != nil {
+		return models.Partition{}, fmt.Errorf("Storage.CreatePartition.не удалось создать секцию: %w", err)
+	}
+	return partition, nil
+}
+
+func (p *PartitionStorage) DropPartition(ctx context.Context, name string) error {
+	if err := p.adapter.DropCurrencyRatePartition(ctx, name); err != nil {
+		return fmt.Errorf("Storage.DropPartition.не удалось удалить секцию: %w", err)
+	}
+	return nil
+}
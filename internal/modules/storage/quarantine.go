@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"usdt/internal/models"
+)
+
+type QuarantineStorage struct {
+	adapter QuarantineStorager
+}
+
+func NewQuarantineStorage(adapter QuarantineStorager) *QuarantineStorage {
+	return &QuarantineStorage{adapter: adapter}
+}
+
+func (q *QuarantineStorage) Create(ctx context.Context, rate models.QuarantinedRate) error {
+	err := q.adapter.CreateQuarantinedRate(ctx, rate)
+	if err != nil {
+		return fmt.Errorf("Storage.Quarantine.не удалось сохранить отклонённый курс: %w", err)
+	}
+	return nil
+}
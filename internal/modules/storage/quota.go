@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type QuotaStorage struct {
+	adapter QuotaStorager
+}
+
+func NewQuotaStorage(adapter QuotaStorager) *QuotaStorage {
+	return &QuotaStorage{adapter: adapter}
+}
+
+func (q *QuotaStorage) Increment(ctx context.Context, client string, day time.Time, limit int64) (bool, error) {
+	admitted, err := q.adapter.IncrementClientQuota(ctx, client, day, limit)
+	if err != nil {
+		return false, fmt.Errorf("Storage.IncrementQuota.не удалось учесть запрос клиента: %w", err)
+	}
+	return admitted, nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+type QuoteStorage struct {
+	adapter QuoteStorager
+}
+
+func NewQuoteStorage(adapter QuoteStorager) *QuoteStorage {
+	return &QuoteStorage{adapter: adapter}
+}
+
+func (q *QuoteStorage) Create(ctx context.Context, quote models.Quote) error {
+	err := q.adapter.CreateQuote(ctx, quote)
+	if err != nil {
+		return fmt.Errorf("Storage.CreateQuote.не удалось создать котировку: %w", err)
+	}
+	return nil
+}
+
+func (q *QuoteStorage) GetById(ctx context.Context, id string) (models.Quote, error) {
+	quote, err := q.adapter.GetQuote(ctx, id)
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("Storage.GetQuote.не удалось получить котировку по ID: %w", err)
+	}
+	if quote == nil {
+		return models.Quote{}, models.ErrQuoteNotFound
+	}
+	return *quote, nil
+}
+
+func (q *QuoteStorage) Redeem(ctx context.Context, id string, now time.Time) (models.Quote, error) {
+	quote, err := q.adapter.RedeemQuote(ctx, id, now)
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("Storage.RedeemQuote.не удалось погасить котировку: %w", err)
+	}
+	return *quote, nil
+}
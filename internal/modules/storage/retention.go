@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usdt/internal/models"
+)
+
+type RetentionStorage struct {
+	adapter RetentionStorager
+}
+
+func NewRetentionStorage(adapter RetentionStorager) *RetentionStorage {
+	return &RetentionStorage{adapter: adapter}
+}
+
+func (r *RetentionStorage) Downsample(ctx context.Context, before time.Time, limit int) (models.DownsampleResult, error) {
+	result, err := r.adapter.DownsampleCurrencyRates(ctx, before, limit)
+	if err != nil {
+		return models.DownsampleResult{}, fmt.Errorf("Storage.Downsample.не удалось проредить курсы: %w", err)
+	}
+	return result, nil
+}
+
+func (r *RetentionStorage) DeleteAggregates(ctx context.Context, resolution string, before time.Time, limit int) (int64, error) {
+	deleted, err := r.adapter.DeleteCurrencyRateAggregates(ctx, resolution, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("Storage.DeleteAggregates.не удалось удалить агрегаты: %w", err)
+	}
+	return deleted, nil
+}
+
+func (r *RetentionStorage) GetAggregates(ctx context.Context, pair, resolution string, from, to time.Time) ([]models.CurrencyRateAggregate, error) {
+	aggregates, err := r.adapter.GetCurrencyRateAggregates(ctx, pair, resolution, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.GetAggregates.не удалось получить агрегаты: %w", err)
+	}
+	return aggregates, nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"time"
 	"usdt/internal/models"
 )
 
@@ -14,24 +15,38 @@ func NewUsdtStorage(adapter UsdtStorager) *UsdtStorage {
 	return &UsdtStorage{adapter: adapter}
 }
 
-func (u *UsdtStorage) Create(ctx context.Context, rate models.CurrencyRate) error {
-	err := u.adapter.CreateCurrencyRate(ctx, rate)
+// Create сохраняет курс и возвращает сохранённую запись и признак того, был
+// ли снимок новым; повторный снимок с теми же парой, временем биржи и
+// поставщиком не сохраняется, а возвращается уже сохранённая запись.
+func (u *UsdtStorage) Create(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
+	stored, created, err := u.adapter.CreateCurrencyRate(ctx, rate)
 	if err != nil {
-		return fmt.Errorf("Storage.Create.не удалось создать запись курса валют: %w", err)
+		return models.CurrencyRate{}, false, fmt.Errorf("Storage.Create.не удалось создать запись курса валют: %w", err)
 	}
-	return nil
+	return stored, created, nil
 }
 
-func (u *UsdtStorage) Update(ctx context.Context, rate models.CurrencyRate) error {
-	err := u.adapter.UpdateCurrencyRate(ctx, rate)
+// CreateBatch сохраняет несколько курсов одним многострочным INSERT, пропуская
+// уже сохранённые снимки.
+func (u *UsdtStorage) CreateBatch(ctx context.Context, rates []models.CurrencyRate) error {
+	err := u.adapter.CreateCurrencyRates(ctx, rates)
 	if err != nil {
-		return fmt.Errorf("Storage.Update.не удалось обновить запись курса валют: %w", err)
+		return fmt.Errorf("Storage.CreateBatch.не удалось создать записи курсов валют: %w", err)
 	}
 	return nil
 }
 
-func (u *UsdtStorage) Delete(ctx context.Context, id int64) error {
-	err := u.adapter.DeleteCurrencyRate(ctx, id)
+// Update меняет курс с версией rate.Version и возвращает его новую версию.
+func (u *UsdtStorage) Update(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, error) {
+	updated, err := u.adapter.UpdateCurrencyRate(ctx, rate)
+	if err != nil {
+		return models.CurrencyRate{}, fmt.Errorf("Storage.Update.не удалось обновить запись курса валют: %w", err)
+	}
+	return *updated, nil
+}
+
+func (u *UsdtStorage) Delete(ctx context.Context, id, version int64) error {
+	err := u.adapter.DeleteCurrencyRate(ctx, id, version)
 	if err != nil {
 		return fmt.Errorf("Storage.Delete.не удалось удалить запись курса валют: %w", err)
 	}
@@ -67,3 +82,45 @@ func (u *UsdtStorage) GetAll(ctx context.Context) ([]models.CurrencyRate, error)
 	}
 	return rates, nil
 }
+
+func (u *UsdtStorage) GetHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	rates, err := u.adapter.GetCurrencyRateHistory(ctx, pair, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.GetHistory.не удалось получить историю курсов: %w", err)
+	}
+	return rates, nil
+}
+
+// GetLatest возвращает самый свежий по времени биржи курс пары; ok=false, если курсов ещё нет.
+func (u *UsdtStorage) GetLatest(ctx context.Context, pair string) (models.CurrencyRate, bool, error) {
+	rate, err := u.adapter.GetLatestCurrencyRate(ctx, pair)
+	if err != nil {
+		return models.CurrencyRate{}, false, fmt.Errorf("Storage.GetLatest.не удалось получить последний курс: %w", err)
+	}
+	if rate == nil {
+		return models.CurrencyRate{}, false, nil
+	}
+	return *rate, true, nil
+}
+
+// GetByKey возвращает сохранённый снимок с теми же парой, временем биржи и
+// поставщиком, что и rate; ok=false, если его нет.
+func (u *UsdtStorage) GetByKey(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
+	stored, err := u.adapter.GetCurrencyRateByKey(ctx, rate)
+	if err != nil {
+		return models.CurrencyRate{}, false, fmt.Errorf("Storage.GetByKey.не удалось получить курс по ключу снимка: %w", err)
+	}
+	if stored == nil {
+		return models.CurrencyRate{}, false, nil
+	}
+	return *stored, true, nil
+}
+
+// GetPage возвращает следующую страницу истории пары после курсора after.
+func (u *UsdtStorage) GetPage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error) {
+	rates, err := u.adapter.GetCurrencyRatePage(ctx, pair, from, to, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Storage.GetPage.не удалось получить страницу курсов: %w", err)
+	}
+	return rates, nil
+}
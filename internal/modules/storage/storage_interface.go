@@ -2,14 +2,80 @@ package storage
 
 import (
 	"context"
+	"time"
 	"usdt/internal/models"
 )
 
 type UsdtStorager interface {
-	CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) error
+	CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error)
+	CreateCurrencyRates(ctx context.Context, rates []models.CurrencyRate) error
 	GetCurrencyRate(ctx context.Context, id int64) (*models.CurrencyRate, error)
 	GetCurrencyRateByPair(ctx context.Context, pair string) (*models.CurrencyRate, error)
 	GetAllCurrencyRates(ctx context.Context) ([]models.CurrencyRate, error)
-	UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) error
-	DeleteCurrencyRate(ctx context.Context, id int64) error
+	UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error)
+	DeleteCurrencyRate(ctx context.Context, id, version int64) error
+	GetCurrencyRateHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error)
+	GetLatestCurrencyRate(ctx context.Context, pair string) (*models.CurrencyRate, error)
+	GetCurrencyRateByKey(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error)
+	GetCurrencyRatePage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error)
+}
+
+type QuoteStorager interface {
+	CreateQuote(ctx context.Context, quote models.Quote) error
+	GetQuote(ctx context.Context, id string) (*models.Quote, error)
+	RedeemQuote(ctx context.Context, id string, now time.Time) (*models.Quote, error)
+}
+
+type AlertStorager interface {
+	CreateAlertRule(ctx context.Context, rule *models.AlertRule) error
+	GetAlertRule(ctx context.Context, id int64) (*models.AlertRule, error)
+	ListAlertRules(ctx context.Context, pair string) ([]models.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule models.AlertRule) error
+	DeleteAlertRule(ctx context.Context, id int64) error
+	CreateAlertDelivery(ctx context.Context, delivery models.AlertDelivery) error
+	ListAlertDeliveries(ctx context.Context, ruleID int64, limit int) ([]models.AlertDelivery, error)
+}
+
+type QuarantineStorager interface {
+	CreateQuarantinedRate(ctx context.Context, rate models.QuarantinedRate) error
+}
+
+type MarketStatsStorager interface {
+	CreateMarketStats(ctx context.Context, stats models.MarketStats) error
+	GetLatestMarketStats(ctx context.Context, pair string) (*models.MarketStats, error)
+	GetMarketStatsHistory(ctx context.Context, pair string, from, to time.Time) ([]models.MarketStats, error)
+}
+
+type ArbitrageStorager interface {
+	CreateArbitrageOpportunity(ctx context.Context, opportunity models.ArbitrageOpportunity) error
+}
+
+type RetentionStorager interface {
+	DownsampleCurrencyRates(ctx context.Context, before time.Time, limit int) (models.DownsampleResult, error)
+	DeleteCurrencyRateAggregates(ctx context.Context, resolution string, before time.Time, limit int) (int64, error)
+	GetCurrencyRateAggregates(ctx context.Context, pair, resolution string, from, to time.Time) ([]models.CurrencyRateAggregate, error)
+}
+
+type PartitionStorager interface {
+	ListCurrencyRatePartitions(ctx context.Context) ([]models.Partition, error)
+	CreateCurrencyRatePartition(ctx context.Context, month time.Time) (models.Partition, error)
+	DropCurrencyRatePartition(ctx context.Context, name string) error
+}
+
+type OutboxStorager interface {
+	ListPendingOutboxEvents(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64, at time.Time) error
+	MarkOutboxEventFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error
+	DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type APIKeyStorager interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64, at time.Time) error
+}
+
+type QuotaStorager interface {
+	IncrementClientQuota(ctx context.Context, client string, day time.Time, limit int64) (bool, error)
 }
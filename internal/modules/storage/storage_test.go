@@ -16,18 +16,26 @@ type MockDbAdapter struct {
 	mock.Mock
 }
 
-func (m *MockDbAdapter) CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) error {
+func (m *MockDbAdapter) CreateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (models.CurrencyRate, bool, error) {
 	args := m.Called(ctx, rate)
+	return args.Get(0).(models.CurrencyRate), args.Bool(1), args.Error(2)
+}
+
+func (m *MockDbAdapter) CreateCurrencyRates(ctx context.Context, rates []models.CurrencyRate) error {
+	args := m.Called(ctx, rates)
 	return args.Error(0)
 }
 
-func (m *MockDbAdapter) UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) error {
+func (m *MockDbAdapter) UpdateCurrencyRate(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error) {
 	args := m.Called(ctx, rate)
-	return args.Error(0)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CurrencyRate), args.Error(1)
 }
 
-func (m *MockDbAdapter) DeleteCurrencyRate(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
+func (m *MockDbAdapter) DeleteCurrencyRate(ctx context.Context, id, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
@@ -61,44 +69,128 @@ func (m *MockDbAdapter) GetAllCurrencyRates(ctx context.Context) ([]models.Curre
 	return args.Get(0).([]models.CurrencyRate), nil
 }
 
+func (m *MockDbAdapter) GetCurrencyRateHistory(ctx context.Context, pair string, from, to time.Time) ([]models.CurrencyRate, error) {
+	args := m.Called(ctx, pair, from, to)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CurrencyRate), nil
+}
+
+func (m *MockDbAdapter) GetCurrencyRatePage(ctx context.Context, pair string, from, to time.Time, after models.RateCursor, limit int) ([]models.CurrencyRate, error) {
+	args := m.Called(ctx, pair, from, to, after, limit)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CurrencyRate), nil
+}
+
+func (m *MockDbAdapter) GetLatestCurrencyRate(ctx context.Context, pair string) (*models.CurrencyRate, error) {
+	args := m.Called(ctx, pair)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	if args.Get(0) == nil {
+		return nil, nil
+	}
+	return args.Get(0).(*models.CurrencyRate), nil
+}
+
+func (m *MockDbAdapter) GetCurrencyRateByKey(ctx context.Context, rate models.CurrencyRate) (*models.CurrencyRate, error) {
+	args := m.Called(ctx, rate)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	if args.Get(0) == nil {
+		return nil, nil
+	}
+	return args.Get(0).(*models.CurrencyRate), nil
+}
+
 func TestUsdtStorage_Create(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockAdapter := new(MockDbAdapter)
 		storage := NewUsdtStorage(mockAdapter)
 		rate := models.CurrencyRate{Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now()}
-		mockAdapter.On("CreateCurrencyRate", mock.Anything, rate).Return(nil)
-		err := storage.Create(context.Background(), rate)
+		stored := rate
+		stored.ID, stored.Version = 1, 1
+		mockAdapter.On("CreateCurrencyRate", mock.Anything, rate).Return(stored, true, nil)
+		got, created, err := storage.Create(context.Background(), rate)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, stored, got)
+	})
+	t.Run("Duplicate", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		rate := models.CurrencyRate{Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now()}
+		mockAdapter.On("CreateCurrencyRate", mock.Anything, rate).Return(rate, false, nil)
+		_, created, err := storage.Create(context.Background(), rate)
 		assert.NoError(t, err)
+		assert.False(t, created)
 	})
 	t.Run("Error", func(t *testing.T) {
 		mockAdapter := new(MockDbAdapter)
 		storage := NewUsdtStorage(mockAdapter)
 		rate := models.CurrencyRate{Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now()}
-		mockAdapter.On("CreateCurrencyRate", mock.Anything, rate).Return(errors.New("db error"))
-		err := storage.Create(context.Background(), rate)
+		mockAdapter.On("CreateCurrencyRate", mock.Anything, rate).Return(models.CurrencyRate{}, false, errors.New("db error"))
+		_, _, err := storage.Create(context.Background(), rate)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "db error")
 	})
 }
 
+func TestUsdtStorage_CreateBatch(t *testing.T) {
+	rates := []models.CurrencyRate{
+		{Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now()},
+		{Pair: "USDT/RUB", AskPrice: 95.1, BidPrice: 95.0, Timestamp: time.Now()},
+	}
+	t.Run("Success", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("CreateCurrencyRates", mock.Anything, rates).Return(nil)
+		err := storage.CreateBatch(context.Background(), rates)
+		assert.NoError(t, err)
+	})
+	t.Run("Error", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("CreateCurrencyRates", mock.Anything, rates).Return(errors.New("db error"))
+		err := storage.CreateBatch(context.Background(), rates)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Storage.CreateBatch")
+	})
+}
+
 func TestUsdtStorage_Update(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockAdapter := new(MockDbAdapter)
 		storage := NewUsdtStorage(mockAdapter)
-		rate := models.CurrencyRate{Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now()}
-		mockAdapter.On("UpdateCurrencyRate", mock.Anything, rate).Return(nil)
-		err := storage.Update(context.Background(), rate)
+		rate := models.CurrencyRate{ID: 1, Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now(), Version: 1}
+		updated := rate
+		updated.Version = 2
+		mockAdapter.On("UpdateCurrencyRate", mock.Anything, rate).Return(&updated, nil)
+		result, err := storage.Update(context.Background(), rate)
 		assert.NoError(t, err)
+		assert.Equal(t, updated, result)
 	})
 	t.Run("Error", func(t *testing.T) {
 		mockAdapter := new(MockDbAdapter)
 		storage := NewUsdtStorage(mockAdapter)
 		rate := models.CurrencyRate{Pair: "USDT/USD", AskPrice: 10.1, BidPrice: 10.0, Timestamp: time.Now()}
-		mockAdapter.On("UpdateCurrencyRate", mock.Anything, rate).Return(errors.New("db error"))
-		err := storage.Update(context.Background(), rate)
+		mockAdapter.On("UpdateCurrencyRate", mock.Anything, rate).Return(nil, errors.New("db error"))
+		_, err := storage.Update(context.Background(), rate)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "db error")
 	})
+	t.Run("VersionConflict", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		rate := models.CurrencyRate{ID: 1, Version: 1}
+		mockAdapter.On("UpdateCurrencyRate", mock.Anything, rate).Return(nil, models.ErrRateVersionConflict)
+		_, err := storage.Update(context.Background(), rate)
+		assert.ErrorIs(t, err, models.ErrRateVersionConflict)
+	})
 }
 
 func TestUsdtStorage_Delete(t *testing.T) {
@@ -106,16 +198,16 @@ func TestUsdtStorage_Delete(t *testing.T) {
 		mockAdapter := new(MockDbAdapter)
 		storage := NewUsdtStorage(mockAdapter)
 		testID := int64(1)
-		mockAdapter.On("DeleteCurrencyRate", mock.Anything, testID).Return(nil)
-		err := storage.Delete(context.Background(), testID)
+		mockAdapter.On("DeleteCurrencyRate", mock.Anything, testID, int64(3)).Return(nil)
+		err := storage.Delete(context.Background(), testID, 3)
 		assert.NoError(t, err)
 	})
 	t.Run("Error", func(t *testing.T) {
 		mockAdapter := new(MockDbAdapter)
 		storage := NewUsdtStorage(mockAdapter)
 		testID := int64(1)
-		mockAdapter.On("DeleteCurrencyRate", mock.Anything, testID).Return(errors.New("db error"))
-		err := storage.Delete(context.Background(), testID)
+		mockAdapter.On("DeleteCurrencyRate", mock.Anything, testID, int64(3)).Return(errors.New("db error"))
+		err := storage.Delete(context.Background(), testID, 3)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "db error")
 	})
@@ -207,3 +299,109 @@ func TestUsdtStorage_GetAll(t *testing.T) {
 		assert.Contains(t, err.Error(), "db error")
 	})
 }
+
+func TestUsdtStorage_GetHistory(t *testing.T) {
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	t.Run("Success", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		expectedRates := []models.CurrencyRate{
+			{Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: from},
+			{Pair: "USDT/RUB", AskPrice: 101, BidPrice: 100, Timestamp: to},
+		}
+		mockAdapter.On("GetCurrencyRateHistory", mock.Anything, "USDT/RUB", from, to).Return(expectedRates, nil)
+		rates, err := storage.GetHistory(context.Background(), "USDT/RUB", from, to)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedRates, rates)
+	})
+	t.Run("Error", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("GetCurrencyRateHistory", mock.Anything, "USDT/RUB", from, to).Return(nil, errors.New("db error"))
+		_, err := storage.GetHistory(context.Background(), "USDT/RUB", from, to)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "db error")
+	})
+}
+
+func TestUsdtStorage_GetLatest(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		expectedRate := models.CurrencyRate{Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: time.Now()}
+		mockAdapter.On("GetLatestCurrencyRate", mock.Anything, "USDT/RUB").Return(&expectedRate, nil)
+		rate, ok, err := storage.GetLatest(context.Background(), "USDT/RUB")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, expectedRate, rate)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("GetLatestCurrencyRate", mock.Anything, "USDT/RUB").Return(nil, nil)
+		_, ok, err := storage.GetLatest(context.Background(), "USDT/RUB")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("Error", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("GetLatestCurrencyRate", mock.Anything, "USDT/RUB").Return(nil, errors.New("db error"))
+		_, _, err := storage.GetLatest(context.Background(), "USDT/RUB")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "db error")
+	})
+}
+
+func TestUsdtStorage_GetByKey(t *testing.T) {
+	key := models.CurrencyRate{Pair: "USDT/RUB", Source: "garantex", Timestamp: time.Now()}
+	t.Run("Success", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		expectedRate := models.CurrencyRate{ID: 3, Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: key.Timestamp, Source: "garantex"}
+		mockAdapter.On("GetCurrencyRateByKey", mock.Anything, key).Return(&expectedRate, nil)
+		rate, ok, err := storage.GetByKey(context.Background(), key)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, expectedRate, rate)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("GetCurrencyRateByKey", mock.Anything, key).Return(nil, nil)
+		_, ok, err := storage.GetByKey(context.Background(), key)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("Error", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("GetCurrencyRateByKey", mock.Anything, key).Return(nil, errors.New("db error"))
+		_, _, err := storage.GetByKey(context.Background(), key)
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestUsdtStorage_GetPage(t *testing.T) {
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	after := models.RateCursor{Timestamp: from, ID: 10}
+	t.Run("Success", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		expectedRates := []models.CurrencyRate{{ID: 11, Pair: "USDT/RUB", AskPrice: 100, BidPrice: 99, Timestamp: to}}
+		mockAdapter.On("GetCurrencyRatePage", mock.Anything, "USDT/RUB", from, to, after, 100).Return(expectedRates, nil)
+		rates, err := storage.GetPage(context.Background(), "USDT/RUB", from, to, after, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedRates, rates)
+	})
+	t.Run("Error", func(t *testing.T) {
+		mockAdapter := new(MockDbAdapter)
+		storage := NewUsdtStorage(mockAdapter)
+		mockAdapter.On("GetCurrencyRatePage", mock.Anything, "USDT/RUB", from, to, after, 100).Return(nil, errors.New("db error"))
+		_, err := storage.GetPage(context.Background(), "USDT/RUB", from, to, after, 100)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "db error")
+	})
+}
@@ -1,28 +1,61 @@
 package run
 
 import (
+	"context"
 	"fmt"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 	"usdt/config"
 	"usdt/internal/db"
+	"usdt/internal/gateway"
+	"usdt/internal/infrastructure/metrics"
+	"usdt/internal/infrastructure/outbox"
 	"usdt/internal/infrastructure/requestAPI/garantex"
+	"usdt/internal/infrastructure/requestAPI/httpclient"
+	"usdt/internal/infrastructure/requestAPI/replay"
+	"usdt/internal/infrastructure/requestAPI/simulator"
+	"usdt/internal/infrastructure/tlsconfig"
+	"usdt/internal/infrastructure/webhook"
 	"usdt/internal/modules/controller"
+	"usdt/internal/modules/poller"
 	"usdt/internal/modules/service"
 	"usdt/internal/modules/storage"
 	proto "usdt/internal/proto/usdt_proto"
 )
 
-func shutdown(adapter *db.DbAdapter, logger *zap.Logger, grpcServer *grpc.Server) {
+// shutdownFlushTimeout ограничивает запись оставшихся в буфере курсов при остановке.
+const shutdownFlushTimeout = 10 * time.Second
+
+func shutdown(adapter *db.DbAdapter, logger *zap.Logger, grpcServer *grpc.Server, gatewayServer *http.Server, stopWorkers func()) {
 	logger.Info("Получен сигнал завершения работы. Начинаем graceful shutdown...")
+	if gatewayServer != nil {
+		// Шлюз останавливается первым: его запросы идут через gRPC сервер.
+		logger.Info("Остановка REST шлюза...")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+		if err := gatewayServer.Shutdown(ctx); err != nil {
+			logger.Error("Ошибка остановки REST шлюза:", zap.Error(err))
+		}
+		cancel()
+		logger.Info("REST шлюз остановлен.")
+	}
 	logger.Info("Остановка gRPC сервера...")
 	grpcServer.GracefulStop() // Используем ctx
 	logger.Info("gRPC сервер остановлен.")
+	logger.Info("Остановка фоновых задач...")
+	stopWorkers()
+	logger.Info("Фоновые задачи остановлены.")
 	logger.Info("Закрытие соединения с базой данных...")
 	err := adapter.Close()
 	if err != nil {
@@ -32,12 +65,298 @@ func shutdown(adapter *db.DbAdapter, logger *zap.Logger, grpcServer *grpc.Server
 	logger.Info("Сервис завершил работу.")
 }
 
+// serveMetrics запускает HTTP-сервер метрик Prometheus; пустой METRICS_PORT отключает его.
+func serveMetrics(conf config.Config, logger *zap.Logger) *http.Server {
+	if conf.MetricsPort == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Addr: fmt.Sprintf(":%s", conf.MetricsPort), Handler: mux}
+	go func() {
+		logger.Info(fmt.Sprintf("Metrics server started on port: %s", conf.MetricsPort))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error(fmt.Sprintf("failed to serve metrics: %v", err))
+		}
+	}()
+	return server
+}
+
+// serveGateway запускает REST шлюз, который обращается к gRPC серверу этого
+// же процесса через локальный порт; пустой GATEWAY_PORT отключает его.
+func serveGateway(conf config.Config, logger *zap.Logger) *http.Server {
+	if conf.GatewayPort == "" {
+		return nil
+	}
+	creds := insecure.NewCredentials()
+	if conf.TLS.Enabled() {
+		tlsConf, err := tlsconfig.Loopback(conf.TLS)
+		if err != nil {
+			log.Fatalf("failed to configure gateway TLS: %v", err)
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%s", conf.Port), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatalf("failed to connect gateway: %v", err)
+	}
+	handler, err := gateway.NewHandler(context.Background(), conn, conf.GatewaySecret)
+	if err != nil {
+		log.Fatalf("failed to configure gateway: %v", err)
+	}
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%s", conf.GatewayPort),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	server.RegisterOnShutdown(func() { conn.Close() })
+	go func() {
+		logger.Info(fmt.Sprintf("REST gateway started on port: %s", conf.GatewayPort))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error(fmt.Sprintf("failed to serve gateway: %v", err))
+		}
+	}()
+	return server
+}
+
+// newOutboxSink создаёт приёмник событий outbox по conf.Sink. Приёмник
+// брокера собирается из outbox.NewBrokerSink с клиентом конкретного брокера.
+func newOutboxSink(conf config.Outbox, timeout time.Duration) (service.OutboxSink, error) {
+	switch conf.Sink {
+	case config.SinkWebhook:
+		if conf.WebhookURL == "" {
+			return nil, fmt.Errorf("не задан %s", config.OutboxURL)
+		}
+		return outbox.NewWebhookSink(conf.WebhookURL, conf.WebhookSecret, timeout), nil
+	case config.SinkFile:
+		return outbox.NewFileSink(conf.FilePath), nil
+	default:
+		return nil, fmt.Errorf("неизвестный приёмник событий outbox: %s", conf.Sink)
+	}
+}
+
+// newVenues собирает биржи поиска арбитража из conf.Arbitrage.Venues. Биржа
+// source, выбранная EXCHANGE_MODE, используется тем же клиентом api, а
+// garantex в других режимах - REST клиентом rest.
+func newVenues(conf config.Config, source string, api, rest service.RequestAPI) ([]service.Venue, error) {
+	if len(conf.Arbitrage.Venues) < 2 {
+		return nil, fmt.Errorf("в %s нужны минимум две биржи, задано: %s", config.ArbVenues,
+			strings.Join(conf.Arbitrage.Venues, ","))
+	}
+	venues := make([]service.Venue, 0, len(conf.Arbitrage.Venues))
+	seen := make(map[string]bool, len(conf.Arbitrage.Venues))
+	for _, name := range conf.Arbitrage.Venues {
+		if seen[name] {
+			return nil, fmt.Errorf("биржа %s указана в %s дважды", name, config.ArbVenues)
+		}
+		seen[name] = true
+		venue := service.Venue{Name: name, FeePercent: conf.Arbitrage.Fees[name]}
+		switch {
+		case name == source:
+			venue.API = api
+		case name == garantex.Name:
+			venue.API = rest
+		case name == garantex.ReplayName:
+			exchanges, err := replay.Load(conf.Exchange.ReplayFile)
+			if err != nil {
+				return nil, err
+			}
+			venue.API = garantex.NewReplayAPI(replay.NewTransport(exchanges, conf.Exchange.ReplayFrom, conf.Exchange.ReplaySpeed))
+		case name == simulator.Name:
+			venue.API = simulator.NewMarket(conf.Simulation)
+		case strings.HasPrefix(name, simulator.Name+":"):
+			seed, err := strconv.ParseInt(strings.TrimPrefix(name, simulator.Name+":"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("неверный seed биржи %s: %w", name, err)
+			}
+			sim := conf.Simulation
+			sim.Seed = seed
+			venue.API = simulator.NewMarket(sim)
+		default:
+			return nil, fmt.Errorf("неизвестная биржа арбитража: %s", name)
+		}
+		venues = append(venues, venue)
+	}
+	return venues, nil
+}
+
 func Run(adapter *db.DbAdapter, logger *zap.Logger, conf config.Config, grpcServer *grpc.Server) {
-	storageusddt := storage.NewUsdtStorage(adapter)
-	api := garantex.NewGrantexAPI("")
-	serviceusdt := service.NewUsdtService(storageusddt, api)
+	var rateAdapter storage.UsdtStorager = adapter
+	if conf.StorageBackend == config.StorageMemory {
+		logger.Info("Курсы и остальные данные хранятся в памяти и будут потеряны при перезапуске")
+		rateAdapter = db.NewMemoryAdapter()
+	}
+	storageusddt := storage.NewUsdtStorage(rateAdapter)
+	// Лимитер биржи общий для опроса, котировок и поиска арбитража.
+	limiter := httpclient.NewLimiter(conf.Exchange.Limits[garantex.Name])
+	client, err := httpclient.NewClient(conf.HTTPClient)
+	if err != nil {
+		log.Fatalf("failed to configure exchange client: %v", err)
+	}
+	var recorder *replay.Recorder
+	if conf.Exchange.RecordFile != "" {
+		recorder, err = replay.NewRecorder(client.Transport, conf.Exchange.RecordFile, logger)
+		if err != nil {
+			log.Fatalf("failed to configure exchange recording: %v", err)
+		}
+		client.Transport = recorder
+	}
+	source := garantex.Name
+	rest := garantex.NewGrantexAPI("", client, httpclient.NewRetrier(limiter, conf.Exchange))
+	var api service.RequestAPI = rest
+	// В потоковом режиме стаканы ведутся по подписке WebSocket, а курсы
+	// сохраняются при каждом изменении стакана вместо опроса по интервалу.
+	var stream *garantex.Stream
+	switch conf.Exchange.Mode {
+	case config.ExchangePoll:
+	case config.ExchangeStream:
+		// Протокол потока синтетический и не сверен с биржей, поэтому адреса
+		// по умолчанию нет: поток подключается только к явно заданному серверу.
+		if conf.Exchange.StreamURL == "" {
+			log.Fatalf("%s=%s requires %s", config.ExMode, config.ExchangeStream, config.ExStreamURL)
+		}
+		dialer, err := httpclient.NewDialer(conf.HTTPClient)
+		if err != nil {
+			log.Fatalf("failed to configure exchange stream: %v", err)
+		}
+		stream = garantex.NewStream(conf.Exchange, dialer, rest, conf.Poll.Currencies, logger)
+		api = stream
+	case config.ExchangeReplay:
+		exchanges, err := replay.Load(conf.Exchange.ReplayFile)
+		if err != nil {
+			log.Fatalf("failed to load exchange replay: %v", err)
+		}
+		logger.Info("Курсы воспроизводятся из записи обменов", zap.String("file", conf.Exchange.ReplayFile))
+		api = garantex.NewReplayAPI(replay.NewTransport(exchanges, conf.Exchange.ReplayFrom, conf.Exchange.ReplaySpeed))
+		source = garantex.ReplayName
+	case config.ExchangeSim:
+		logger.Info("Курсы генерируются симуляцией биржи", zap.Int64("seed", conf.Simulation.Seed))
+		api, source = simulator.NewMarket(conf.Simulation), simulator.Name
+	default:
+		log.Fatalf("unknown exchange mode: %s", conf.Exchange.Mode)
+	}
+	validator := service.NewRateValidator(storageusddt, storage.NewQuarantineStorage(adapter), conf.Validation)
+	statsStorage := storage.NewMarketStatsStorage(adapter)
+	serviceusdt := service.NewUsdtService(storageusddt, api, source, validator, statsStorage, logger)
+	// При пакетной записи курсы опроса копятся в batchWriter и пишутся
+	// многострочными INSERT. GetRates и котировки сохраняют курс сразу: их
+	// вызывающий должен получить сохранённую запись или ошибку записи.
+	var pollSource poller.RateSource = serviceusdt
+	var batchWriter *service.BatchWriter
+	var flusher poller.Flusher
+	if conf.Batch.Size > 1 {
+		batchWriter = service.NewBatchWriter(storageusddt, conf.Batch, logger)
+		pollSource = service.NewUsdtService(batchWriter, api, source, validator, batchWriter.DeferStats(statsStorage), logger)
+		flusher = batchWriter
+	}
 	controllerusdt := controller.NewController(serviceusdt, logger)
 	proto.RegisterAuthServiceServer(grpcServer, controllerusdt)
+	quoteStorage := storage.NewQuoteStorage(adapter)
+	quoteService := service.NewQuoteService(quoteStorage, serviceusdt, conf.Quote.TTL, conf.Quote.MarkupPercent)
+	proto.RegisterQuoteServiceServer(grpcServer, controller.NewQuoteController(quoteService, logger))
+	proto.RegisterMarketServiceServer(grpcServer, controller.NewMarketStatsController(service.NewMarketStatsService(statsStorage), logger))
+	alertStorage := storage.NewAlertStorage(adapter)
+	alertService := service.NewAlertService(alertStorage, storageusddt, webhook.NewSender(conf.Alert.WebhookTimeout, conf.Alert.AllowPrivate), logger,
+		conf.Alert.MaxAttempts, conf.Alert.RetryBackoff)
+	proto.RegisterAlertServiceServer(grpcServer, controller.NewAlertController(alertService, logger))
+	// Без аутентификации любой клиент мог бы менять и удалять курсы и выпускать
+	// ключи, поэтому эти сервисы регистрируются только при AUTH_ENABLED=true.
+	if conf.Auth.Enabled {
+		rateAdminService := service.NewRateAdminService(storageusddt, storageusddt)
+		proto.RegisterRateAdminServiceServer(grpcServer, controller.NewRateAdminController(rateAdminService, logger))
+		keyService := service.NewAPIKeyService(storage.NewAPIKeyStorage(adapter), conf.Auth.JWTSecret)
+		proto.RegisterKeyServiceServer(grpcServer, controller.NewKeyController(keyService, logger))
+	} else {
+		logger.Warn("RateAdminService и KeyService отключены: аутентификация выключена")
+	}
+	exportService := service.NewExportService(storageusddt, conf.Export.PageSize)
+	proto.RegisterExportServiceServer(grpcServer, controller.NewExportController(exportService, logger))
+	var venues []service.Venue
+	if conf.Arbitrage.Interval > 0 && len(conf.Arbitrage.Venues) > 0 {
+		venues, err = newVenues(conf, source, api, rest)
+		if err != nil {
+			log.Fatalf("failed to configure arbitrage: %v", err)
+		}
+	} else {
+		logger.Info("Поиск арбитража отключён")
+	}
+	arbitrageService := service.NewArbitrageService(storage.NewArbitrageStorage(adapter), venues, conf.Poll.Currencies,
+		conf.Arbitrage.Interval, logger)
+	proto.RegisterArbitrageServiceServer(grpcServer, controller.NewArbitrageController(arbitrageService, logger))
+	retentionService := service.NewRetentionService(storage.NewRetentionStorage(adapter), conf.Retention, logger)
+	partitionService := service.NewPartitionService(storage.NewPartitionStorage(adapter), conf.Partition, logger)
+	var snapshotHandlers []poller.SnapshotHandler
+	if batchWriter != nil {
+		// Курс из буфера ещё может не попасть в базу, поэтому правила проверяются после записи пакета.
+		batchWriter.OnCommit(alertService.HandleSnapshot)
+	} else {
+		snapshotHandlers = append(snapshotHandlers, alertService)
+	}
+	ratePoller := poller.NewPoller(pollSource, flusher, conf.Poll.Currencies, conf.Poll.Interval, logger, snapshotHandlers...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	backgroundWorkers := []func(context.Context){alertService.Run}
+	if len(venues) > 0 {
+		backgroundWorkers = append(backgroundWorkers, arbitrageService.Run)
+	}
+	unsubscribe := func() {}
+	if stream != nil {
+		var updates <-chan string
+		updates, unsubscribe = stream.Subscribe()
+		follow := func(ctx context.Context) { ratePoller.Follow(ctx, updates, conf.Exchange.StreamMinInterval) }
+		backgroundWorkers = append(backgroundWorkers, stream.Run, follow)
+	} else {
+		backgroundWorkers = append(backgroundWorkers, ratePoller.Run)
+	}
+	if conf.Db.Driver == config.DriverSQLite {
+		// Прореживание и секционирование используют возможности Postgres.
+		logger.Info("Очистка истории и обслуживание секций недоступны для SQLite")
+	} else {
+		backgroundWorkers = append(backgroundWorkers, retentionService.Run, partitionService.Run)
+	}
+	if batchWriter != nil {
+		backgroundWorkers = append(backgroundWorkers, batchWriter.Run)
+	}
+	if conf.Outbox.Enabled {
+		if conf.StorageBackend == config.StorageMemory {
+			// События пишутся в одной транзакции с курсом, а курсы в памяти вне базы.
+			log.Fatalf("outbox is not supported with %s=%s", config.StorageBackend, config.StorageMemory)
+		}
+		sink, err := newOutboxSink(conf.Outbox, conf.Alert.WebhookTimeout)
+		if err != nil {
+			log.Fatalf("failed to configure outbox: %v", err)
+		}
+		relay := service.NewOutboxRelay(storage.NewOutboxStorage(adapter), sink, conf.Outbox, logger)
+		backgroundWorkers = append(backgroundWorkers, relay.Run)
+	}
+	for _, worker := range backgroundWorkers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(ctx)
+		}()
+	}
+	metricsServer := serveMetrics(conf, logger)
+	gatewayServer := serveGateway(conf, logger)
+	stopWorkers := func() {
+		cancel()
+		workers.Wait()
+		unsubscribe()
+		if batchWriter != nil {
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+			if err := batchWriter.Flush(flushCtx); err != nil {
+				logger.Error("Ошибка записи оставшихся курсов:", zap.Error(err))
+			}
+			flushCancel()
+		}
+		if metricsServer != nil {
+			metricsServer.Close()
+		}
+		if recorder != nil {
+			recorder.Close()
+		}
+	}
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", conf.Port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
@@ -49,7 +368,7 @@ func Run(adapter *db.DbAdapter, logger *zap.Logger, conf config.Config, grpcServ
 
 	go func() {
 		<-quit
-		shutdown(adapter, logger, grpcServer)
+		shutdown(adapter, logger, grpcServer, gatewayServer, stopWorkers)
 	}()
 
 	logger.Info(fmt.Sprintf("USDT service started on port: %s", conf.Port))
@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"usdt/internal/infrastructure/tlsconfig"
+	"usdt/internal/modules/interceptor"
 	"usdt/internal/proto/usdt_proto"
 )
 
@@ -48,7 +54,31 @@ func (c *RateController) HealthCheck() (string, error) {
 }
 
 func main() {
-	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
+	addr := flag.String("addr", "localhost:50051", "адрес gRPC сервера")
+	useTLS := flag.Bool("tls", false, "подключаться по TLS")
+	caFile := flag.String("ca", "", "CA для проверки сервера (по умолчанию системные)")
+	certFile := flag.String("cert", "", "клиентский сертификат для взаимного TLS")
+	keyFile := flag.String("key", "", "ключ клиентского сертификата")
+	serverName := flag.String("server-name", "", "имя сервера в сертификате, если отличается от адреса")
+	apiKey := flag.String("api-key", "", "API ключ при включённой аутентификации")
+	flag.Parse()
+
+	creds := insecure.NewCredentials()
+	if *useTLS {
+		tlsConf, err := tlsconfig.Client(*caFile, *certFile, *keyFile, *serverName)
+		if err != nil {
+			log.Fatalf("TLS: %v", err)
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if *apiKey != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+			ctx = metadata.AppendToOutgoingContext(ctx, interceptor.APIKeyHeader, *apiKey)
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}))
+	}
+	conn, err := grpc.NewClient(*addr, opts...)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}